@@ -26,6 +26,9 @@ var (
 		Name:    "myapp_http_request_duration_seconds",
 		Help:    "HTTP request latency.",
 		Buckets: prometheus.DefBuckets,
+		// Also expose a native histogram for Prometheus servers that
+		// scrape them, to exercise heatmap panels with both formats.
+		NativeHistogramBucketFactor: 1.1,
 	}, []string{"method", "path"})
 
 	httpRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
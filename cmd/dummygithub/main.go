@@ -23,6 +23,7 @@ func main() {
 	http.HandleFunc("GET /api/v3/user", handleUser)
 	http.HandleFunc("GET /api/v3/user/emails", handleEmails)
 	http.HandleFunc("GET /api/v3/user/orgs", handleOrgs)
+	http.HandleFunc("GET /api/v3/user/teams", handleTeams)
 
 	slog.Info("dummy github server starting", "port", port)
 	if err := http.ListenAndServe(":"+port, nil); err != nil {
@@ -111,3 +112,17 @@ func handleOrgs(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// handleTeams returns a fixed team list.
+func handleTeams(w http.ResponseWriter, r *http.Request) {
+	slog.Info("user teams requested")
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+		{
+			"slug":         "sre",
+			"name":         "SRE",
+			"organization": map[string]interface{}{"login": "dummy-org"},
+		},
+	})
+}
@@ -0,0 +1,268 @@
+// dummyloki is a fake Loki query API server that returns synthetic application
+// logs. It supports log queries (streams) and a few metric LogQL queries
+// (matrix), which is enough to demo and e2e-test Dashyard's logs panel
+// offline. Similar in spirit to cmd/dummyprom.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	port := "3100"
+	if p := os.Getenv("PORT"); p != "" {
+		port = p
+	}
+
+	http.HandleFunc("GET /loki/api/v1/query_range", handleQueryRange)
+	http.HandleFunc("GET /loki/api/v1/labels", handleLabels)
+	http.HandleFunc("GET /loki/api/v1/label/{name}/values", handleLabelValues)
+	http.HandleFunc("GET /ready", handleReady)
+
+	slog.Info("dummy loki server starting", "port", port)
+	if err := http.ListenAndServe(":"+port, nil); err != nil {
+		slog.Error("server error", "error", err)
+		os.Exit(1)
+	}
+}
+
+func handleReady(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ready"))
+}
+
+// streamLabels are the label sets of the synthetic log streams.
+var streamLabels = []map[string]string{
+	{"app": "web", "level": "info"},
+	{"app": "web", "level": "error"},
+	{"app": "api", "level": "info"},
+	{"app": "api", "level": "warn"},
+	{"app": "worker", "level": "info"},
+}
+
+var messages = map[string][]string{
+	"info": {
+		"GET /api/users 200 12ms",
+		"GET /health 200 1ms",
+		"POST /api/search 200 87ms",
+		"job completed id=%d",
+	},
+	"warn": {
+		"slow query took 1.2s id=%d",
+		"retrying upstream request attempt=2",
+	},
+	"error": {
+		"GET /api/reports 500 upstream timeout",
+		"database connection reset id=%d",
+	},
+}
+
+var (
+	matcherRe    = regexp.MustCompile(`(\w+)\s*=\s*"([^"]*)"`)
+	lineFilterRe = regexp.MustCompile(`\|=\s*"([^"]*)"`)
+	metricRe     = regexp.MustCompile(`\b(rate|count_over_time|bytes_over_time|bytes_rate)\s*\(`)
+)
+
+type stream struct {
+	labels map[string]string
+	// interval is the number of seconds between two log lines.
+	interval int64
+}
+
+// selectStreams returns the streams matching the equality matchers in query.
+func selectStreams(query string) []stream {
+	selector := query
+	if i := strings.Index(query, "{"); i >= 0 {
+		if j := strings.Index(query[i:], "}"); j >= 0 {
+			selector = query[i : i+j+1]
+		}
+	}
+	matchers := matcherRe.FindAllStringSubmatch(selector, -1)
+
+	var result []stream
+	for i, labels := range streamLabels {
+		ok := true
+		for _, m := range matchers {
+			if labels[m[1]] != m[2] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			result = append(result, stream{labels: labels, interval: int64(7 + i*5)})
+		}
+	}
+	return result
+}
+
+func logLine(s stream, ts int64) string {
+	msgs := messages[s.labels["level"]]
+	msg := msgs[int(ts/s.interval)%len(msgs)]
+	if strings.Contains(msg, "%d") {
+		msg = fmt.Sprintf(msg, ts%10000)
+	}
+	return fmt.Sprintf("level=%s app=%s msg=%q", s.labels["level"], s.labels["app"], msg)
+}
+
+type entry struct {
+	ts   int64
+	line string
+}
+
+func handleQueryRange(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := q.Get("query")
+	start := parseTime(q.Get("start"))
+	end := parseTime(q.Get("end"))
+
+	slog.Info("query_range", "query", query, "start", start, "end", end)
+
+	if metricRe.MatchString(query) {
+		writeMatrix(w, query, start, end, parseStep(q.Get("step")))
+		return
+	}
+
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+	forward := q.Get("direction") == "forward"
+
+	var filter string
+	if m := lineFilterRe.FindStringSubmatch(query); m != nil {
+		filter = m[1]
+	}
+
+	type result struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	var results []result
+	for _, s := range selectStreams(query) {
+		var entries []entry
+		for ts := start - start%s.interval + s.interval; ts <= end; ts += s.interval {
+			line := logLine(s, ts)
+			if filter != "" && !strings.Contains(line, filter) {
+				continue
+			}
+			entries = append(entries, entry{ts: ts, line: line})
+		}
+		if !forward {
+			sort.Slice(entries, func(i, j int) bool { return entries[i].ts > entries[j].ts })
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		if len(entries) > limit {
+			entries = entries[:limit]
+		}
+		values := make([][2]string, len(entries))
+		for i, e := range entries {
+			values[i] = [2]string{strconv.FormatInt(e.ts*1_000_000_000, 10), e.line}
+		}
+		results = append(results, result{Stream: s.labels, Values: values})
+	}
+
+	writeJSON(w, map[string]any{
+		"status": "success",
+		"data": map[string]any{
+			"resultType": "streams",
+			"result":     results,
+		},
+	})
+}
+
+// writeMatrix answers metric queries such as count_over_time or rate with
+// per-stream line rates derived from each stream's interval.
+func writeMatrix(w http.ResponseWriter, query string, start, end, step int64) {
+	if step <= 0 {
+		step = 15
+	}
+	type result struct {
+		Metric map[string]string `json:"metric"`
+		Values [][2]any          `json:"values"`
+	}
+	var results []result
+	for _, s := range selectStreams(query) {
+		var values [][2]any
+		for ts := start; ts <= end; ts += step {
+			rate := 1 / float64(s.interval)
+			// Add a slow wave so graphs are not flat.
+			rate *= 1 + 0.5*float64((ts/300)%4)/4
+			values = append(values, [2]any{ts, strconv.FormatFloat(rate, 'f', 6, 64)})
+		}
+		results = append(results, result{Metric: s.labels, Values: values})
+	}
+
+	writeJSON(w, map[string]any{
+		"status": "success",
+		"data": map[string]any{
+			"resultType": "matrix",
+			"result":     results,
+		},
+	})
+}
+
+func handleLabels(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]any{"status": "success", "data": []string{"app", "level"}})
+}
+
+func handleLabelValues(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	query := r.URL.Query().Get("query")
+	slog.Info("label_values", "label", name, "query", query)
+
+	seen := map[string]bool{}
+	values := []string{}
+	for _, s := range selectStreams(query) {
+		if v, ok := s.labels[name]; ok && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	writeJSON(w, map[string]any{"status": "success", "data": values})
+}
+
+// parseTime parses a Loki timestamp: Unix seconds (up to 10 digits, optionally
+// fractional) or Unix nanoseconds.
+func parseTime(s string) int64 {
+	if strings.Contains(s, ".") {
+		f, _ := strconv.ParseFloat(s, 64)
+		return int64(f)
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	if len(s) > 10 {
+		return n / 1_000_000_000
+	}
+	return n
+}
+
+func parseStep(s string) int64 {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "s") {
+		v, _ := strconv.ParseFloat(strings.TrimSuffix(s, "s"), 64)
+		return int64(v)
+	}
+	if strings.HasSuffix(s, "m") {
+		v, _ := strconv.ParseFloat(strings.TrimSuffix(s, "m"), 64)
+		return int64(v * 60)
+	}
+	v, _ := strconv.ParseFloat(s, 64)
+	return int64(v)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,250 @@
+// dummyoidc is a fake OpenID Connect issuer for local development and
+// testing. It implements discovery, the authorization code flow with PKCE,
+// and userinfo for two fixed users, allowing end-to-end testing of the oidc
+// provider without Keycloak or Dex. ID tokens are unsigned, which Dashyard
+// accepts since it receives them directly from the token endpoint.
+// Similar in spirit to cmd/dummygithub.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// user is a fixed identity the issuer signs in.
+type user struct {
+	Sub    string
+	Name   string
+	Email  string
+	Groups []string
+}
+
+var users = map[string]user{
+	"dummyuser": {Sub: "1001", Name: "Dummy User", Email: "dummy@example.com", Groups: []string{"sre", "developers"}},
+	"outsider":  {Sub: "1002", Name: "Outsider", Email: "outsider@example.com", Groups: []string{"sales"}},
+}
+
+// grant is an issued authorization code, waiting to be exchanged.
+type grant struct {
+	username  string
+	challenge string
+	nonce     string
+}
+
+var (
+	issuer string
+
+	mu     sync.Mutex
+	grants = map[string]grant{}
+	tokens = map[string]string{} // access token -> username
+)
+
+func main() {
+	port := "5556"
+	if p := os.Getenv("PORT"); p != "" {
+		port = p
+	}
+	issuer = "http://localhost:" + port
+	if v := os.Getenv("ISSUER"); v != "" {
+		issuer = v
+	}
+
+	http.HandleFunc("GET /.well-known/openid-configuration", handleDiscovery)
+	http.HandleFunc("GET /authorize", handleAuthorize)
+	http.HandleFunc("GET /approve", handleApprove)
+	http.HandleFunc("POST /token", handleToken)
+	http.HandleFunc("GET /userinfo", handleUserInfo)
+
+	slog.Info("dummy oidc server starting", "port", port, "issuer", issuer)
+	if err := http.ListenAndServe(":"+port, nil); err != nil {
+		slog.Error("server error", "error", err)
+		os.Exit(1)
+	}
+}
+
+// handleDiscovery serves the discovery document.
+func handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"userinfo_endpoint":                     issuer + "/userinfo",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// handleAuthorize shows a login form with a button per user. Each button
+// approves the request as that user.
+func handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	slog.Info("authorize", "client_id", q.Get("client_id"), "redirect_uri", q.Get("redirect_uri"), "code_challenge_method", q.Get("code_challenge_method"))
+
+	if method := q.Get("code_challenge_method"); q.Get("code_challenge") != "" && method != "S256" {
+		http.Error(w, "unsupported code_challenge_method", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprint(w, `<!DOCTYPE html>
+<html>
+<head><title>Dummy OIDC Login</title></head>
+<body style="font-family: sans-serif; display: flex; justify-content: center; align-items: center; height: 100vh; margin: 0; background: #f6f8fa;">
+  <div style="background: white; padding: 2rem; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.12); text-align: center;">
+    <h2>Dummy OIDC Login</h2>
+`)
+	for _, name := range []string{"dummyuser", "outsider"} {
+		approve := url.Values{}
+		for k, v := range q {
+			approve[k] = v
+		}
+		approve.Set("username", name)
+		_, _ = fmt.Fprintf(w, `    <p><a href="/approve?%s"
+       style="display: inline-block; padding: 0.6rem 1.5rem; background: #2da44e; color: white; text-decoration: none; border-radius: 6px; font-size: 1rem;">
+      Sign in as %s</a> (groups: %v)</p>
+`, html.EscapeString(approve.Encode()), name, users[name].Groups)
+	}
+	_, _ = fmt.Fprint(w, `  </div>
+</body>
+</html>`)
+}
+
+// handleApprove issues an authorization code for the chosen user and
+// redirects back to the client.
+func handleApprove(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	username := q.Get("username")
+	if _, ok := users[username]; !ok {
+		http.Error(w, "unknown user", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	mu.Lock()
+	grants[code] = grant{username: username, challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	mu.Unlock()
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// handleToken exchanges an authorization code, checking its PKCE code
+// verifier, for an access token and an ID token.
+func handleToken(w http.ResponseWriter, r *http.Request) {
+	code := r.FormValue("code")
+	clientID, _, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.FormValue("client_id")
+	}
+
+	mu.Lock()
+	g, found := grants[code]
+	delete(grants, code)
+	mu.Unlock()
+	if !found {
+		tokenError(w, "invalid_grant", "unknown authorization code")
+		return
+	}
+	if g.challenge != "" {
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+			tokenError(w, "invalid_grant", "code_verifier does not match code_challenge")
+			return
+		}
+	}
+	slog.Info("token", "user", g.username, "client_id", clientID, "pkce", g.challenge != "")
+
+	u := users[g.username]
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":                issuer,
+		"sub":                u.Sub,
+		"aud":                clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+		"preferred_username": g.username,
+		"name":               u.Name,
+		"email":              u.Email,
+		"groups":             u.Groups,
+	}
+	if g.nonce != "" {
+		claims["nonce"] = g.nonce
+	}
+
+	accessToken := randomString()
+	mu.Lock()
+	tokens[accessToken] = g.username
+	mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     unsignedJWT(claims),
+	})
+}
+
+// handleUserInfo returns the claims of the user the access token belongs to.
+func handleUserInfo(w http.ResponseWriter, r *http.Request) {
+	var accessToken string
+	_, _ = fmt.Sscanf(r.Header.Get("Authorization"), "Bearer %s", &accessToken)
+	mu.Lock()
+	username, ok := tokens[accessToken]
+	mu.Unlock()
+	if !ok {
+		http.Error(w, "invalid access token", http.StatusUnauthorized)
+		return
+	}
+
+	u := users[username]
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"sub":                u.Sub,
+		"preferred_username": username,
+		"name":               u.Name,
+		"email":              u.Email,
+		"groups":             u.Groups,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code, description string) {
+	slog.Warn("token request rejected", "error", code, "description", description)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": code, "error_description": description})
+}
+
+// unsignedJWT encodes claims as a JWT with the "none" algorithm.
+func unsignedJWT(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "none", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -1,4 +1,4 @@
-// dummyprom is a fake Prometheus query_range API server that returns
+// dummyprom is a fake Prometheus query API server that returns
 // synthetic host-metrics-style data (similar to OpenTelemetry Collector's
 // hostmetricsreceiver). Useful for demoing Dashyard without a real Prometheus.
 package main
@@ -11,9 +11,11 @@ import (
 	"math"
 	"net/http"
 	"os"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func main() {
@@ -24,6 +26,7 @@ func main() {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/v1/query_range", handleQueryRange)
+	mux.HandleFunc("/api/v1/query", handleQuery)
 	mux.HandleFunc("/api/v1/metadata", handleMetadata)
 	mux.HandleFunc("/api/v1/labels", handleLabels)
 	mux.HandleFunc("/api/v1/label/", handleLabelValues)
@@ -135,6 +138,47 @@ func handleQueryRange(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type promVectorResult struct {
+	Metric map[string]string `json:"metric"`
+	Value  [2]interface{}    `json:"value"`
+}
+
+// handleQuery answers instant queries with the last sample of the same
+// synthetic series a one-hour query_range ending at the evaluation time returns.
+func handleQuery(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	timeStr := r.URL.Query().Get("time")
+
+	slog.Info("query", "query", query, "time", timeStr)
+
+	ts, _ := strconv.ParseFloat(timeStr, 64)
+	if ts == 0 {
+		ts = float64(time.Now().Unix())
+	}
+
+	results := []promVectorResult{}
+	for _, series := range generateData(r.Context(), query, ts-3600, ts, 60) {
+		if len(series.Values) == 0 {
+			continue
+		}
+		last := series.Values[len(series.Values)-1]
+		results = append(results, promVectorResult{Metric: series.Metric, Value: last})
+	}
+
+	resp := map[string]interface{}{
+		"status": "success",
+		"data": map[string]interface{}{
+			"resultType": "vector",
+			"result":     results,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("failed to encode query response", "error", err)
+	}
+}
+
 func parseStep(s string) float64 {
 	s = strings.TrimSpace(s)
 	if strings.HasSuffix(s, "s") {
@@ -152,8 +196,12 @@ func parseStep(s string) float64 {
 func generateData(ctx context.Context, query string, start, end, step float64) []promResult {
 	_ = seedOffsetFromContext(ctx) // available for future per-prefix data variation
 	switch {
+	case strings.Contains(query, "build_info"):
+		return generateDeployEvents(start, end, step)
+	case strings.Contains(query, "http_request_duration_seconds_bucket"):
+		return generateLatencyBuckets(start, end, step)
 	case strings.Contains(query, "cpu_utilization"):
-		return generateCPUUtilization(start, end, step)
+		return filterSeries(generateCPUUtilization(start, end, step), parseMatchers(query))
 	case strings.Contains(query, "cpu_load_average"):
 		return generateLoadAverage(start, end, step)
 	case strings.Contains(query, "memory_usage"):
@@ -170,6 +218,8 @@ func generateData(ctx context.Context, query string, start, end, step float64) [
 		return generateDemoRequestsPerSecond(start, end, step)
 	case strings.Contains(query, "container_requests_total"):
 		return generateContainerRequests(start, end, step)
+	case strings.Contains(query, "pod_cpu_usage_seconds_total"):
+		return generatePodCPU(query, start, end, step)
 	default:
 		return generateGeneric(query, start, end, step)
 	}
@@ -390,6 +440,87 @@ func generateContainerRequests(start, end, step float64) []promResult {
 	}
 }
 
+// generateDeployEvents simulates `changes(app_build_info[5m]) > 0`: each
+// version has a single non-zero sample at the time it was deployed (30% and
+// 70% into the range), which annotation queries turn into markers.
+func generateDeployEvents(start, end, step float64) []promResult {
+	deploys := []struct {
+		version string
+		at      float64
+	}{
+		{"v1.1.0", 0.3},
+		{"v1.2.0", 0.7},
+	}
+	var results []promResult
+	for _, d := range deploys {
+		at := start + (end-start)*d.at
+		values := generateTimeSeries(start, end, step, func(t float64) float64 {
+			// t is relative to start.
+			if start+t >= at && start+t < at+step {
+				return 1
+			}
+			return 0
+		})
+		results = append(results, promResult{
+			Metric: map[string]string{"__name__": "app_build_info", "version": d.version},
+			Values: values,
+		})
+	}
+	return results
+}
+
+// latencyBuckets are the upper bounds of the classic latency histogram
+// (Prometheus client default buckets).
+var latencyBuckets = []string{"0.005", "0.01", "0.025", "0.05", "0.1", "0.25", "0.5", "1", "2.5", "5", "10", "+Inf"}
+
+// generateLatencyBuckets returns cumulative per-second bucket rates of a
+// log-normal request latency whose median drifts between ~30ms and ~130ms,
+// as `sum by (le) (rate(..._bucket[5m]))` would.
+func generateLatencyBuckets(start, end, step float64) []promResult {
+	var results []promResult
+	for _, le := range latencyBuckets {
+		bound, _ := strconv.ParseFloat(le, 64)
+		values := generateTimeSeries(start, end, step, func(t float64) float64 {
+			total := 50 + 10*math.Sin(t/1200) + noise(t, 800)*5
+			median := 0.08 * (1 + 0.6*math.Sin(t/900))
+			if math.IsInf(bound, 1) {
+				return total
+			}
+			z := (math.Log(bound) - math.Log(median)) / 0.9
+			return total * 0.5 * (1 + math.Erf(z/math.Sqrt2))
+		})
+		results = append(results, promResult{
+			Metric: map[string]string{"le": le},
+			Values: values,
+		})
+	}
+	return results
+}
+
+// generatePodCPU returns a CPU usage rate for every pod series matching the
+// label matchers in query, so that chained namespace/pod variables narrow the
+// graph.
+func generatePodCPU(query string, start, end, step float64) []promResult {
+	matchers := parseMatchers(query)
+	var results []promResult
+	for i, labels := range seriesRegistry["pod_cpu_usage_seconds_total"] {
+		if !matchesAll(labels, matchers) {
+			continue
+		}
+		seed := uint64(900 + i)
+		base := 0.1 + 0.05*float64(i)
+		values := generateTimeSeries(start, end, step, func(t float64) float64 {
+			return math.Max(0, base+0.05*math.Sin(t/700+float64(i))+noise(t, seed)*0.02)
+		})
+		metric := map[string]string{}
+		for k, v := range labels {
+			metric[k] = v
+		}
+		results = append(results, promResult{Metric: metric, Values: values})
+	}
+	return results
+}
+
 func generateGeneric(query string, start, end, step float64) []promResult {
 	values := generateTimeSeries(start, end, step, func(t float64) float64 {
 		return math.Max(0, 50+30*math.Sin(t/600)+noise(t, 500)*10)
@@ -417,15 +548,94 @@ func generateTimeSeries(start, end, step float64, fn func(float64) float64) [][2
 	return values
 }
 
+// seriesRegistry lists the label sets of metrics whose label values depend on
+// each other, so that label values requests with matchers can be answered.
+var seriesRegistry = map[string][]map[string]string{
+	"pod_cpu_usage_seconds_total": {
+		{"namespace": "default", "pod": "web-6f7b9-abcde"},
+		{"namespace": "default", "pod": "web-6f7b9-fghij"},
+		{"namespace": "default", "pod": "worker-5c8d4-klmno"},
+		{"namespace": "monitoring", "pod": "prometheus-0"},
+		{"namespace": "monitoring", "pod": "alertmanager-0"},
+		{"namespace": "payments", "pod": "api-7d9f8-pqrst"},
+	},
+}
+
+// matcher is a single label matcher of a series selector.
+type matcher struct {
+	name, op, value string
+}
+
+var (
+	matcherRe    = regexp.MustCompile(`(\w+)\s*(=~|!~|!=|=)\s*"((?:[^"\\]|\\.)*)"`)
+	metricNameRe = regexp.MustCompile(`^\s*([a-zA-Z_:][a-zA-Z0-9_:]*)`)
+)
+
+// parseMatchers extracts the label matchers from the first {...} of a
+// PromQL expression. It is not a PromQL parser, but enough for the simple
+// selectors dashboards use.
+func parseMatchers(query string) []matcher {
+	i := strings.Index(query, "{")
+	if i < 0 {
+		return nil
+	}
+	j := strings.Index(query[i:], "}")
+	if j < 0 {
+		return nil
+	}
+	var matchers []matcher
+	for _, m := range matcherRe.FindAllStringSubmatch(query[i:i+j], -1) {
+		value := strings.ReplaceAll(m[3], `\\`, `\`)
+		matchers = append(matchers, matcher{name: m[1], op: m[2], value: value})
+	}
+	return matchers
+}
+
+func matchesAll(labels map[string]string, matchers []matcher) bool {
+	for _, m := range matchers {
+		v := labels[m.name]
+		switch m.op {
+		case "=":
+			if v != m.value {
+				return false
+			}
+		case "!=":
+			if v == m.value {
+				return false
+			}
+		case "=~", "!~":
+			re, err := regexp.Compile("^(?:" + m.value + ")$")
+			if err != nil || re.MatchString(v) != (m.op == "=~") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// filterSeries keeps the series matching matchers, e.g. those dashboards add
+// as ad-hoc filters.
+func filterSeries(results []promResult, matchers []matcher) []promResult {
+	out := []promResult{}
+	for _, r := range results {
+		if matchesAll(r.Metric, matchers) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
 // labelRegistry maps metric names to their label name -> values.
 var labelRegistry = map[string]map[string][]string{
-	"system_cpu_utilization_ratio":      {"cpu": {"cpu0", "cpu1", "cpu2", "cpu3"}},
-	"system_cpu_load_average_1m_ratio":  {},
-	"system_memory_usage_bytes":         {"state": {"used", "cached", "free", "buffers"}},
-	"system_network_io_bytes_total":     {"device": {"eth0", "eth1"}, "direction": {"receive", "transmit"}},
-	"system_disk_io_bytes_total":        {"device": {"sda"}, "direction": {"read", "write"}},
-	"demo_requests_per_second":          {"endpoint": {"/health", "/api/users", "/api/search", "/api/reports", "/admin"}},
-	"container_requests_total":           {"version": {"v1.0.0", "v1.1.0", "v1.2.0"}},
+	"system_cpu_utilization_ratio":               {"cpu": {"cpu0", "cpu1", "cpu2", "cpu3"}},
+	"system_cpu_load_average_1m_ratio":           {},
+	"system_memory_usage_bytes":                  {"state": {"used", "cached", "free", "buffers"}},
+	"system_network_io_bytes_total":              {"device": {"eth0", "eth1"}, "direction": {"receive", "transmit"}},
+	"system_disk_io_bytes_total":                 {"device": {"sda"}, "direction": {"read", "write"}},
+	"demo_requests_per_second":                   {"endpoint": {"/health", "/api/users", "/api/search", "/api/reports", "/admin"}},
+	"container_requests_total":                   {"version": {"v1.0.0", "v1.1.0", "v1.2.0"}},
+	"myapp_http_request_duration_seconds_bucket": {"le": latencyBuckets},
+	"pod_cpu_usage_seconds_total":                {"namespace": {"default", "monitoring", "payments"}, "pod": {"web-6f7b9-abcde", "web-6f7b9-fghij", "worker-5c8d4-klmno", "prometheus-0", "alertmanager-0", "api-7d9f8-pqrst"}},
 }
 
 type labelValuesResponse struct {
@@ -469,10 +679,12 @@ func collectLabelValues(label, match string) []string {
 	seen := map[string]bool{}
 	var values []string
 
-	// Special case: __name__ returns all metric names
+	// Special case: __name__ returns the metric names matching match[], e.g.
+	// {__name__=~".*cpu.*"} of a metrics() variable
 	if label == "__name__" {
+		matchers := parseMatchers(match)
 		for metric := range labelRegistry {
-			if !seen[metric] {
+			if !seen[metric] && matchesAll(map[string]string{"__name__": metric}, matchers) {
 				seen[metric] = true
 				values = append(values, metric)
 			}
@@ -481,8 +693,24 @@ func collectLabelValues(label, match string) []string {
 		return values
 	}
 
+	name := ""
+	if m := metricNameRe.FindStringSubmatch(match); m != nil {
+		name = m[1]
+	}
+	if series, ok := seriesRegistry[name]; ok {
+		matchers := parseMatchers(match)
+		for _, labels := range series {
+			if v, ok := labels[label]; ok && !seen[v] && matchesAll(labels, matchers) {
+				seen[v] = true
+				values = append(values, v)
+			}
+		}
+		sort.Strings(values)
+		return values
+	}
+
 	for metric, labels := range labelRegistry {
-		if match != "" && metric != match {
+		if match != "" && metric != name {
 			continue
 		}
 		if vals, ok := labels[label]; ok {
@@ -503,13 +731,15 @@ var metadataRegistry = map[string]struct {
 	Type string
 	Help string
 }{
-	"system_cpu_utilization_ratio":    {"gauge", "CPU utilization as a ratio between 0 and 1."},
-	"system_cpu_load_average_1m_ratio": {"gauge", "1-minute CPU load average."},
-	"system_memory_usage_bytes":       {"gauge", "Memory usage in bytes by state."},
-	"system_network_io_bytes_total":   {"counter", "Total network I/O bytes by device and direction."},
-	"system_disk_io_bytes_total":      {"counter", "Total disk I/O bytes by device and direction."},
-	"demo_requests_per_second":        {"gauge", "Request rate per endpoint (spans multiple orders of magnitude)."},
-	"container_requests_total":        {"counter", "Total container requests by version (simulates rolling deployments with data gaps)."},
+	"system_cpu_utilization_ratio":        {"gauge", "CPU utilization as a ratio between 0 and 1."},
+	"system_cpu_load_average_1m_ratio":    {"gauge", "1-minute CPU load average."},
+	"system_memory_usage_bytes":           {"gauge", "Memory usage in bytes by state."},
+	"system_network_io_bytes_total":       {"counter", "Total network I/O bytes by device and direction."},
+	"system_disk_io_bytes_total":          {"counter", "Total disk I/O bytes by device and direction."},
+	"demo_requests_per_second":            {"gauge", "Request rate per endpoint (spans multiple orders of magnitude)."},
+	"container_requests_total":            {"counter", "Total container requests by version (simulates rolling deployments with data gaps)."},
+	"myapp_http_request_duration_seconds": {"histogram", "HTTP request latency."},
+	"pod_cpu_usage_seconds_total":         {"counter", "CPU time used by pod."},
 }
 
 func handleMetadata(w http.ResponseWriter, r *http.Request) {
@@ -543,9 +773,12 @@ func handleLabels(w http.ResponseWriter, r *http.Request) {
 	for metric, metricLabels := range labelRegistry {
 		// If match[] is provided, parse out the metric name
 		if match != "" {
-			// match[] format: {__name__="metric_name"}
+			// match[] format: {__name__="metric_name"} or metric_name{...}
 			metricName := strings.TrimPrefix(match, `{__name__="`)
 			metricName = strings.TrimSuffix(metricName, `"}`)
+			if m := metricNameRe.FindStringSubmatch(match); m != nil {
+				metricName = m[1]
+			}
 			if metric != metricName {
 				continue
 			}
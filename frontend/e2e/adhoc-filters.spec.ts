@@ -0,0 +1,32 @@
+import { test, expect } from "@playwright/test";
+
+test.describe("Ad-hoc Filters", () => {
+  test("adding a filter narrows every panel query", async ({ page }) => {
+    await page.goto("/d/adhoc-filters");
+
+    const table = page.locator(".table-panel", { hasText: "CPU by Core" });
+    await expect(table.locator("tbody tr").first()).toBeVisible({ timeout: 10000 });
+    await expect(table.locator("tbody tr")).toHaveCount(4);
+
+    const bar = page.locator(".variable-bar");
+    await bar.locator(".adhoc-filter-key").fill("cpu");
+    await bar.locator(".adhoc-filter-value").fill("cpu2");
+    await bar.locator(".adhoc-filter-add").click();
+
+    await expect(bar.locator(".adhoc-filter")).toHaveText([/cpu="cpu2"/]);
+    await expect(table.locator("tbody tr")).toHaveCount(1);
+    await expect(table.locator("tbody tr").first()).toContainText("cpu2");
+    await expect(page).toHaveURL(/var-filters=cpu%3D%22cpu2%22/);
+  });
+
+  test("filters from the URL apply and can be removed", async ({ page }) => {
+    await page.goto('/d/adhoc-filters?var-filters=cpu%3D~%22cpu%5B01%5D%22');
+
+    const table = page.locator(".table-panel", { hasText: "CPU by Core" });
+    await expect(table.locator("tbody tr")).toHaveCount(2, { timeout: 10000 });
+
+    await page.locator(".adhoc-filter-remove").click();
+    await expect(page.locator(".adhoc-filter")).toHaveCount(0);
+    await expect(table.locator("tbody tr")).toHaveCount(4);
+  });
+});
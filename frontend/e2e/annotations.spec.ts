@@ -0,0 +1,39 @@
+import { test, expect } from "@playwright/test";
+
+test.describe("Annotations", () => {
+  test("annotation events are fetched for the dashboard", async ({ page }) => {
+    await page.goto("/");
+
+    const responsePromise = page.waitForResponse((resp) =>
+      resp.url().includes("/api/annotations"),
+    );
+    await page.locator(".sidebar-item", { hasText: "annotations" }).click();
+
+    const response = await responsePromise;
+    expect(response.status()).toBe(200);
+    const body = await response.json();
+    const deploys = body.annotations.filter(
+      (a: { source: string }) => a.source === "Deploys",
+    );
+    expect(deploys.length).toBe(2);
+    expect(deploys[0].text).toMatch(/^deploy v1\.\d\.0$/);
+
+    await expect(page.locator(".panel canvas").first()).toBeVisible({
+      timeout: 15000,
+    });
+  });
+
+  test("dashboards without annotations make no request", async ({ page }) => {
+    let requested = false;
+    page.on("request", (req) => {
+      if (req.url().includes("/api/annotations")) requested = true;
+    });
+
+    await page.goto("/");
+    await page.locator(".sidebar-item", { hasText: "overview" }).click();
+    await expect(page.locator(".panel canvas").first()).toBeVisible({
+      timeout: 15000,
+    });
+    expect(requested).toBe(false);
+  });
+});
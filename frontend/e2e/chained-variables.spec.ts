@@ -0,0 +1,44 @@
+import { test, expect } from "@playwright/test";
+
+test.describe("Chained Variables", () => {
+  test("downstream variable lists values of the selected upstream value", async ({
+    page,
+  }) => {
+    await page.goto("/d/chained-variables");
+
+    const variableBar = page.locator(".variable-bar");
+    await expect(variableBar).toBeVisible({ timeout: 10000 });
+
+    const namespace = variableBar.locator("select.variable-select");
+    await expect(namespace).toHaveValue("default");
+
+    const podToggle = variableBar.locator(".variable-multi-toggle");
+    await expect(podToggle).toHaveText("All");
+    await podToggle.click();
+    const options = page.locator(".variable-multi-option");
+    await expect(options).toHaveText(["All", "web-6f7b9-abcde", "web-6f7b9-fghij", "worker-5c8d4-klmno"]);
+    await podToggle.click();
+  });
+
+  test("changing the upstream value re-fetches the downstream variable", async ({
+    page,
+  }) => {
+    await page.goto("/d/chained-variables");
+
+    const variableBar = page.locator(".variable-bar");
+    await expect(variableBar).toBeVisible({ timeout: 10000 });
+
+    await variableBar.locator("select.variable-select").selectOption("monitoring");
+    await expect(page.locator(".row-title").first()).toHaveText("CPU in monitoring");
+
+    await variableBar.locator(".variable-multi-toggle").click();
+    await expect(page.locator(".variable-multi-option")).toHaveText(["All", "alertmanager-0", "prometheus-0"]);
+  });
+
+  test("upstream value from the URL drives the downstream query", async ({ page }) => {
+    await page.goto("/d/chained-variables?var-namespace=payments&var-pod=api-7d9f8-pqrst");
+
+    const toggle = page.locator(".variable-multi-toggle");
+    await expect(toggle).toHaveText("api-7d9f8-pqrst", { timeout: 10000 });
+  });
+});
@@ -0,0 +1,34 @@
+import { test, expect } from "@playwright/test";
+
+test.describe("Gauge panels", () => {
+  test("gauge dashboard shows reduced values", async ({ page }) => {
+    await page.goto("/");
+
+    await page.locator(".sidebar-item", { hasText: "gauges" }).click();
+
+    const gauge = page.locator(".gauge-panel", { hasText: "Average CPU" });
+    await expect(gauge.locator(".gauge-svg")).toBeVisible({ timeout: 15000 });
+    await expect(gauge.locator(".gauge-value")).toHaveText(/%$/);
+
+    const perCore = page.locator(".gauge-panel", { hasText: "CPU by Core" });
+    await expect(perCore.locator(".gauge-item").first()).toBeVisible({
+      timeout: 15000,
+    });
+    expect(await perCore.locator(".gauge-item").count()).toBe(4);
+  });
+
+  test("bar gauge shows one bar per series", async ({ page }) => {
+    await page.goto("/");
+
+    await page.locator(".sidebar-item", { hasText: "gauges" }).click();
+
+    const bars = page.locator(".bargauge-panel", {
+      hasText: "CPU Utilization by Core",
+    });
+    await expect(bars.locator(".bargauge-item").first()).toBeVisible({
+      timeout: 15000,
+    });
+    expect(await bars.locator(".bargauge-item").count()).toBe(4);
+    await expect(bars.locator(".gauge-label").first()).not.toBeEmpty();
+  });
+});
@@ -0,0 +1,30 @@
+import { test, expect } from "@playwright/test";
+
+test.describe("Heatmap panels", () => {
+  test("heatmap dashboard renders histogram buckets", async ({ page }) => {
+    await page.goto("/");
+
+    await page.locator(".sidebar-item", { hasText: "heatmap" }).click();
+
+    const heatmap = page.locator(".heatmap-panel").first();
+    await expect(heatmap.locator(".heatmap-canvas")).toBeVisible({
+      timeout: 15000,
+    });
+    // One label per bucket of the default Prometheus buckets.
+    expect(await heatmap.locator(".heatmap-y-label").count()).toBe(12);
+    await expect(heatmap.locator(".heatmap-y-label").first()).toHaveText(
+      "> 10.00s",
+    );
+  });
+
+  test("hovering a cell shows its bucket count", async ({ page }) => {
+    await page.goto("/");
+
+    await page.locator(".sidebar-item", { hasText: "heatmap" }).click();
+
+    const canvas = page.locator(".heatmap-canvas").first();
+    await expect(canvas).toBeVisible({ timeout: 15000 });
+    await canvas.hover({ position: { x: 50, y: 150 } });
+    await expect(page.locator(".heatmap-tooltip")).toBeVisible();
+  });
+});
@@ -0,0 +1,63 @@
+import { test, expect, type Page } from "@playwright/test";
+
+// The "payments" user is restricted to namespace="payments" by a label policy
+// in the E2E config, so it logs in on its own instead of using the global
+// admin session.
+test.use({ storageState: { cookies: [], origins: [] } });
+
+async function loginAsPayments(page: Page) {
+  await page.goto("/");
+  await expect(page.locator(".login-form")).toBeVisible({ timeout: 10000 });
+  await page.fill("#userId", "payments");
+  await page.fill("#password", "payments");
+  await page.click('button[type="submit"]');
+  await expect(page.locator(".login-form")).not.toBeVisible({ timeout: 10000 });
+}
+
+test.describe("Label Policies", () => {
+  test("variables only list values the policy allows", async ({ page }) => {
+    await loginAsPayments(page);
+    await page.goto("/d/chained-variables");
+
+    const variableBar = page.locator(".variable-bar");
+    await expect(variableBar).toBeVisible({ timeout: 10000 });
+
+    const namespace = variableBar.locator("select.variable-select");
+    await expect(namespace).toHaveValue("payments");
+    await expect(namespace.locator("option")).toHaveText(["payments"]);
+
+    await variableBar.locator(".variable-multi-toggle").click();
+    await expect(page.locator(".variable-multi-option")).toHaveText(["All", "api-7d9f8-pqrst"]);
+  });
+
+  test("crafted requests cannot read other namespaces", async ({ page }) => {
+    await loginAsPayments(page);
+
+    const values = await page.request.get(
+      "/api/label-values?label=pod&match[]=" + encodeURIComponent('pod_cpu_usage_seconds_total{namespace="default"}'),
+    );
+    expect(values.ok()).toBeTruthy();
+    expect((await values.json()).data ?? []).toEqual([]);
+
+    const now = Math.floor(Date.now() / 1000);
+    const query = await page.request.get(
+      `/api/query?query=${encodeURIComponent("rate(pod_cpu_usage_seconds_total[5m])")}&start=${now - 3600}&end=${now}&step=60`,
+    );
+    expect(query.ok()).toBeTruthy();
+    const result = (await query.json()).data.result as { metric: Record<string, string> }[];
+    expect(result.length).toBeGreaterThan(0);
+    for (const series of result) {
+      expect(series.metric.namespace).toBe("payments");
+    }
+  });
+
+  test("log queries are refused", async ({ page }) => {
+    await loginAsPayments(page);
+
+    const now = Math.floor(Date.now() / 1000);
+    const logs = await page.request.get(
+      `/api/logs?datasource=loki&query=${encodeURIComponent('{app="web"}')}&start=${now - 3600}&end=${now}`,
+    );
+    expect(logs.status()).toBe(403);
+  });
+});
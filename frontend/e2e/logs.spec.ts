@@ -0,0 +1,34 @@
+import { test, expect } from "@playwright/test";
+
+test.describe("Logs", () => {
+  test("logs dashboard shows log lines from loki", async ({ page }) => {
+    await page.goto("/");
+
+    await page.locator(".sidebar-item", { hasText: "logs" }).click();
+
+    // Wait for logs panels to render
+    const logsPanel = page.locator(".logs-panel").first();
+    await expect(logsPanel).toBeVisible({ timeout: 15000 });
+
+    // dummyloki returns synthetic lines for the web error stream
+    await expect(logsPanel.locator(".logs-line").first()).toBeVisible({
+      timeout: 15000,
+    });
+    await expect(logsPanel.locator(".logs-line").first()).toContainText(
+      "level=error",
+    );
+  });
+
+  test("logs dashboard renders loki metric queries as graphs", async ({
+    page,
+  }) => {
+    await page.goto("/");
+
+    await page.locator(".sidebar-item", { hasText: "logs" }).click();
+
+    await expect(page.locator(".graph-panel canvas").first()).toBeVisible({
+      timeout: 15000,
+    });
+    expect(await page.locator(".logs-panel").count()).toBe(2);
+  });
+});
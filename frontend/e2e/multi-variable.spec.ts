@@ -0,0 +1,64 @@
+import { test, expect } from "@playwright/test";
+
+test.describe("Multi-value Variables", () => {
+  test("multi-value variable defaults to All and repeats over every value", async ({
+    page,
+  }) => {
+    await page.goto("/d/multi-variable");
+
+    const variableBar = page.locator(".variable-bar");
+    await expect(variableBar).toBeVisible({ timeout: 10000 });
+
+    const toggle = variableBar.locator(".variable-multi-toggle");
+    await expect(toggle).toHaveText("All");
+
+    // One shared row plus one repeated row per device
+    await expect(page.locator(".row-title", { hasText: "Device eth0" })).toBeVisible();
+    await expect(page.locator(".row-title", { hasText: "Device eth1" })).toBeVisible();
+  });
+
+  test("selecting a single value narrows repeat rows and updates the URL", async ({
+    page,
+  }) => {
+    await page.goto("/d/multi-variable");
+
+    const toggle = page.locator(".variable-multi-toggle");
+    await expect(toggle).toBeVisible({ timeout: 10000 });
+    await toggle.click();
+
+    const menu = page.locator(".variable-multi-menu");
+    await expect(menu).toBeVisible();
+    await menu.locator(".variable-multi-option", { hasText: "eth1" }).click();
+
+    await expect(toggle).toHaveText("eth1");
+    await expect(page.locator(".row-title", { hasText: "Device eth1" })).toBeVisible();
+    await expect(page.locator(".row-title", { hasText: "Device eth0" })).toHaveCount(0);
+
+    const url = new URL(page.url());
+    expect(url.searchParams.getAll("var-device")).toEqual(["eth1"]);
+
+    // Adding a second value shows both again
+    await menu.locator(".variable-multi-option", { hasText: "eth0" }).click();
+    await expect(toggle).toHaveText("eth1 + 1");
+    await expect(page.locator(".row-title", { hasText: "Device eth0" })).toBeVisible();
+  });
+
+  test("multiple values are restored from the URL", async ({ page }) => {
+    await page.goto("/d/multi-variable?var-device=eth0&var-device=eth1");
+
+    const toggle = page.locator(".variable-multi-toggle");
+    await expect(toggle).toHaveText("eth0 + 1", { timeout: 10000 });
+  });
+
+  test("single-select variable offers an All option", async ({ page }) => {
+    await page.goto("/d/multi-variable");
+
+    const select = page.locator("select.variable-select");
+    await expect(select).toBeVisible({ timeout: 10000 });
+    await expect(select.locator("option").first()).toHaveText("All");
+    await expect(select).toHaveValue("$__all");
+
+    await select.selectOption("receive");
+    await expect(page.locator(".panel-title").first()).toContainText("receive");
+  });
+});
@@ -20,7 +20,7 @@ test.describe("OAuth Login", () => {
     // Should show OAuth button for GitHub
     const oauthButton = page.locator(".oauth-button-github");
     await expect(oauthButton).toBeVisible();
-    await expect(oauthButton).toHaveText("Sign in with Github");
+    await expect(oauthButton).toHaveText("Sign in with GitHub");
   });
 
   test("login page shows both OAuth and password form", async ({ page }) => {
@@ -45,7 +45,7 @@ test.describe("OAuth Login", () => {
     // Wait for login form
     await expect(page.locator(".login-form")).toBeVisible({ timeout: 10000 });
 
-    // Click "Sign in with Github" — this navigates through the OAuth flow:
+    // Click "Sign in with GitHub" — this navigates through the OAuth flow:
     // 1. /auth/github (backend) → redirects to dummygithub /login/oauth/authorize
     // 2. dummygithub shows login page
     const oauthButton = page.locator(".oauth-button-github");
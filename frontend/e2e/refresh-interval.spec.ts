@@ -40,9 +40,9 @@ test.describe("Refresh Interval Selector", () => {
     const selector = page.locator(".refresh-interval-selector");
     await selector.selectOption("10000");
 
-    // Wait for auto-refresh to trigger a /api/query request
+    // Wait for auto-refresh to trigger a /api/panel-query request
     const response = await page.waitForResponse(
-      (resp) => resp.url().includes("/api/query?"),
+      (resp) => resp.url().includes("/api/panel-query?"),
       { timeout: 15000 }
     );
     expect(response.status()).toBe(200);
@@ -55,7 +55,7 @@ test.describe("Refresh Interval Selector", () => {
     await page.waitForLoadState("networkidle");
     await selector.selectOption("10000");
     await page.waitForResponse(
-      (resp) => resp.url().includes("/api/query?"),
+      (resp) => resp.url().includes("/api/panel-query?"),
       { timeout: 15000 }
     );
 
@@ -65,7 +65,7 @@ test.describe("Refresh Interval Selector", () => {
     // Count requests after turning off
     let queryCount = 0;
     page.on("request", (req) => {
-      if (req.url().includes("/api/query?")) {
+      if (req.url().includes("/api/panel-query?")) {
         queryCount++;
       }
     });
@@ -92,7 +92,7 @@ test.describe("Refresh Interval Selector", () => {
     // Count requests after enabling refresh
     let queryCount = 0;
     page.on("request", (req) => {
-      if (req.url().includes("/api/query?")) {
+      if (req.url().includes("/api/panel-query?")) {
         queryCount++;
       }
     });
@@ -0,0 +1,33 @@
+import { test, expect } from "@playwright/test";
+
+test.describe("Stat panels", () => {
+  test("stat dashboard shows single values", async ({ page }) => {
+    await page.goto("/");
+
+    await page.locator(".sidebar-item", { hasText: "stat" }).click();
+
+    const statPanel = page.locator(".stat-panel").first();
+    await expect(statPanel).toBeVisible({ timeout: 15000 });
+    await expect(statPanel.locator(".stat-value").first()).toBeVisible({
+      timeout: 15000,
+    });
+    await expect(statPanel.locator(".stat-value").first()).not.toHaveText("-");
+  });
+
+  test("per-series stat panel shows one value per series", async ({
+    page,
+  }) => {
+    await page.goto("/");
+
+    await page.locator(".sidebar-item", { hasText: "stat" }).click();
+
+    const perSeries = page.locator(".stat-panel", {
+      hasText: "CPU Utilization by Core",
+    });
+    await expect(perSeries.locator(".stat-item").first()).toBeVisible({
+      timeout: 15000,
+    });
+    expect(await perSeries.locator(".stat-item").count()).toBe(4);
+    await expect(perSeries.locator(".stat-sparkline canvas").first()).toBeVisible();
+  });
+});
@@ -0,0 +1,55 @@
+import { test, expect } from "@playwright/test";
+
+test.describe("Static Variables", () => {
+  test("custom, interval and textbox variables render; constants are hidden", async ({
+    page,
+  }) => {
+    await page.goto("/d/static-variables");
+
+    const variableBar = page.locator(".variable-bar");
+    await expect(variableBar).toBeVisible({ timeout: 10000 });
+
+    const labels = variableBar.locator(".variable-label");
+    await expect(labels).toHaveText(["Direction", "Rate Window", "Device Regex"]);
+
+    const direction = variableBar.locator(".variable-selector", { hasText: "Direction" }).locator("select");
+    await expect(direction).toHaveValue("receive");
+    await expect(direction.locator("option")).toHaveText(["receive", "transmit"]);
+
+    const windowSelect = variableBar.locator(".variable-selector", { hasText: "Rate Window" }).locator("select");
+    await expect(windowSelect.locator("option").first()).toHaveText("auto");
+
+    // Last 1 hour with a 60s step: auto resolves to 1h / 30 = 2m
+    await expect(page.locator(".row-title").first()).toHaveText("Traffic (receive over 2m)");
+
+    const textbox = variableBar.locator(".variable-input");
+    await expect(textbox).toHaveValue("eth.*");
+  });
+
+  test("changing static variables updates titles and the URL", async ({ page }) => {
+    await page.goto("/d/static-variables");
+
+    const variableBar = page.locator(".variable-bar");
+    await expect(variableBar).toBeVisible({ timeout: 10000 });
+
+    await variableBar.locator(".variable-selector", { hasText: "Direction" }).locator("select").selectOption("transmit");
+    await variableBar.locator(".variable-selector", { hasText: "Rate Window" }).locator("select").selectOption("5m");
+    await expect(page.locator(".row-title").first()).toHaveText("Traffic (transmit over 5m)");
+
+    const textbox = variableBar.locator(".variable-input");
+    await textbox.fill("eth0");
+    await textbox.press("Enter");
+    await expect(page).toHaveURL(/var-device=eth0/);
+
+    const url = new URL(page.url());
+    expect(url.searchParams.get("var-direction")).toBe("transmit");
+    expect(url.searchParams.get("var-window")).toBe("5m");
+    expect(url.searchParams.get("var-device")).toBe("eth0");
+  });
+
+  test("textbox value is restored from the URL", async ({ page }) => {
+    await page.goto("/d/static-variables?var-device=eth1");
+
+    await expect(page.locator(".variable-input")).toHaveValue("eth1", { timeout: 10000 });
+  });
+});
@@ -0,0 +1,42 @@
+import { test, expect } from "@playwright/test";
+
+test.describe("Table panels", () => {
+  test("single-query table has one row per series", async ({ page }) => {
+    await page.goto("/");
+
+    await page.locator(".sidebar-item", { hasText: "tables" }).click();
+
+    const cpuTable = page.locator(".table-panel", {
+      hasText: "CPU Utilization by Core",
+    });
+    await expect(cpuTable.locator("tbody tr").first()).toBeVisible({
+      timeout: 15000,
+    });
+    expect(await cpuTable.locator("tbody tr").count()).toBe(4);
+  });
+
+  test("multi-query table joins by shared labels in configured order", async ({
+    page,
+  }) => {
+    await page.goto("/");
+
+    await page.locator(".sidebar-item", { hasText: "tables" }).click();
+
+    const netTable = page.locator(".table-panel", {
+      hasText: "Network I/O by Device",
+    });
+    await expect(netTable.locator("tbody tr").first()).toBeVisible({
+      timeout: 15000,
+    });
+
+    // eth0 and eth1, each with both received and transmitted columns
+    expect(await netTable.locator("tbody tr").count()).toBe(2);
+    const headers = await netTable.locator("thead th").allTextContents();
+    expect(headers.map((h) => h.replace(/[▲▼]/g, "").trim())).toEqual([
+      "device",
+      "Transmitted",
+      "Received",
+    ]);
+    await expect(netTable.locator("tbody tr").first()).toContainText("eth0");
+  });
+});
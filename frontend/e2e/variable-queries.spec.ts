@@ -0,0 +1,29 @@
+import { test, expect } from "@playwright/test";
+
+test.describe("Variable Queries", () => {
+  test("lists metric names, label names and query results", async ({ page }) => {
+    await page.goto("/d/variable-queries");
+
+    const variableBar = page.locator(".variable-bar");
+    await expect(variableBar).toBeVisible({ timeout: 10000 });
+
+    const selects = variableBar.locator("select.variable-select");
+    await expect(selects.nth(0).locator("option")).toHaveText([
+      "system_cpu_load_average_1m_ratio",
+      "system_cpu_utilization_ratio",
+      "system_disk_io_bytes_total",
+      "system_memory_usage_bytes",
+      "system_network_io_bytes_total",
+    ]);
+    await expect(selects.nth(1).locator("option")).toHaveText(["direction", "device"]);
+    await expect(selects.nth(2).locator("option")).toHaveText(["1.2.0", "1.1.0", "1.0.0"]);
+  });
+
+  test("regex capture group extracts part of each value", async ({ page }) => {
+    await page.goto("/d/variable-queries");
+
+    const endpoint = page.locator(".variable-bar select.variable-select").nth(3);
+    await expect(endpoint.locator("option")).toHaveText(["users", "search", "reports"], { timeout: 10000 });
+    await expect(page.locator(".panel-title", { hasText: "Requests to /api/users" })).toBeVisible();
+  });
+});
@@ -48,18 +48,19 @@ function parseTimeRange(): TimeRange {
   return DEFAULT_TIME_RANGE;
 }
 
-function parseVariableValues(): Record<string, string> {
+function parseVariableValues(): Record<string, string[]> {
   const params = new URLSearchParams(window.location.search);
-  const values: Record<string, string> = {};
+  const values: Record<string, string[]> = {};
   params.forEach((value, key) => {
     if (key.startsWith('var-')) {
-      values[key.slice(4)] = value;
+      const name = key.slice(4);
+      values[name] = [...(values[name] || []), value];
     }
   });
   return values;
 }
 
-function buildUrl(dashboardPath: string, timeRange: TimeRange, varValues?: Record<string, string>): string {
+function buildUrl(dashboardPath: string, timeRange: TimeRange, varValues?: Record<string, string[]>): string {
   let url = `/d/${dashboardPath}`;
   const params = new URLSearchParams();
   if (timeRange.type === 'absolute') {
@@ -71,8 +72,10 @@ function buildUrl(dashboardPath: string, timeRange: TimeRange, varValues?: Recor
     params.set('t', timeRange.value);
   }
   if (varValues) {
-    for (const [name, value] of Object.entries(varValues)) {
-      params.set(`var-${name}`, value);
+    for (const [name, values] of Object.entries(varValues)) {
+      for (const value of values) {
+        params.append(`var-${name}`, value);
+      }
     }
   }
   const qs = params.toString();
@@ -83,7 +86,7 @@ function App() {
   const [authenticated, setAuthenticated] = useState(true); // Optimistic; API calls will detect 401
   const [currentPath, setCurrentPath] = useState<string | null>(parseDashboardPath);
   const [timeRange, setTimeRange] = useState<TimeRange>(parseTimeRange);
-  const [variableValues, setVariableValues] = useState<Record<string, string>>(parseVariableValues);
+  const [variableValues, setVariableValues] = useState<Record<string, string[]>>(parseVariableValues);
   const [refreshInterval, setRefreshInterval] = useState(0);
 
   useEffect(() => {
@@ -114,7 +117,7 @@ function App() {
     });
   }, []);
 
-  const onVariableValuesChange = useCallback((values: Record<string, string>) => {
+  const onVariableValuesChange = useCallback((values: Record<string, string[]>) => {
     setVariableValues(values);
     setCurrentPath((prev) => {
       if (prev) {
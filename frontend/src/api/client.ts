@@ -1,4 +1,4 @@
-import type { Dashboard, DashboardsResponse, DatasourcesResponse, LabelValuesResponse, QueryResponse } from '../types';
+import type { AnnotationsResponse, Dashboard, DashboardsResponse, DatasourcesResponse, InstantQueryResponse, LabelValuesResponse, LogsResponse, QueryResponse, Variable } from '../types';
 
 export interface OAuthProviderInfo {
   name: string;
@@ -49,34 +49,141 @@ export async function fetchDashboard(path: string): Promise<Dashboard> {
   return request(`/api/dashboards/${path}`);
 }
 
-export async function queryDatasource(
-  query: string,
+// PanelRef identifies a query of a dashboard panel for /api/panel-query,
+// which substitutes the variables and runs it server-side.
+export interface PanelRef {
+  path: string;
+  // Panel id "<row>-<index>" counting from 0 in the dashboard definition.
+  panel: string;
+  // Query of a table panel with several queries.
+  index?: number;
+  // Values of each variable; ['$__all'] for an "All" with all_value.
+  values: Record<string, string[]>;
+  // Ad-hoc filter matchers, e.g. {instance="web-3"}.
+  filters?: string;
+}
+
+function panelParams(ref: PanelRef, mode: string): URLSearchParams {
+  const params = new URLSearchParams({ path: ref.path, panel: ref.panel, mode });
+  if (ref.index) {
+    params.set('index', ref.index.toString());
+  }
+  for (const [name, values] of Object.entries(ref.values)) {
+    for (const value of values) {
+      params.append(`var-${name}`, value);
+    }
+  }
+  if (ref.filters) {
+    params.set('filters', ref.filters);
+  }
+  return params;
+}
+
+export async function queryPanelRange(
+  ref: PanelRef,
+  start: number,
+  end: number,
+  step: string,
+): Promise<QueryResponse> {
+  const params = panelParams(ref, 'range');
+  params.set('start', start.toString());
+  params.set('end', end.toString());
+  params.set('step', step);
+  return request(`/api/panel-query?${params}`);
+}
+
+export async function queryPanelInstant(ref: PanelRef, time?: number): Promise<InstantQueryResponse> {
+  const params = panelParams(ref, 'instant');
+  if (time !== undefined) {
+    params.set('time', time.toString());
+  }
+  return request(`/api/panel-query?${params}`);
+}
+
+export async function queryPanelReduced(
+  ref: PanelRef,
+  start: number,
+  end: number,
+  step: string,
+  reduce?: string,
+): Promise<InstantQueryResponse> {
+  const params = panelParams(ref, 'reduce');
+  params.set('start', start.toString());
+  params.set('end', end.toString());
+  params.set('step', step);
+  if (reduce) {
+    params.set('reduce', reduce);
+  }
+  return request(`/api/panel-query?${params}`);
+}
+
+export async function queryPanelHeatmap(
+  ref: PanelRef,
   start: number,
   end: number,
   step: string,
-  datasource?: string,
 ): Promise<QueryResponse> {
+  const params = panelParams(ref, 'heatmap');
+  params.set('start', start.toString());
+  params.set('end', end.toString());
+  params.set('step', step);
+  return request(`/api/panel-query?${params}`);
+}
+
+export async function queryPanelLogs(
+  ref: PanelRef,
+  start: number,
+  end: number,
+  limit?: number,
+): Promise<LogsResponse> {
+  const params = panelParams(ref, 'logs');
+  params.set('start', start.toString());
+  params.set('end', end.toString());
+  if (limit) {
+    params.set('limit', limit.toString());
+  }
+  return request(`/api/panel-query?${params}`);
+}
+
+export async function fetchAnnotations(
+  path: string,
+  start: number,
+  end: number,
+  step: string,
+): Promise<AnnotationsResponse> {
   const params = new URLSearchParams({
-    query,
+    path,
     start: start.toString(),
     end: end.toString(),
     step,
   });
-  if (datasource) {
-    params.set('datasource', datasource);
-  }
-  return request(`/api/query?${params}`);
+  return request(`/api/annotations?${params}`);
 }
 
-export async function fetchLabelValues(label: string, match?: string, datasource?: string): Promise<LabelValuesResponse> {
-  const params = new URLSearchParams({ label });
-  if (match) {
-    params.set('match', match);
+export async function fetchVariableValues(
+  variable: Variable,
+  query: string,
+  start?: number,
+  end?: number,
+): Promise<LabelValuesResponse> {
+  const params = new URLSearchParams({ query });
+  if (start !== undefined && end !== undefined) {
+    params.set('start', start.toString());
+    params.set('end', end.toString());
+  }
+  if (variable.datasource) {
+    params.set('datasource', variable.datasource);
+  }
+  if (variable.regex) {
+    params.set('regex', variable.regex);
+  }
+  if (variable.sort) {
+    params.set('sort', variable.sort);
   }
-  if (datasource) {
-    params.set('datasource', datasource);
+  if (variable.sort_desc) {
+    params.set('sort_desc', 'true');
   }
-  return request(`/api/label-values?${params}`);
+  return request(`/api/variable-values?${params}`);
 }
 
 export async function fetchDatasources(): Promise<DatasourcesResponse> {
@@ -2,6 +2,7 @@ import { useState, useEffect, useMemo, useCallback } from 'react';
 import type { TimeRange } from '../types';
 import { useDashboardDetail } from '../hooks/useDashboards';
 import { useVariables } from '../hooks/useVariables';
+import { useAnnotations } from '../hooks/useAnnotations';
 import { fetchDashboardSource, ApiError } from '../api/client';
 import { VariableBar } from './VariableBar';
 import { RowView } from './RowView';
@@ -10,28 +11,29 @@ interface DashboardViewProps {
   path: string;
   timeRange: TimeRange;
   onAuthError: () => void;
-  variableValues: Record<string, string>;
-  onVariableValuesChange: (values: Record<string, string>) => void;
+  variableValues: Record<string, string[]>;
+  onVariableValuesChange: (values: Record<string, string[]>) => void;
 }
 
 export function DashboardView({ path, timeRange, onAuthError, variableValues, onVariableValuesChange }: DashboardViewProps) {
   const { dashboard, loading, error } = useDashboardDetail(path, onAuthError);
-  const { variables, selectedValues, allValues, setVariableValue, loading: varsLoading } =
-    useVariables(dashboard?.variables, onAuthError, variableValues);
+  const { variables, selectedValues, repeatValues, queryValues, filters, setVariableValue, loading: varsLoading } =
+    useVariables(dashboard?.variables, onAuthError, timeRange, variableValues);
+  const annotations = useAnnotations(dashboard, timeRange);
 
-  const handleVariableChange = useCallback((name: string, value: string) => {
-    setVariableValue(name, value);
-    onVariableValuesChange({ ...variableValues, [name]: value });
+  const handleVariableChange = useCallback((name: string, values: string[]) => {
+    setVariableValue(name, values);
+    onVariableValuesChange({ ...variableValues, [name]: values });
   }, [setVariableValue, onVariableValuesChange, variableValues]);
   const repeatVarNames = useMemo(() => {
     if (!dashboard) return new Set<string>();
     return new Set(dashboard.rows.map((r) => r.repeat).filter(Boolean) as string[]);
   }, [dashboard]);
 
-  // Only hide variables with explicit hide: true
+  // Hide variables with explicit hide: true, and constants which have nothing to choose
   const hiddenVarNames = useMemo(() => {
     if (!dashboard?.variables) return new Set<string>();
-    return new Set(dashboard.variables.filter((v) => v.hide).map((v) => v.name));
+    return new Set(dashboard.variables.filter((v) => v.hide || v.type === 'constant').map((v) => v.name));
   }, [dashboard]);
 
   const visibleVariables = useMemo(
@@ -99,17 +101,23 @@ export function DashboardView({ path, timeRange, onAuthError, variableValues, on
             <div className="dashboard-loading">Loading variables...</div>
           ) : (
             dashboard.rows.map((row, idx) => {
-              if (row.repeat && allValues[row.repeat]) {
+              if (row.repeat && repeatValues[row.repeat]) {
                 // Repeat this row for each value of the variable
-                return allValues[row.repeat].map((value, repeatIdx) => {
-                  const repeatValues = { ...selectedValues, [row.repeat!]: value };
+                return repeatValues[row.repeat].map((value, repeatIdx) => {
+                  const rowValues = { ...selectedValues, [row.repeat!]: value };
+                  const rowQueryValues = { ...queryValues, [row.repeat!]: [value] };
                   return (
                     <RowView
                       key={`${idx}-${value}`}
                       row={row}
                       rowIndex={idx * 100 + repeatIdx}
+                      dashboardPath={dashboard.path}
+                      dashboardRow={idx}
                       timeRange={timeRange}
-                      variableValues={repeatValues}
+                      variableValues={rowValues}
+                      queryValues={rowQueryValues}
+                      filters={filters}
+                      annotations={annotations}
                     />
                   );
                 });
@@ -119,8 +127,13 @@ export function DashboardView({ path, timeRange, onAuthError, variableValues, on
                   key={idx}
                   row={row}
                   rowIndex={idx}
+                  dashboardPath={dashboard.path}
+                  dashboardRow={idx}
                   timeRange={timeRange}
                   variableValues={selectedValues}
+                  queryValues={queryValues}
+                  filters={filters}
+                  annotations={annotations}
                 />
               );
             })
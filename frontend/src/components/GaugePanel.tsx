@@ -0,0 +1,131 @@
+import type { InstantQueryResponse, Threshold } from '../types';
+import { formatValue } from '../utils/units';
+import { buildLabel } from '../utils/legend';
+import { thresholdColor } from '../utils/stat';
+import { gaugeFraction, thresholdBands, GAUGE_BASE_COLOR } from '../utils/gauge';
+
+interface GaugePanelProps {
+  title: string;
+  variant: 'gauge' | 'bargauge';
+  // Server-side reduced values, one sample per series.
+  data: InstantQueryResponse | null;
+  min: number;
+  max: number;
+  unit?: string;
+  legend?: string;
+  thresholds?: Threshold[];
+  loading: boolean;
+  error: string | null;
+  id?: string;
+}
+
+// Point on the gauge arc for fraction f (0 = left end, 1 = right end).
+function arcPoint(f: number, r: number): [number, number] {
+  const angle = Math.PI * (1 - f);
+  return [60 + r * Math.cos(angle), 60 - r * Math.sin(angle)];
+}
+
+function arcPath(from: number, to: number, r: number): string {
+  const [x1, y1] = arcPoint(from, r);
+  const [x2, y2] = arcPoint(to, r);
+  return `M ${x1} ${y1} A ${r} ${r} 0 0 1 ${x2} ${y2}`;
+}
+
+function Gauge({ value, min, max, unit, thresholds }: { value: number; min: number; max: number; unit?: string; thresholds?: Threshold[] }) {
+  const color = thresholdColor(value, thresholds) || GAUGE_BASE_COLOR;
+  const fraction = gaugeFraction(value, min, max);
+  return (
+    <svg className="gauge-svg" viewBox="0 0 120 70">
+      {thresholdBands(min, max, thresholds).map((band, idx) => (
+        <path
+          key={idx}
+          d={arcPath(gaugeFraction(band.from, min, max), gaugeFraction(band.to, min, max), 54)}
+          stroke={band.color}
+          strokeWidth={3}
+          fill="none"
+        />
+      ))}
+      <path d={arcPath(0, 1, 44)} stroke="var(--color-border)" strokeWidth={12} fill="none" />
+      {fraction > 0 && (
+        <path d={arcPath(0, fraction, 44)} stroke={color} strokeWidth={12} fill="none" />
+      )}
+      <text x={60} y={58} textAnchor="middle" className="gauge-value" fill={color}>
+        {formatValue(value, unit)}
+      </text>
+    </svg>
+  );
+}
+
+function BarGauge({ value, min, max, unit, thresholds }: { value: number; min: number; max: number; unit?: string; thresholds?: Threshold[] }) {
+  const color = thresholdColor(value, thresholds) || GAUGE_BASE_COLOR;
+  return (
+    <div className="bargauge-row">
+      <div className="bargauge-track">
+        <div
+          className="bargauge-fill"
+          style={{ width: `${gaugeFraction(value, min, max) * 100}%`, background: color }}
+        />
+      </div>
+      <span className="bargauge-value" style={{ color }}>{formatValue(value, unit)}</span>
+    </div>
+  );
+}
+
+export function GaugePanel({ title, variant, data, min, max, unit, legend, thresholds, loading, error, id }: GaugePanelProps) {
+  const className = `panel ${variant === 'gauge' ? 'gauge-panel' : 'bargauge-panel'}`;
+
+  const titleContent = (
+    <h3 className="panel-title">
+      {title}
+      {id && <a href={`#${id}`} className="panel-anchor">#</a>}
+    </h3>
+  );
+
+  if (loading) {
+    return (
+      <div className={className} id={id}>
+        {titleContent}
+        <div className="panel-loading">Loading...</div>
+      </div>
+    );
+  }
+
+  if (error) {
+    return (
+      <div className={className} id={id}>
+        {titleContent}
+        <div className="panel-error">{error}</div>
+      </div>
+    );
+  }
+
+  const results = data?.data?.result || [];
+  if (results.length === 0) {
+    return (
+      <div className={className} id={id}>
+        {titleContent}
+        <div className="panel-empty">No data</div>
+      </div>
+    );
+  }
+
+  return (
+    <div className={className} id={id}>
+      {titleContent}
+      <div className={variant === 'gauge' ? 'gauge-items' : 'bargauge-items'}>
+        {results.map((r, idx) => {
+          const value = parseFloat(r.value[1]);
+          const label = results.length > 1 ? buildLabel(r.metric, legend) : undefined;
+          return (
+            <div key={idx} className={variant === 'gauge' ? 'gauge-item' : 'bargauge-item'}>
+              {variant === 'gauge'
+                ? <Gauge value={value} min={min} max={max} unit={unit} thresholds={thresholds} />
+                : <BarGauge value={value} min={min} max={max} unit={unit} thresholds={thresholds} />}
+              {label && <div className="gauge-label">{label}</div>}
+            </div>
+          );
+        })}
+      </div>
+    </div>
+  );
+}
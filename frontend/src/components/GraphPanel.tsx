@@ -17,9 +17,10 @@ import {
 } from 'chart.js';
 import annotationPlugin from 'chartjs-plugin-annotation';
 import 'chartjs-adapter-date-fns';
-import type { QueryResponse, Threshold } from '../types';
+import type { AnnotationEvent, QueryResponse, Threshold } from '../types';
 import { getYAxisTickCallback } from '../utils/units';
 import { buildLabel } from '../utils/legend';
+import { buildEventAnnotations } from '../utils/annotations';
 
 ChartJS.register(
   CategoryScale,
@@ -49,6 +50,7 @@ interface GraphPanelProps {
   legendMaxHeight?: number;
   legendMaxWidth?: number;
   thresholds?: Threshold[];
+  annotations?: AnnotationEvent[];
   chartType?: 'line' | 'bar' | 'area' | 'scatter';
   stacked?: boolean;
   yScale?: 'linear' | 'log';
@@ -82,32 +84,33 @@ function insertGapNulls(
   return result;
 }
 
-function buildAnnotations(thresholds?: Threshold[]) {
-  if (!thresholds || thresholds.length === 0) return {};
+function buildAnnotations(thresholds?: Threshold[], events?: AnnotationEvent[]) {
+  if ((!thresholds || thresholds.length === 0) && (!events || events.length === 0)) return {};
+  const thresholdLines = (thresholds || []).map((th) => {
+    const color = th.color || '#ef4444';
+    return {
+      type: 'line' as const,
+      scaleID: 'y',
+      value: th.value,
+      borderColor: color,
+      borderWidth: 2,
+      borderDash: [6, 3],
+      drawTime: 'afterDatasetsDraw' as const,
+      ...(th.label ? {
+        label: {
+          display: true,
+          content: th.label,
+          position: 'end' as const,
+          backgroundColor: color,
+          color: '#fff',
+          font: { size: 11 },
+        },
+      } : {}),
+    };
+  });
   return {
     annotation: {
-      annotations: thresholds.map((th) => {
-        const color = th.color || '#ef4444';
-        return {
-          type: 'line' as const,
-          scaleID: 'y',
-          value: th.value,
-          borderColor: color,
-          borderWidth: 2,
-          borderDash: [6, 3],
-          drawTime: 'afterDatasetsDraw' as const,
-          ...(th.label ? {
-            label: {
-              display: true,
-              content: th.label,
-              position: 'end' as const,
-              backgroundColor: color,
-              color: '#fff',
-              font: { size: 11 },
-            },
-          } : {}),
-        };
-      }),
+      annotations: [...thresholdLines, ...buildEventAnnotations(events || [])],
     },
   };
 }
@@ -118,7 +121,7 @@ const COLORS = [
 ];
 
 
-export function GraphPanel({ title, data, unit, yMin, yMax, legend, legendDisplay, legendPosition, legendAlign, legendMaxHeight, legendMaxWidth, thresholds, chartType, stacked, yScale, stepSeconds, loading, error, id }: GraphPanelProps) {
+export function GraphPanel({ title, data, unit, yMin, yMax, legend, legendDisplay, legendPosition, legendAlign, legendMaxHeight, legendMaxWidth, thresholds, annotations, chartType, stacked, yScale, stepSeconds, loading, error, id }: GraphPanelProps) {
   const [expanded, setExpanded] = useState(false);
   const [chartHeight, setChartHeight] = useState<number | null>(null);
   const panelChartRef = useRef<HTMLDivElement>(null);
@@ -249,7 +252,7 @@ export function GraphPanel({ title, data, unit, yMin, yMax, legend, legendDispla
           usePointStyle: true,
         },
       },
-      ...buildAnnotations(thresholds),
+      ...buildAnnotations(thresholds, annotations),
     },
     scales: {
       x: {
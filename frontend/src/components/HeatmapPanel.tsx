@@ -0,0 +1,158 @@
+import { useEffect, useMemo, useRef, useState } from 'react';
+import type { QueryResponse } from '../types';
+import { buildHeatmap, bucketLabel, heatmapColor } from '../utils/heatmap';
+
+interface HeatmapPanelProps {
+  title: string;
+  // Per-bucket counts from /api/heatmap, one series per bucket.
+  data: QueryResponse | null;
+  unit?: string;
+  loading: boolean;
+  error: string | null;
+  id?: string;
+}
+
+interface HoverCell {
+  bucket: number;
+  time: number;
+  x: number;
+  y: number;
+}
+
+const CANVAS_HEIGHT = 220;
+
+function formatTime(ts: number): string {
+  return new Date(ts * 1000).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' });
+}
+
+export function HeatmapPanel({ title, data, unit, loading, error, id }: HeatmapPanelProps) {
+  const canvasRef = useRef<HTMLCanvasElement>(null);
+  const [width, setWidth] = useState(0);
+  const [hover, setHover] = useState<HoverCell | null>(null);
+
+  const heatmap = useMemo(() => buildHeatmap(data?.data?.result || []), [data]);
+  const labels = useMemo(
+    () => heatmap.buckets.map((le, i) => bucketLabel(le, heatmap.buckets[i - 1], unit)),
+    [heatmap, unit],
+  );
+
+  // Track the canvas width so cells are redrawn when the panel resizes.
+  useEffect(() => {
+    const canvas = canvasRef.current;
+    if (!canvas) return;
+    const observer = new ResizeObserver(() => setWidth(canvas.clientWidth));
+    observer.observe(canvas);
+    setWidth(canvas.clientWidth);
+    return () => observer.disconnect();
+  }, [heatmap]);
+
+  useEffect(() => {
+    const canvas = canvasRef.current;
+    if (!canvas || width === 0) return;
+    const ratio = window.devicePixelRatio || 1;
+    canvas.width = width * ratio;
+    canvas.height = CANVAS_HEIGHT * ratio;
+    const ctx = canvas.getContext('2d');
+    if (!ctx) return;
+    ctx.scale(ratio, ratio);
+    ctx.clearRect(0, 0, width, CANVAS_HEIGHT);
+
+    const { buckets, times, cells, max } = heatmap;
+    const cellW = width / times.length;
+    const cellH = CANVAS_HEIGHT / buckets.length;
+    cells.forEach((row, b) => {
+      // Lowest bucket at the bottom.
+      const y = CANVAS_HEIGHT - (b + 1) * cellH;
+      row.forEach((value, t) => {
+        const color = heatmapColor(value, max);
+        if (!color) return;
+        ctx.fillStyle = color;
+        ctx.fillRect(t * cellW, y, Math.ceil(cellW), Math.ceil(cellH));
+      });
+    });
+  }, [heatmap, width]);
+
+  const handleMouseMove = (e: React.MouseEvent<HTMLCanvasElement>) => {
+    const rect = e.currentTarget.getBoundingClientRect();
+    const x = e.clientX - rect.left;
+    const y = e.clientY - rect.top;
+    const time = Math.floor((x / rect.width) * heatmap.times.length);
+    const bucket = heatmap.buckets.length - 1 - Math.floor((y / rect.height) * heatmap.buckets.length);
+    if (time < 0 || time >= heatmap.times.length || bucket < 0 || bucket >= heatmap.buckets.length) {
+      setHover(null);
+      return;
+    }
+    setHover({ bucket, time, x, y });
+  };
+
+  const titleContent = (
+    <h3 className="panel-title">
+      {title}
+      {id && <a href={`#${id}`} className="panel-anchor">#</a>}
+    </h3>
+  );
+
+  if (loading) {
+    return (
+      <div className="panel heatmap-panel" id={id}>
+        {titleContent}
+        <div className="panel-loading">Loading...</div>
+      </div>
+    );
+  }
+
+  if (error) {
+    return (
+      <div className="panel heatmap-panel" id={id}>
+        {titleContent}
+        <div className="panel-error">{error}</div>
+      </div>
+    );
+  }
+
+  if (heatmap.buckets.length === 0 || heatmap.times.length === 0) {
+    return (
+      <div className="panel heatmap-panel" id={id}>
+        {titleContent}
+        <div className="panel-empty">No data</div>
+      </div>
+    );
+  }
+
+  const hoverValue = hover ? heatmap.cells[hover.bucket][hover.time] : NaN;
+
+  return (
+    <div className="panel heatmap-panel" id={id}>
+      {titleContent}
+      <div className="heatmap-body">
+        <div className="heatmap-y-axis" style={{ height: CANVAS_HEIGHT }}>
+          {[...labels].reverse().map((label, i) => (
+            <span key={i} className="heatmap-y-label">{label}</span>
+          ))}
+        </div>
+        <div className="heatmap-plot">
+          <canvas
+            ref={canvasRef}
+            className="heatmap-canvas"
+            style={{ height: CANVAS_HEIGHT }}
+            onMouseMove={handleMouseMove}
+            onMouseLeave={() => setHover(null)}
+          />
+          {hover && (
+            <div className="heatmap-tooltip" style={{ left: hover.x + 12, top: hover.y + 12 }}>
+              <div>{new Date(heatmap.times[hover.time] * 1000).toLocaleString()}</div>
+              <div>{labels[hover.bucket]}</div>
+              <div className="heatmap-tooltip-value">
+                {Number.isNaN(hoverValue) ? '-' : hoverValue.toLocaleString('en-US', { maximumFractionDigits: 3 })}
+              </div>
+            </div>
+          )}
+          <div className="heatmap-x-axis">
+            <span>{formatTime(heatmap.times[0])}</span>
+            <span>{formatTime(heatmap.times[heatmap.times.length - 1])}</span>
+          </div>
+        </div>
+      </div>
+    </div>
+  );
+}
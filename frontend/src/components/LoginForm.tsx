@@ -1,7 +1,15 @@
 import { useState, useEffect } from 'react';
-import { login, fetchAuthInfo } from '../api/client';
+import { login, fetchAuthInfo, ApiError } from '../api/client';
 import type { AuthInfo } from '../api/client';
 
+// Display names of the OAuth providers for their sign-in buttons.
+const providerLabels: Record<string, string> = {
+  github: 'GitHub',
+  gitlab: 'GitLab',
+  google: 'Google',
+  oidc: 'SSO',
+};
+
 interface LoginFormProps {
   onLoginSuccess: () => void;
 }
@@ -29,7 +37,7 @@ export function LoginForm({ onLoginSuccess }: LoginFormProps) {
     if (oauthError) {
       const messages: Record<string, string> = {
         oauth_failed: 'OAuth authentication failed',
-        access_denied: 'Access denied. You are not in the allowed users list.',
+        access_denied: 'Access denied. Your account is not allowed to sign in.',
         unknown_provider: 'Unknown OAuth provider',
         session_failed: 'Session creation failed',
       };
@@ -47,8 +55,12 @@ export function LoginForm({ onLoginSuccess }: LoginFormProps) {
     try {
       await login(userId, password);
       onLoginSuccess();
-    } catch {
-      setError('Invalid credentials');
+    } catch (err) {
+      if (err instanceof ApiError && err.status === 429) {
+        setError('Too many failed attempts. Please try again later.');
+      } else {
+        setError('Invalid credentials');
+      }
     } finally {
       setLoading(false);
     }
@@ -79,7 +91,7 @@ export function LoginForm({ onLoginSuccess }: LoginFormProps) {
                 href={provider.url}
                 className={`oauth-button oauth-button-${provider.name}`}
               >
-                Sign in with {provider.name.charAt(0).toUpperCase() + provider.name.slice(1)}
+                Sign in with {providerLabels[provider.name] ?? provider.name}
               </a>
             ))}
           </div>
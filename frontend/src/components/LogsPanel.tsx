@@ -0,0 +1,66 @@
+import type { LogsResponse } from '../types';
+import { mergeLogStreams } from '../utils/logs';
+
+interface LogsPanelProps {
+  title: string;
+  data: LogsResponse | null;
+  loading: boolean;
+  error: string | null;
+  id?: string;
+}
+
+function formatTimestamp(ns: string): string {
+  const ms = Number(BigInt(ns) / 1_000_000n);
+  return new Date(ms).toLocaleString();
+}
+
+export function LogsPanel({ title, data, loading, error, id }: LogsPanelProps) {
+  const titleContent = (
+    <h3 className="panel-title">
+      {title}
+      {id && <a href={`#${id}`} className="panel-anchor">#</a>}
+    </h3>
+  );
+
+  if (loading) {
+    return (
+      <div className="panel logs-panel" id={id}>
+        {titleContent}
+        <div className="panel-loading">Loading...</div>
+      </div>
+    );
+  }
+
+  if (error) {
+    return (
+      <div className="panel logs-panel" id={id}>
+        {titleContent}
+        <div className="panel-error">{error}</div>
+      </div>
+    );
+  }
+
+  const lines = mergeLogStreams(data?.data?.result || []);
+  if (lines.length === 0) {
+    return (
+      <div className="panel logs-panel" id={id}>
+        {titleContent}
+        <div className="panel-empty">No logs</div>
+      </div>
+    );
+  }
+
+  return (
+    <div className="panel logs-panel" id={id}>
+      {titleContent}
+      <div className="logs-lines">
+        {lines.map((entry, idx) => (
+          <div key={idx} className="logs-line" title={Object.entries(entry.labels).map(([k, v]) => `${k}=${v}`).join(', ')}>
+            <span className="logs-time">{formatTimestamp(entry.timestamp)}</span>
+            <span className="logs-text">{entry.line}</span>
+          </div>
+        ))}
+      </div>
+    </div>
+  );
+}
@@ -1,19 +1,37 @@
 import { useMemo } from 'react';
-import type { Row, TimeRange } from '../types';
+import type { AnnotationEvent, Row, TimeRange } from '../types';
 import { GraphPanel } from './GraphPanel';
 import { MarkdownPanel } from './MarkdownPanel';
+import { LogsPanel } from './LogsPanel';
+import { StatPanel } from './StatPanel';
+import { TablePanel } from './TablePanel';
+import { GaugePanel } from './GaugePanel';
+import { HeatmapPanel } from './HeatmapPanel';
 import { useQuery } from '../hooks/useQuery';
+import { useLogs } from '../hooks/useLogs';
+import { useInstantQueries } from '../hooks/useInstantQueries';
+import { useReducedQuery } from '../hooks/useReducedQuery';
+import { useHeatmapQuery } from '../hooks/useHeatmapQuery';
+import type { PanelRef } from '../api/client';
 import { substituteVariables } from '../utils/variables';
 import { getTimeRangeParams } from '../utils/time';
 
 interface RowViewProps {
   row: Row;
   rowIndex: number;
+  // The dashboard and the index of the row in it, which panel queries refer to.
+  dashboardPath: string;
+  dashboardRow: number;
   timeRange: TimeRange;
   variableValues?: Record<string, string>;
+  // Values of each variable sent with panel queries.
+  queryValues?: Record<string, string[]>;
+  // Ad-hoc filter matchers added to every PromQL panel query.
+  filters?: string;
+  annotations?: AnnotationEvent[];
 }
 
-export function RowView({ row, rowIndex, timeRange, variableValues }: RowViewProps) {
+export function RowView({ row, rowIndex, dashboardPath, dashboardRow, timeRange, variableValues, queryValues, filters, annotations }: RowViewProps) {
   const vars = variableValues || {};
   const title = substituteVariables(row.title, vars);
 
@@ -34,7 +52,14 @@ export function RowView({ row, rowIndex, timeRange, variableValues }: RowViewPro
           const span = panel.span || defaultSpan;
           return (
             <div key={idx} style={{ gridColumn: `span ${span}` }}>
-              <PanelRenderer panel={panel} panelId={panelId} timeRange={timeRange} variableValues={vars} />
+              <PanelRenderer
+                panel={panel}
+                panelId={panelId}
+                panelRef={{ path: dashboardPath, panel: `${dashboardRow}-${idx}`, values: queryValues || {}, filters }}
+                timeRange={timeRange}
+                variableValues={vars}
+                annotations={annotations}
+              />
             </div>
           );
         })}
@@ -46,29 +71,63 @@ export function RowView({ row, rowIndex, timeRange, variableValues }: RowViewPro
 interface PanelRendererProps {
   panel: Row['panels'][0];
   panelId: string;
+  // The panel's query, which the server substitutes variables in and runs.
+  panelRef: PanelRef;
   timeRange: TimeRange;
   variableValues: Record<string, string>;
+  annotations?: AnnotationEvent[];
 }
 
-function PanelRenderer({ panel, panelId, timeRange, variableValues }: PanelRendererProps) {
+function PanelRenderer({ panel, panelId, panelRef, timeRange, variableValues, annotations }: PanelRendererProps) {
   const substitutedTitle = substituteVariables(panel.title, variableValues);
-  const substitutedQuery = useMemo(
-    () => panel.query ? substituteVariables(panel.query, variableValues) : undefined,
-    [panel.query, variableValues],
-  );
   const substitutedContent = useMemo(
     () => panel.content ? substituteVariables(panel.content, variableValues) : undefined,
     [panel.content, variableValues],
   );
-  const substitutedDatasource = useMemo(
-    () => panel.datasource ? substituteVariables(panel.datasource, variableValues) : undefined,
-    [panel.datasource, variableValues],
-  );
+  const queryRef = panel.query ? panelRef : undefined;
+
+  // Stat panels take their value reduced server-side and only need the range
+  // query for the sparkline.
+  const statNeedsRange = panel.type === 'stat' && !!panel.sparkline;
 
   const { data, loading, error } = useQuery(
-    panel.type === 'graph' ? substitutedQuery : undefined,
+    panel.type === 'graph' || statNeedsRange ? queryRef : undefined,
+    timeRange,
+  );
+
+  const isReduced = panel.type === 'stat' || panel.type === 'gauge' || panel.type === 'bargauge';
+  const reduced = useReducedQuery(
+    isReduced ? queryRef : undefined,
+    timeRange,
+    panel.reduce,
+  );
+
+  const heatmap = useHeatmapQuery(
+    panel.type === 'heatmap' ? queryRef : undefined,
+    timeRange,
+  );
+
+  // Table panels take either a single query or a list of named queries.
+  const tableColumns = useMemo(() => {
+    if (panel.type !== 'table') return [];
+    if (panel.queries && panel.queries.length > 0) {
+      return panel.queries.map((q) => ({
+        name: q.name || 'Value',
+        unit: q.unit || panel.unit,
+      }));
+    }
+    return panel.query ? [{ name: 'Value', unit: panel.unit }] : [];
+  }, [panel.type, panel.queries, panel.query, panel.unit]);
+  const table = useInstantQueries(
+    panel.type === 'table' && tableColumns.length > 0
+      ? tableColumns.map((_, index) => ({ ...panelRef, index }))
+      : undefined,
     timeRange,
-    substitutedDatasource,
+  );
+  const logs = useLogs(
+    panel.type === 'logs' ? queryRef : undefined,
+    timeRange,
+    panel.limit,
   );
 
   const stepSeconds = useMemo(() => {
@@ -80,6 +139,75 @@ function PanelRenderer({ panel, panelId, timeRange, variableValues }: PanelRende
     return <MarkdownPanel title={substitutedTitle} content={substitutedContent || ''} id={panelId} />;
   }
 
+  if (panel.type === 'stat') {
+    return (
+      <StatPanel
+        title={substitutedTitle}
+        data={reduced.data}
+        rangeData={statNeedsRange ? data : null}
+        unit={panel.unit}
+        legend={panel.legend}
+        thresholds={panel.thresholds}
+        sparkline={panel.sparkline}
+        loading={reduced.loading || loading}
+        error={reduced.error || error}
+        id={panelId}
+      />
+    );
+  }
+
+  if (panel.type === 'gauge' || panel.type === 'bargauge') {
+    return (
+      <GaugePanel
+        title={substitutedTitle}
+        variant={panel.type}
+        data={reduced.data}
+        min={panel.y_min ?? 0}
+        max={panel.y_max ?? 100}
+        unit={panel.unit}
+        legend={panel.legend}
+        thresholds={panel.thresholds}
+        loading={reduced.loading}
+        error={reduced.error}
+        id={panelId}
+      />
+    );
+  }
+
+  if (panel.type === 'heatmap') {
+    return (
+      <HeatmapPanel
+        title={substitutedTitle}
+        data={heatmap.data}
+        unit={panel.unit}
+        loading={heatmap.loading}
+        error={heatmap.error}
+        id={panelId}
+      />
+    );
+  }
+
+  if (panel.type === 'table') {
+    return (
+      <TablePanel
+        title={substitutedTitle}
+        data={table.data}
+        valueColumns={tableColumns}
+        columns={panel.columns}
+        hiddenLabels={panel.hidden_labels}
+        sortBy={panel.sort_by}
+        sortDesc={panel.sort_desc}
+        loading={table.loading}
+        error={table.error}
+        id={panelId}
+      />
+    );
+  }
+
+  if (panel.type === 'logs') {
+    return <LogsPanel title={substitutedTitle} data={logs.data} loading={logs.loading} error={logs.error} id={panelId} />;
+  }
+
   return (
     <GraphPanel
       title={substitutedTitle}
@@ -94,6 +222,7 @@ function PanelRenderer({ panel, panelId, timeRange, variableValues }: PanelRende
       legendMaxHeight={panel.legend_max_height}
       legendMaxWidth={panel.legend_max_width}
       thresholds={panel.thresholds}
+      annotations={annotations}
       chartType={panel.chart_type}
       stacked={panel.stacked}
       yScale={panel.y_scale}
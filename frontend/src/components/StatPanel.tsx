@@ -0,0 +1,143 @@
+import { Line } from 'react-chartjs-2';
+import {
+  Chart as ChartJS,
+  LinearScale,
+  PointElement,
+  LineElement,
+  Filler,
+} from 'chart.js';
+import type { InstantQueryResponse, QueryResponse, Threshold } from '../types';
+import { formatValue } from '../utils/units';
+import { buildLabel } from '../utils/legend';
+import { thresholdColor } from '../utils/stat';
+
+ChartJS.register(LinearScale, PointElement, LineElement, Filler);
+
+interface StatPanelProps {
+  title: string;
+  // The value of each series, reduced server-side.
+  data: InstantQueryResponse | null;
+  // Range query result, used for the sparkline.
+  rangeData: QueryResponse | null;
+  unit?: string;
+  legend?: string;
+  thresholds?: Threshold[];
+  sparkline?: boolean;
+  loading: boolean;
+  error: string | null;
+  id?: string;
+}
+
+interface StatItem {
+  label: string;
+  value: number | null;
+  spark?: [number, string][];
+}
+
+function seriesKey(metric: Record<string, string>): string {
+  return JSON.stringify(Object.entries(metric).sort(([a], [b]) => a.localeCompare(b)));
+}
+
+function buildItems(props: StatPanelProps): StatItem[] {
+  const { data, rangeData, legend } = props;
+  const ranges = new Map<string, [number, string][]>();
+  for (const r of rangeData?.data?.result || []) {
+    ranges.set(seriesKey(r.metric), r.values);
+  }
+
+  return (data?.data?.result || []).map((r) => ({
+    label: buildLabel(r.metric, legend),
+    value: parseFloat(r.value[1]),
+    spark: ranges.get(seriesKey(r.metric)),
+  }));
+}
+
+function Sparkline({ values, color }: { values: [number, string][]; color: string }) {
+  const data = {
+    datasets: [{
+      data: values.map(([ts, v]) => ({ x: ts, y: parseFloat(v) })),
+      borderColor: color,
+      backgroundColor: color + '20',
+      borderWidth: 1.5,
+      pointRadius: 0,
+      fill: 'origin',
+      tension: 0.1,
+    }],
+  };
+  const options = {
+    responsive: true,
+    maintainAspectRatio: false,
+    animation: false as const,
+    plugins: { legend: { display: false }, tooltip: { enabled: false } },
+    scales: {
+      x: { type: 'linear' as const, display: false },
+      y: { display: false },
+    },
+  };
+  return (
+    <div className="stat-sparkline">
+      <Line data={data} options={options} />
+    </div>
+  );
+}
+
+export function StatPanel(props: StatPanelProps) {
+  const { title, unit, thresholds, sparkline, loading, error, id } = props;
+
+  const titleContent = (
+    <h3 className="panel-title">
+      {title}
+      {id && <a href={`#${id}`} className="panel-anchor">#</a>}
+    </h3>
+  );
+
+  if (loading) {
+    return (
+      <div className="panel stat-panel" id={id}>
+        {titleContent}
+        <div className="panel-loading">Loading...</div>
+      </div>
+    );
+  }
+
+  if (error) {
+    return (
+      <div className="panel stat-panel" id={id}>
+        {titleContent}
+        <div className="panel-error">{error}</div>
+      </div>
+    );
+  }
+
+  const items = buildItems(props);
+  if (items.length === 0) {
+    return (
+      <div className="panel stat-panel" id={id}>
+        {titleContent}
+        <div className="panel-empty">No data</div>
+      </div>
+    );
+  }
+
+  return (
+    <div className="panel stat-panel" id={id}>
+      {titleContent}
+      <div className="stat-items">
+        {items.map((item, idx) => {
+          const color = item.value !== null ? thresholdColor(item.value, thresholds) : undefined;
+          return (
+            <div key={idx} className="stat-item">
+              <div className="stat-value" style={color ? { color } : undefined}>
+                {item.value !== null && !Number.isNaN(item.value) ? formatValue(item.value, unit) : '-'}
+              </div>
+              {items.length > 1 && <div className="stat-label">{item.label}</div>}
+              {sparkline && item.spark && item.spark.length > 1 && (
+                <Sparkline values={item.spark} color={color || '#3b82f6'} />
+              )}
+            </div>
+          );
+        })}
+      </div>
+    </div>
+  );
+}
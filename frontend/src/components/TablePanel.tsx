@@ -0,0 +1,111 @@
+import { useState } from 'react';
+import type { InstantQueryResponse } from '../types';
+import { formatValue } from '../utils/units';
+import { buildTable, sortRows, type TableCell, type ValueColumnDef } from '../utils/table';
+
+interface TablePanelProps {
+  title: string;
+  // data[i] is the result of the query behind valueColumns[i].
+  data: InstantQueryResponse[] | null;
+  valueColumns: ValueColumnDef[];
+  columns?: string[];
+  hiddenLabels?: string[];
+  sortBy?: string;
+  sortDesc?: boolean;
+  loading: boolean;
+  error: string | null;
+  id?: string;
+}
+
+function formatCell(value: TableCell, isValue: boolean, unit?: string): string {
+  if (value === undefined) return '';
+  if (isValue && typeof value === 'number') {
+    return Number.isNaN(value) ? '-' : formatValue(value, unit);
+  }
+  return String(value);
+}
+
+export function TablePanel({ title, data, valueColumns, columns, hiddenLabels, sortBy, sortDesc, loading, error, id }: TablePanelProps) {
+  // Clicking a header overrides the configured sort until the panel remounts.
+  const [sort, setSort] = useState<{ column?: string; desc: boolean }>({ column: sortBy, desc: !!sortDesc });
+
+  const titleContent = (
+    <h3 className="panel-title">
+      {title}
+      {id && <a href={`#${id}`} className="panel-anchor">#</a>}
+    </h3>
+  );
+
+  if (loading) {
+    return (
+      <div className="panel table-panel" id={id}>
+        {titleContent}
+        <div className="panel-loading">Loading...</div>
+      </div>
+    );
+  }
+
+  if (error) {
+    return (
+      <div className="panel table-panel" id={id}>
+        {titleContent}
+        <div className="panel-error">{error}</div>
+      </div>
+    );
+  }
+
+  const table = buildTable(
+    (data || []).map((r) => r.data?.result || []),
+    valueColumns,
+    { columns, hiddenLabels },
+  );
+  if (table.rows.length === 0) {
+    return (
+      <div className="panel table-panel" id={id}>
+        {titleContent}
+        <div className="panel-empty">No data</div>
+      </div>
+    );
+  }
+
+  const rows = sort.column ? sortRows(table.rows, sort.column, sort.desc) : table.rows;
+
+  const toggleSort = (column: string) => {
+    setSort((prev) => (prev.column === column ? { column, desc: !prev.desc } : { column, desc: false }));
+  };
+
+  return (
+    <div className="panel table-panel" id={id}>
+      {titleContent}
+      <div className="table-scroll">
+        <table className="data-table">
+          <thead>
+            <tr>
+              {table.columns.map((col) => (
+                <th
+                  key={col.name}
+                  className={col.isValue ? 'numeric' : undefined}
+                  onClick={() => toggleSort(col.name)}
+                >
+                  {col.name}
+                  {sort.column === col.name && <span className="sort-indicator">{sort.desc ? ' ▼' : ' ▲'}</span>}
+                </th>
+              ))}
+            </tr>
+          </thead>
+          <tbody>
+            {rows.map((row, idx) => (
+              <tr key={idx}>
+                {table.columns.map((col) => (
+                  <td key={col.name} className={col.isValue ? 'numeric' : undefined}>
+                    {formatCell(row[col.name], col.isValue, col.unit)}
+                  </td>
+                ))}
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      </div>
+    </div>
+  );
+}
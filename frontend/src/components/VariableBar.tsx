@@ -1,9 +1,204 @@
+import { useEffect, useRef, useState } from 'react';
 import type { VariableState } from '../hooks/useVariables';
+import { fetchVariableValues } from '../api/client';
+import { ALL_VALUE, AUTO_INTERVAL, FILTER_OPERATORS, formatFilter, toggleSelection } from '../utils/variables';
+import type { AdhocFilter } from '../utils/variables';
 
 interface VariableBarProps {
   variables: VariableState[];
   repeatVarNames: Set<string>;
-  onValueChange: (name: string, value: string) => void;
+  onValueChange: (name: string, values: string[]) => void;
+}
+
+function selectionSummary(selected: string[]): string {
+  if (selected.includes(ALL_VALUE)) return 'All';
+  if (selected.length <= 1) return selected[0] || '';
+  return `${selected[0]} + ${selected.length - 1}`;
+}
+
+interface MultiSelectProps {
+  variable: VariableState;
+  onChange: (values: string[]) => void;
+}
+
+// MultiSelect is a dropdown of checkboxes for multi-value variables.
+function MultiSelect({ variable, onChange }: MultiSelectProps) {
+  const [open, setOpen] = useState(false);
+  const ref = useRef<HTMLDivElement>(null);
+
+  useEffect(() => {
+    if (!open) return;
+    const onMouseDown = (e: MouseEvent) => {
+      if (ref.current && !ref.current.contains(e.target as Node)) {
+        setOpen(false);
+      }
+    };
+    document.addEventListener('mousedown', onMouseDown);
+    return () => document.removeEventListener('mousedown', onMouseDown);
+  }, [open]);
+
+  const options = variable.includeAll ? [ALL_VALUE, ...variable.values] : variable.values;
+
+  return (
+    <div className="variable-multi" ref={ref}>
+      <button type="button" className="variable-select variable-multi-toggle" onClick={() => setOpen(!open)}>
+        {selectionSummary(variable.selected)}
+      </button>
+      {open && (
+        <div className="variable-multi-menu">
+          {options.map((value) => (
+            <label key={value} className="variable-multi-option">
+              <input
+                type="checkbox"
+                checked={variable.selected.includes(value)}
+                onChange={() => onChange(toggleSelection(variable.selected, value, variable.includeAll))}
+              />
+              {value === ALL_VALUE ? 'All' : value}
+            </label>
+          ))}
+        </div>
+      )}
+    </div>
+  );
+}
+
+interface TextboxProps {
+  value: string;
+  onChange: (value: string) => void;
+}
+
+// Textbox edits free text and applies it on Enter or when focus leaves.
+function Textbox({ value, onChange }: TextboxProps) {
+  const [text, setText] = useState(value);
+
+  useEffect(() => setText(value), [value]);
+
+  const commit = () => {
+    if (text !== value) onChange(text);
+  };
+
+  return (
+    <input
+      type="text"
+      className="variable-input"
+      value={text}
+      onChange={(e) => setText(e.target.value)}
+      onBlur={commit}
+      onKeyDown={(e) => {
+        if (e.key === 'Enter') commit();
+      }}
+    />
+  );
+}
+
+interface AdhocFiltersProps {
+  variable: VariableState;
+  onChange: (filters: string[]) => void;
+}
+
+// AdhocFilters edits the label matchers added to every panel query. Label
+// names and values from the variable's datasource are offered as suggestions.
+function AdhocFilters({ variable, onChange }: AdhocFiltersProps) {
+  const [key, setKey] = useState('');
+  const [op, setOp] = useState<AdhocFilter['op']>('=');
+  const [value, setValue] = useState('');
+  const [keys, setKeys] = useState<string[]>([]);
+  const [values, setValues] = useState<string[]>([]);
+  const { name, datasource } = variable;
+
+  useEffect(() => {
+    let cancelled = false;
+    fetchVariableValues({ name, datasource }, 'label_names()')
+      .then((resp) => {
+        if (!cancelled) setKeys((resp.data || []).filter((k) => k !== '__name__'));
+      })
+      .catch(() => {
+        if (!cancelled) setKeys([]);
+      });
+    return () => {
+      cancelled = true;
+    };
+  }, [name, datasource]);
+
+  useEffect(() => {
+    setValues([]);
+    if (!keys.includes(key)) return;
+    let cancelled = false;
+    fetchVariableValues({ name, datasource }, `label_values(${key})`)
+      .then((resp) => {
+        if (!cancelled) setValues(resp.data || []);
+      })
+      .catch(() => {});
+    return () => {
+      cancelled = true;
+    };
+  }, [key, keys, name, datasource]);
+
+  const validKey = /^[a-zA-Z_][a-zA-Z0-9_]*$/.test(key);
+  const add = () => {
+    if (!validKey) return;
+    const filter = formatFilter({ key, op, value });
+    if (!variable.selected.includes(filter)) onChange([...variable.selected, filter]);
+    setKey('');
+    setValue('');
+  };
+
+  return (
+    <div className="adhoc-filters">
+      {variable.selected.map((filter) => (
+        <span key={filter} className="adhoc-filter">
+          {filter}
+          <button
+            type="button"
+            className="adhoc-filter-remove"
+            aria-label={`Remove ${filter}`}
+            onClick={() => onChange(variable.selected.filter((f) => f !== filter))}
+          >
+            ×
+          </button>
+        </span>
+      ))}
+      <input
+        type="text"
+        className="variable-input adhoc-filter-key"
+        placeholder="label"
+        list={`adhoc-keys-${name}`}
+        value={key}
+        onChange={(e) => setKey(e.target.value)}
+      />
+      <datalist id={`adhoc-keys-${name}`}>
+        {keys.map((k) => (
+          <option key={k} value={k} />
+        ))}
+      </datalist>
+      <select className="variable-select adhoc-filter-op" value={op} onChange={(e) => setOp(e.target.value as AdhocFilter['op'])}>
+        {FILTER_OPERATORS.map((o) => (
+          <option key={o} value={o}>
+            {o}
+          </option>
+        ))}
+      </select>
+      <input
+        type="text"
+        className="variable-input adhoc-filter-value"
+        placeholder="value"
+        list={`adhoc-values-${name}`}
+        value={value}
+        onChange={(e) => setValue(e.target.value)}
+        onKeyDown={(e) => {
+          if (e.key === 'Enter') add();
+        }}
+      />
+      <datalist id={`adhoc-values-${name}`}>
+        {values.map((v) => (
+          <option key={v} value={v} />
+        ))}
+      </datalist>
+      <button type="button" className="adhoc-filter-add" onClick={add} disabled={!validKey}>
+        Add
+      </button>
+    </div>
+  );
 }
 
 export function VariableBar({ variables, repeatVarNames, onValueChange }: VariableBarProps) {
@@ -13,6 +208,8 @@ export function VariableBar({ variables, repeatVarNames, onValueChange }: Variab
     <div className="variable-bar">
       {variables.map((variable) => {
         const isRepeat = repeatVarNames.has(variable.name);
+        // Single-value repeat variables always repeat over every value.
+        const repeatsAll = isRepeat && !variable.multi && !variable.includeAll;
         return (
           <div key={variable.name} className="variable-selector">
             <label className="variable-label">
@@ -23,21 +220,30 @@ export function VariableBar({ variables, repeatVarNames, onValueChange }: Variab
               <span className="variable-loading">Loading...</span>
             ) : variable.error ? (
               <span className="variable-error">{variable.error}</span>
+            ) : variable.type === 'adhoc_filters' ? (
+              <AdhocFilters variable={variable} onChange={(filters) => onValueChange(variable.name, filters)} />
+            ) : variable.type === 'textbox' ? (
+              <Textbox value={variable.selected[0] ?? ''} onChange={(text) => onValueChange(variable.name, [text])} />
+            ) : variable.multi ? (
+              <MultiSelect variable={variable} onChange={(values) => onValueChange(variable.name, values)} />
             ) : (
               <select
                 className="variable-select"
-                value={variable.selected}
-                onChange={(e) => onValueChange(variable.name, e.target.value)}
-                disabled={isRepeat}
+                value={variable.selected[0] || ''}
+                onChange={(e) => onValueChange(variable.name, [e.target.value])}
+                disabled={repeatsAll}
               >
-                {isRepeat ? (
-                  <option value={variable.selected}>All ({variable.values.length})</option>
+                {repeatsAll ? (
+                  <option value={variable.selected[0] || ''}>All ({variable.values.length})</option>
                 ) : (
-                  variable.values.map((value) => (
-                    <option key={value} value={value}>
-                      {value}
-                    </option>
-                  ))
+                  <>
+                    {variable.includeAll && <option value={ALL_VALUE}>All</option>}
+                    {variable.values.map((value) => (
+                      <option key={value} value={value}>
+                        {value === AUTO_INTERVAL ? 'auto' : value}
+                      </option>
+                    ))}
+                  </>
                 )}
               </select>
             )}
@@ -0,0 +1,38 @@
+import { useState, useEffect } from 'react';
+import { fetchAnnotations } from '../api/client';
+import type { AnnotationEvent, Dashboard, TimeRange } from '../types';
+import { getTimeRangeParams } from '../utils/time';
+
+// useAnnotations fetches the annotation events of a dashboard for the
+// selected time range. Dashboards without annotations make no request.
+// Failures only hide the markers; they never block the panels.
+export function useAnnotations(dashboard: Dashboard | null, timeRange: TimeRange): AnnotationEvent[] {
+  const [events, setEvents] = useState<AnnotationEvent[]>([]);
+  const path = dashboard?.annotations?.length ? dashboard.path : undefined;
+
+  useEffect(() => {
+    if (!path) {
+      setEvents([]);
+      return;
+    }
+
+    let cancelled = false;
+    const { start, end, step } = getTimeRangeParams(timeRange);
+
+    fetchAnnotations(path, start, end, step)
+      .then((result) => {
+        if (!cancelled) {
+          setEvents(result.annotations || []);
+        }
+      })
+      .catch((err) => {
+        console.error('Failed to fetch annotations:', err);
+      });
+
+    return () => {
+      cancelled = true;
+    };
+  }, [path, timeRange]);
+
+  return events;
+}
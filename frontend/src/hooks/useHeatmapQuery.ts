@@ -0,0 +1,57 @@
+import { useState, useEffect } from 'react';
+import { queryPanelHeatmap, ApiError } from '../api/client';
+import type { PanelRef } from '../api/client';
+import type { QueryResponse, TimeRange } from '../types';
+import { getTimeRangeParams } from '../utils/time';
+
+interface UseHeatmapQueryResult {
+  data: QueryResponse | null;
+  loading: boolean;
+  error: string | null;
+}
+
+// useHeatmapQuery fetches per-bucket histogram counts of the panel query ref
+// over the selected time range, one series per bucket ordered by upper bound.
+export function useHeatmapQuery(ref: PanelRef | undefined, timeRange: TimeRange): UseHeatmapQueryResult {
+  const [data, setData] = useState<QueryResponse | null>(null);
+  const [loading, setLoading] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  // Compare by content so a new ref for the same query does not refetch.
+  const key = ref ? JSON.stringify(ref) : undefined;
+
+  useEffect(() => {
+    if (!key) return;
+    const panelRef: PanelRef = JSON.parse(key);
+
+    let cancelled = false;
+    setLoading(true);
+    setError(null);
+
+    const { start, end, step } = getTimeRangeParams(timeRange);
+
+    queryPanelHeatmap(panelRef, start, end, step)
+      .then((result) => {
+        if (!cancelled) {
+          setData(result);
+          setLoading(false);
+        }
+      })
+      .catch((err) => {
+        if (!cancelled) {
+          if (err instanceof ApiError && err.status === 401) {
+            setError('Session expired');
+          } else {
+            setError(err.message || 'Query failed');
+          }
+          setLoading(false);
+        }
+      });
+
+    return () => {
+      cancelled = true;
+    };
+  }, [key, timeRange]);
+
+  return { data, loading, error };
+}
@@ -0,0 +1,57 @@
+import { useState, useEffect } from 'react';
+import { queryPanelInstant, ApiError } from '../api/client';
+import type { PanelRef } from '../api/client';
+import type { InstantQueryResponse, TimeRange } from '../types';
+import { getTimeRangeParams } from '../utils/time';
+
+interface UseInstantQueriesResult {
+  data: InstantQueryResponse[] | null;
+  loading: boolean;
+  error: string | null;
+}
+
+// useInstantQueries evaluates several panel queries at the end of the selected
+// time range and resolves once all of them have returned.
+export function useInstantQueries(refs: PanelRef[] | undefined, timeRange: TimeRange): UseInstantQueriesResult {
+  const [data, setData] = useState<InstantQueryResponse[] | null>(null);
+  const [loading, setLoading] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  // Compare by content so a new array with the same queries does not refetch.
+  const key = refs ? JSON.stringify(refs) : undefined;
+
+  useEffect(() => {
+    if (!key) return;
+    const list: PanelRef[] = JSON.parse(key);
+
+    let cancelled = false;
+    setLoading(true);
+    setError(null);
+
+    const { end } = getTimeRangeParams(timeRange);
+
+    Promise.all(list.map((r) => queryPanelInstant(r, end)))
+      .then((results) => {
+        if (!cancelled) {
+          setData(results);
+          setLoading(false);
+        }
+      })
+      .catch((err) => {
+        if (!cancelled) {
+          if (err instanceof ApiError && err.status === 401) {
+            setError('Session expired');
+          } else {
+            setError(err.message || 'Query failed');
+          }
+          setLoading(false);
+        }
+      });
+
+    return () => {
+      cancelled = true;
+    };
+  }, [key, timeRange]);
+
+  return { data, loading, error };
+}
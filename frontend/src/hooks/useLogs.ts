@@ -0,0 +1,57 @@
+import { useState, useEffect } from 'react';
+import { queryPanelLogs, ApiError } from '../api/client';
+import type { PanelRef } from '../api/client';
+import type { LogsResponse, TimeRange } from '../types';
+import { getTimeRangeParams } from '../utils/time';
+
+interface UseLogsResult {
+  data: LogsResponse | null;
+  loading: boolean;
+  error: string | null;
+}
+
+// useLogs fetches the log lines of the panel query ref over the selected time
+// range.
+export function useLogs(ref: PanelRef | undefined, timeRange: TimeRange, limit?: number): UseLogsResult {
+  const [data, setData] = useState<LogsResponse | null>(null);
+  const [loading, setLoading] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  // Compare by content so a new ref for the same query does not refetch.
+  const key = ref ? JSON.stringify(ref) : undefined;
+
+  useEffect(() => {
+    if (!key) return;
+    const panelRef: PanelRef = JSON.parse(key);
+
+    let cancelled = false;
+    setLoading(true);
+    setError(null);
+
+    const { start, end } = getTimeRangeParams(timeRange);
+
+    queryPanelLogs(panelRef, start, end, limit)
+      .then((result) => {
+        if (!cancelled) {
+          setData(result);
+          setLoading(false);
+        }
+      })
+      .catch((err) => {
+        if (!cancelled) {
+          if (err instanceof ApiError && err.status === 401) {
+            setError('Session expired');
+          } else {
+            setError(err.message || 'Logs query failed');
+          }
+          setLoading(false);
+        }
+      });
+
+    return () => {
+      cancelled = true;
+    };
+  }, [key, timeRange, limit]);
+
+  return { data, loading, error };
+}
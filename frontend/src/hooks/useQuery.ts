@@ -1,5 +1,6 @@
 import { useState, useEffect } from 'react';
-import { queryDatasource, ApiError } from '../api/client';
+import { queryPanelRange, ApiError } from '../api/client';
+import type { PanelRef } from '../api/client';
 import type { QueryResponse, TimeRange } from '../types';
 import { getTimeRangeParams } from '../utils/time';
 
@@ -9,13 +10,19 @@ interface UseQueryResult {
   error: string | null;
 }
 
-export function useQuery(query: string | undefined, timeRange: TimeRange, datasource?: string): UseQueryResult {
+// useQuery runs the panel query ref as a range query over the selected time
+// range.
+export function useQuery(ref: PanelRef | undefined, timeRange: TimeRange): UseQueryResult {
   const [data, setData] = useState<QueryResponse | null>(null);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
 
+  // Compare by content so a new ref for the same query does not refetch.
+  const key = ref ? JSON.stringify(ref) : undefined;
+
   useEffect(() => {
-    if (!query) return;
+    if (!key) return;
+    const panelRef: PanelRef = JSON.parse(key);
 
     let cancelled = false;
     setLoading(true);
@@ -23,7 +30,7 @@ export function useQuery(query: string | undefined, timeRange: TimeRange, dataso
 
     const { start, end, step } = getTimeRangeParams(timeRange);
 
-    queryDatasource(query, start, end, step, datasource)
+    queryPanelRange(panelRef, start, end, step)
       .then((result) => {
         if (!cancelled) {
           setData(result);
@@ -44,7 +51,7 @@ export function useQuery(query: string | undefined, timeRange: TimeRange, dataso
     return () => {
       cancelled = true;
     };
-  }, [query, timeRange, datasource]);
+  }, [key, timeRange]);
 
   return { data, loading, error };
 }
@@ -0,0 +1,57 @@
+import { useState, useEffect } from 'react';
+import { queryPanelReduced, ApiError } from '../api/client';
+import type { PanelRef } from '../api/client';
+import type { InstantQueryResponse, TimeRange } from '../types';
+import { getTimeRangeParams } from '../utils/time';
+
+interface UseReducedQueryResult {
+  data: InstantQueryResponse | null;
+  loading: boolean;
+  error: string | null;
+}
+
+// useReducedQuery fetches one value per series of the panel query ref,
+// reduced server-side over the selected time range.
+export function useReducedQuery(ref: PanelRef | undefined, timeRange: TimeRange, reduce?: string): UseReducedQueryResult {
+  const [data, setData] = useState<InstantQueryResponse | null>(null);
+  const [loading, setLoading] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  // Compare by content so a new ref for the same query does not refetch.
+  const key = ref ? JSON.stringify(ref) : undefined;
+
+  useEffect(() => {
+    if (!key) return;
+    const panelRef: PanelRef = JSON.parse(key);
+
+    let cancelled = false;
+    setLoading(true);
+    setError(null);
+
+    const { start, end, step } = getTimeRangeParams(timeRange);
+
+    queryPanelReduced(panelRef, start, end, step, reduce)
+      .then((result) => {
+        if (!cancelled) {
+          setData(result);
+          setLoading(false);
+        }
+      })
+      .catch((err) => {
+        if (!cancelled) {
+          if (err instanceof ApiError && err.status === 401) {
+            setError('Session expired');
+          } else {
+            setError(err.message || 'Query failed');
+          }
+          setLoading(false);
+        }
+      });
+
+    return () => {
+      cancelled = true;
+    };
+  }, [key, timeRange, reduce]);
+
+  return { data, loading, error };
+}
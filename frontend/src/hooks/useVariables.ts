@@ -1,29 +1,65 @@
 import { useState, useEffect } from 'react';
-import type { Variable } from '../types';
-import { fetchLabelValues, fetchDatasources, ApiError } from '../api/client';
-import { parseLabelValuesQuery } from '../utils/variables';
+import type { TimeRange, Variable } from '../types';
+import { fetchVariableValues, fetchDatasources, ApiError } from '../api/client';
+import {
+  formatSelection,
+  initialSelection,
+  resolveSelection,
+  staticValues,
+  initialStaticSelection,
+  ALL_VALUE,
+  AUTO_INTERVAL,
+  autoInterval,
+  parseDuration,
+  substituteVariables,
+  variableReferences,
+  filtersSelector,
+} from '../utils/variables';
+import { getTimeRangeParams } from '../utils/time';
 
 export interface VariableState {
   name: string;
+  type: NonNullable<Variable['type']>;
   label: string;
   values: string[];
-  selected: string;
+  // Selected values; [ALL_VALUE] when "All" is selected.
+  selected: string[];
+  multi: boolean;
+  includeAll: boolean;
+  allValue?: string;
+  // Interval variables: number of intervals and lower bound (seconds) for "auto".
+  autoCount?: number;
+  autoMinSeconds?: number;
+  // Query variables: the query last fetched, with referenced variables resolved.
+  query?: string;
+  // Query and ad-hoc filter variables: the datasource to query.
+  datasource?: string;
   loading: boolean;
   error: string | null;
 }
 
 interface UseVariablesResult {
   variables: VariableState[];
+  // Text substituted for each variable in queries and titles.
   selectedValues: Record<string, string>;
-  allValues: Record<string, string[]>;
-  setVariableValue: (name: string, value: string) => void;
+  // Values a repeat row iterates over for each variable.
+  repeatValues: Record<string, string[]>;
+  // Values of each variable sent with panel queries, which the server
+  // substitutes: the resolved selection, or [ALL_VALUE] for an "All" that
+  // stands for all_value.
+  queryValues: Record<string, string[]>;
+  // Label matchers of the ad-hoc filters, e.g. {instance="web-3"}, added
+  // server-side to every panel query; '' when there are none.
+  filters: string;
+  setVariableValue: (name: string, values: string[]) => void;
   loading: boolean;
 }
 
 export function useVariables(
   definitions: Variable[] | undefined,
   onAuthError: () => void,
-  initialValues?: Record<string, string>,
+  timeRange: TimeRange,
+  initialValues?: Record<string, string[]>,
 ): UseVariablesResult {
   const [variables, setVariables] = useState<VariableState[]>([]);
 
@@ -33,72 +69,50 @@ export function useVariables(
       return;
     }
 
-    // Initialize variable states
-    const initial: VariableState[] = definitions.map((def) => ({
-      name: def.name,
-      label: def.label || def.name,
-      values: [],
-      selected: '',
-      loading: true,
-      error: null,
-    }));
+    // Initialize variable states; static variables are complete right away.
+    const initial: VariableState[] = definitions.map((def) => {
+      const state: VariableState = {
+        name: def.name,
+        type: def.type || 'query',
+        label: def.label || def.name,
+        values: [],
+        selected: [],
+        multi: !!def.multi,
+        includeAll: !!def.include_all,
+        allValue: def.all_value,
+        datasource: def.datasource,
+        autoCount: def.auto_count,
+        autoMinSeconds: def.auto_min ? parseDuration(def.auto_min) ?? undefined : undefined,
+        loading: true,
+        error: null,
+      };
+      const values = staticValues(def);
+      if (values === null) return state;
+      return {
+        ...state,
+        values,
+        selected: initialStaticSelection(def, values, initialValues?.[def.name]),
+        loading: false,
+      };
+    });
     setVariables(initial);
 
-    // Fetch all variable values in parallel
+    // Datasource variables list the configured datasources; query variables
+    // are fetched by the effect below once their references are resolved.
     definitions.forEach((def, idx) => {
-      const varType = def.type || 'query';
-
-      if (varType === 'datasource') {
-        fetchDatasources()
-          .then((resp) => {
-            const values = resp.datasources || [];
-            const urlVal = initialValues?.[def.name];
-            const defaultVal = urlVal && values.includes(urlVal) ? urlVal : (resp.default || values[0] || '');
-            setVariables((prev) => {
-              const next = [...prev];
-              next[idx] = {
-                ...next[idx],
-                values,
-                selected: defaultVal,
-                loading: false,
-              };
-              return next;
-            });
-          })
-          .catch((err) => {
-            if (err instanceof ApiError && err.status === 401) {
-              onAuthError();
-            }
-            setVariables((prev) => {
-              const next = [...prev];
-              next[idx] = { ...next[idx], loading: false, error: err.message };
-              return next;
-            });
-          });
-        return;
-      }
+      if (def.type !== 'datasource') return;
 
-      const parsed = parseLabelValuesQuery(def.query || '');
-      if (!parsed) {
-        setVariables((prev) => {
-          const next = [...prev];
-          next[idx] = { ...next[idx], loading: false, error: 'Invalid query format' };
-          return next;
-        });
-        return;
-      }
-
-      fetchLabelValues(parsed.label, parsed.metric, def.datasource)
+      fetchDatasources()
         .then((resp) => {
-          const values = resp.data || [];
-          const urlVal = initialValues?.[def.name];
-          const defaultVal = urlVal && values.includes(urlVal) ? urlVal : (values[0] || '');
+          const values = resp.datasources || [];
+          const urlVal = initialValues?.[def.name]?.[0];
+          const defaultVal = urlVal && values.includes(urlVal) ? urlVal : (resp.default || values[0] || '');
           setVariables((prev) => {
             const next = [...prev];
             next[idx] = {
               ...next[idx],
               values,
-              selected: defaultVal,
+              selected: defaultVal ? [defaultVal] : [],
               loading: false,
             };
             return next;
@@ -117,22 +131,127 @@ export function useVariables(
     });
   }, [definitions, onAuthError]);
 
-  const setVariableValue = (name: string, value: string) => {
+  // Query variables are fetched once the variables they reference are
+  // resolved, and fetched again whenever their resolved query changes, e.g.
+  // when an upstream selection changes.
+  useEffect(() => {
+    if (!definitions) return;
+    const names = definitions.map((d) => d.name);
+    const { selectedValues } = formatVariables(variables, timeRange);
+
+    definitions.forEach((def, idx) => {
+      const state = variables[idx];
+      if (!state || state.name !== def.name || state.type !== 'query') return;
+
+      const refs = variableReferences(def.query || '', names);
+      if (refs.some((ref) => variables.find((v) => v.name === ref)?.loading)) return;
+      const query = substituteVariables(
+        def.query || '',
+        Object.fromEntries(refs.map((ref) => [ref, selectedValues[ref] ?? ''])),
+      );
+      if (query === state.query) return;
+
+      const update = (fn: (v: VariableState) => VariableState) =>
+        setVariables((prev) => {
+          // Drop results of a query that has been superseded.
+          if (prev[idx]?.name !== def.name || prev[idx].query !== query) return prev;
+          const next = [...prev];
+          next[idx] = fn(next[idx]);
+          return next;
+        });
+
+      setVariables((prev) => {
+        const next = [...prev];
+        next[idx] = { ...next[idx], query, loading: true, error: null };
+        return next;
+      });
+
+      // The server parses the query and applies the variable's regex and sort.
+      // The time range bounds the series that label_names and metrics see.
+      const { start, end } = getTimeRangeParams(timeRange);
+      fetchVariableValues(def, query, start, end)
+        .then((resp) => {
+          const values = resp.data || [];
+          update((v) => ({
+            ...v,
+            values,
+            // Keep what is still selected, else what the URL asked for.
+            selected: initialSelection(def, values, v.selected.length > 0 ? v.selected : initialValues?.[def.name]),
+            loading: false,
+          }));
+        })
+        .catch((err) => {
+          if (err instanceof ApiError && err.status === 401) {
+            onAuthError();
+          }
+          update((v) => ({ ...v, loading: false, error: err.message }));
+        });
+    });
+  }, [definitions, variables, timeRange, initialValues, onAuthError]);
+
+  const setVariableValue = (name: string, values: string[]) => {
     setVariables((prev) =>
-      prev.map((v) => (v.name === name ? { ...v, selected: value } : v)),
+      prev.map((v) => (v.name === name ? { ...v, selected: values } : v)),
     );
   };
 
+  const { selectedValues, repeatValues, queryValues } = formatVariables(variables, timeRange);
+  const filters = filtersSelector(variables.find((v) => v.type === 'adhoc_filters')?.selected ?? []);
+
+  const loading = variables.some((v) => v.loading);
+
+  return { variables, selectedValues, repeatValues, queryValues, filters, setVariableValue, loading };
+}
+
+// formatVariables computes the text substituted for each variable, the values
+// repeat rows iterate over and the values sent with panel queries.
+function formatVariables(
+  variables: VariableState[],
+  timeRange: TimeRange,
+): {
+  selectedValues: Record<string, string>;
+  repeatValues: Record<string, string[]>;
+  queryValues: Record<string, string[]>;
+} {
+  // "auto" intervals follow the time range, never finer than its query step.
+  const { start, end, step } = getTimeRangeParams(timeRange);
+  const resolveAuto = (v: VariableState, value: string) =>
+    value === AUTO_INTERVAL
+      ? autoInterval(end - start, parseDuration(step) ?? 0, v.autoCount, v.autoMinSeconds)
+      : value;
+
   const selectedValues: Record<string, string> = {};
-  const allValues: Record<string, string[]> = {};
+  const repeatValues: Record<string, string[]> = {};
+  const queryValues: Record<string, string[]> = {};
   for (const v of variables) {
-    if (v.selected) {
-      selectedValues[v.name] = v.selected;
+    // Ad-hoc filters are added to queries server-side, not substituted.
+    if (v.type === 'adhoc_filters') continue;
+    if (v.type === 'interval') {
+      if (v.selected.length > 0) {
+        selectedValues[v.name] = resolveAuto(v, v.selected[0]);
+        queryValues[v.name] = [selectedValues[v.name]];
+      }
+      repeatValues[v.name] = v.values.map((value) => resolveAuto(v, value));
+      continue;
+    }
+    const options = { multi: v.multi, include_all: v.includeAll, all_value: v.allValue };
+    if (v.selected.length > 0) {
+      selectedValues[v.name] = formatSelection(options, v.selected, v.values);
+      queryValues[v.name] = v.allValue && v.selected.includes(ALL_VALUE)
+        ? [ALL_VALUE]
+        : resolveSelection(v.selected, v.values);
+    }
+    // Single-value variables repeat over every value; multi-value and
+    // include_all ones over what is selected, constants and textboxes over
+    // their own value.
+    if (v.multi || v.includeAll) {
+      repeatValues[v.name] = resolveSelection(v.selected, v.values);
+    } else if (v.type === 'constant' || v.type === 'textbox') {
+      repeatValues[v.name] = v.selected;
+    } else {
+      repeatValues[v.name] = v.values;
     }
-    allValues[v.name] = v.values;
   }
 
-  const loading = variables.some((v) => v.loading);
-
-  return { variables, selectedValues, allValues, setVariableValue, loading };
+  return { selectedValues, repeatValues, queryValues };
 }
@@ -4,9 +4,15 @@ export interface Threshold {
   label?: string;
 }
 
+export interface TableQuery {
+  query: string;
+  name?: string;
+  unit?: 'bytes' | 'percent' | 'count' | 'seconds';
+}
+
 export interface Panel {
   title: string;
-  type: 'graph' | 'markdown';
+  type: 'graph' | 'markdown' | 'logs' | 'stat' | 'table' | 'gauge' | 'bargauge' | 'heatmap';
   chart_type?: 'line' | 'bar' | 'area' | 'scatter';
   query?: string;
   datasource?: string;
@@ -24,6 +30,14 @@ export interface Panel {
   y_scale?: 'linear' | 'log';
   content?: string;
   span?: number;
+  limit?: number;
+  reduce?: 'last' | 'avg' | 'max' | 'min';
+  sparkline?: boolean;
+  queries?: TableQuery[];
+  columns?: string[];
+  hidden_labels?: string[];
+  sort_by?: string;
+  sort_desc?: boolean;
 }
 
 export interface Row {
@@ -34,11 +48,20 @@ export interface Row {
 
 export interface Variable {
   name: string;
-  type?: 'query' | 'datasource';
+  type?: 'query' | 'datasource' | 'custom' | 'constant' | 'interval' | 'textbox' | 'adhoc_filters';
   label?: string;
   query?: string;
   datasource?: string;
   hide?: boolean;
+  multi?: boolean;
+  include_all?: boolean;
+  all_value?: string;
+  auto?: boolean;
+  auto_count?: number;
+  auto_min?: string;
+  regex?: string;
+  sort?: 'alpha' | 'numeric' | 'natural';
+  sort_desc?: boolean;
 }
 
 export interface DatasourcesResponse {
@@ -46,9 +69,19 @@ export interface DatasourcesResponse {
   default: string;
 }
 
+export interface Annotation {
+  name: string;
+  query?: string;
+  datasource?: string;
+  text?: string;
+  color?: string;
+  events?: { time: string; time_end?: string; text?: string; tags?: string[] }[];
+}
+
 export interface Dashboard {
   title: string;
   variables?: Variable[];
+  annotations?: Annotation[];
   rows: Row[];
   path: string;
 }
@@ -89,6 +122,45 @@ export interface QueryResponse {
   };
 }
 
+export interface InstantQueryResult {
+  metric: Record<string, string>;
+  value: [number, string];
+}
+
+export interface InstantQueryResponse {
+  status: string;
+  data: {
+    resultType: string;
+    result: InstantQueryResult[];
+  };
+}
+
+export interface AnnotationEvent {
+  source: string; // name of the annotation that produced the event
+  color?: string;
+  time: number; // Unix seconds
+  time_end?: number;
+  text?: string;
+  tags?: string[];
+}
+
+export interface AnnotationsResponse {
+  annotations: AnnotationEvent[];
+}
+
+export interface LogStream {
+  stream: Record<string, string>;
+  values: [string, string][]; // [unix nanoseconds, line]
+}
+
+export interface LogsResponse {
+  status: string;
+  data: {
+    resultType: string;
+    result: LogStream[];
+  };
+}
+
 export interface RelativeTimeRange {
   type: 'relative';
   label: string;
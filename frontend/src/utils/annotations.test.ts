@@ -0,0 +1,32 @@
+import { describe, it, expect } from 'vitest';
+import { annotationLabel, buildEventAnnotations, ANNOTATION_DEFAULT_COLOR } from './annotations';
+
+describe('annotationLabel', () => {
+  it('uses the text and tags', () => {
+    expect(annotationLabel({ source: 'Deploys', time: 1, text: 'deploy 1.2.0', tags: ['env=prod', 'version=1.2.0'] }))
+      .toEqual(['deploy 1.2.0', 'env=prod, version=1.2.0']);
+  });
+
+  it('falls back to the source name', () => {
+    expect(annotationLabel({ source: 'Deploys', time: 1 })).toEqual(['Deploys']);
+  });
+});
+
+describe('buildEventAnnotations', () => {
+  it('draws a vertical line per event', () => {
+    const [line] = buildEventAnnotations([{ source: 'Deploys', time: 1000 }]);
+    expect(line).toMatchObject({
+      type: 'line',
+      scaleID: 'x',
+      value: 1_000_000,
+      borderColor: ANNOTATION_DEFAULT_COLOR,
+    });
+  });
+
+  it('shades events spanning a range', () => {
+    const items = buildEventAnnotations([{ source: 'Incidents', color: '#ef4444', time: 1000, time_end: 1100 }]);
+    expect(items).toHaveLength(2);
+    expect(items[0]).toMatchObject({ type: 'box', xMin: 1_000_000, xMax: 1_100_000, backgroundColor: '#ef44441a' });
+    expect(items[1]).toMatchObject({ type: 'line', value: 1_000_000, borderColor: '#ef4444' });
+  });
+});
@@ -0,0 +1,62 @@
+import type { AnnotationEvent } from '../types';
+
+export const ANNOTATION_DEFAULT_COLOR = '#8b5cf6';
+
+/** Lines shown in an annotation marker's label: text (or source) and tags. */
+export function annotationLabel(event: AnnotationEvent): string[] {
+  const lines = [event.text || event.source];
+  if (event.tags && event.tags.length > 0) {
+    lines.push(event.tags.join(', '));
+  }
+  return lines;
+}
+
+/**
+ * Convert annotation events into chartjs-plugin-annotation entries: a
+ * vertical dashed line per event, plus a shaded box for events spanning a
+ * range. Labels are only shown while the marker is hovered.
+ */
+export function buildEventAnnotations(events: AnnotationEvent[]) {
+  const result = [];
+  for (const event of events) {
+    const color = event.color || ANNOTATION_DEFAULT_COLOR;
+    const x = event.time * 1000;
+    if (event.time_end !== undefined && event.time_end > event.time) {
+      result.push({
+        type: 'box' as const,
+        xScaleID: 'x',
+        xMin: x,
+        xMax: event.time_end * 1000,
+        backgroundColor: `${color}1a`,
+        borderWidth: 0,
+        drawTime: 'beforeDatasetsDraw' as const,
+      });
+    }
+    result.push({
+      type: 'line' as const,
+      scaleID: 'x',
+      value: x,
+      borderColor: color,
+      borderWidth: 1,
+      borderDash: [4, 4],
+      drawTime: 'afterDatasetsDraw' as const,
+      label: {
+        display: false,
+        content: annotationLabel(event),
+        position: 'start' as const,
+        backgroundColor: color,
+        color: '#fff',
+        font: { size: 11 },
+      },
+      enter({ element }: { element: { label?: { options: { display: boolean } } } }) {
+        if (element.label) element.label.options.display = true;
+        return true;
+      },
+      leave({ element }: { element: { label?: { options: { display: boolean } } } }) {
+        if (element.label) element.label.options.display = false;
+        return true;
+      },
+    });
+  }
+  return result;
+}
@@ -0,0 +1,43 @@
+import { describe, it, expect } from 'vitest';
+import { gaugeFraction, thresholdBands, GAUGE_BASE_COLOR } from './gauge';
+
+describe('gaugeFraction', () => {
+  it('maps value into the range', () => {
+    expect(gaugeFraction(25, 0, 100)).toBe(0.25);
+    expect(gaugeFraction(15, 10, 20)).toBe(0.5);
+  });
+
+  it('clamps out-of-range values', () => {
+    expect(gaugeFraction(-5, 0, 100)).toBe(0);
+    expect(gaugeFraction(150, 0, 100)).toBe(1);
+  });
+
+  it('handles empty ranges and NaN', () => {
+    expect(gaugeFraction(5, 10, 10)).toBe(0);
+    expect(gaugeFraction(NaN, 0, 100)).toBe(0);
+  });
+});
+
+describe('thresholdBands', () => {
+  it('uses the base color without thresholds', () => {
+    expect(thresholdBands(0, 100)).toEqual([{ from: 0, to: 100, color: GAUGE_BASE_COLOR }]);
+  });
+
+  it('splits the range at sorted thresholds', () => {
+    expect(thresholdBands(0, 100, [
+      { value: 90, color: 'red' },
+      { value: 70, color: 'yellow' },
+    ])).toEqual([
+      { from: 0, to: 70, color: GAUGE_BASE_COLOR },
+      { from: 70, to: 90, color: 'yellow' },
+      { from: 90, to: 100, color: 'red' },
+    ]);
+  });
+
+  it('clips thresholds outside the range', () => {
+    expect(thresholdBands(10, 20, [
+      { value: 5, color: 'yellow' },
+      { value: 50, color: 'red' },
+    ])).toEqual([{ from: 10, to: 20, color: 'yellow' }]);
+  });
+});
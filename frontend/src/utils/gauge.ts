@@ -0,0 +1,39 @@
+import type { Threshold } from '../types';
+
+export const GAUGE_BASE_COLOR = '#10b981';
+
+export interface GaugeBand {
+  from: number;
+  to: number;
+  color: string;
+}
+
+/** Position of value within [min, max] as a fraction clamped to 0..1. */
+export function gaugeFraction(value: number, min: number, max: number): number {
+  if (max <= min || Number.isNaN(value)) return 0;
+  return Math.min(1, Math.max(0, (value - min) / (max - min)));
+}
+
+/**
+ * Split [min, max] into colored bands: the base color up to the first
+ * threshold, then each threshold's color up to the next one. Thresholds
+ * outside the range are clipped away.
+ */
+export function thresholdBands(min: number, max: number, thresholds?: Threshold[]): GaugeBand[] {
+  const sorted = [...(thresholds || [])].sort((a, b) => a.value - b.value);
+  const bands: GaugeBand[] = [];
+  let from = min;
+  let color = GAUGE_BASE_COLOR;
+  for (const th of sorted) {
+    const to = Math.min(max, th.value);
+    if (to > from) {
+      bands.push({ from, to, color });
+      from = to;
+    }
+    color = th.color || '#ef4444';
+  }
+  if (max > from) {
+    bands.push({ from, to: max, color });
+  }
+  return bands;
+}
@@ -0,0 +1,45 @@
+import { describe, it, expect } from 'vitest';
+import { buildHeatmap, bucketLabel, heatmapColor } from './heatmap';
+
+describe('buildHeatmap', () => {
+  it('builds a bucket x time grid', () => {
+    const data = buildHeatmap([
+      { metric: { le: '0.1' }, values: [[1000, '3'], [1015, '5']] },
+      { metric: { le: '+Inf' }, values: [[1015, '1']] },
+    ]);
+    expect(data.buckets).toEqual(['0.1', '+Inf']);
+    expect(data.times).toEqual([1000, 1015]);
+    expect(data.cells[0]).toEqual([3, 5]);
+    expect(data.cells[1][0]).toBeNaN();
+    expect(data.cells[1][1]).toBe(1);
+    expect(data.max).toBe(5);
+  });
+
+  it('handles empty results', () => {
+    expect(buildHeatmap([])).toEqual({ buckets: [], times: [], cells: [], max: 0 });
+  });
+});
+
+describe('bucketLabel', () => {
+  it('formats the upper bound with the unit', () => {
+    expect(bucketLabel('0.25', '0.1', 'seconds')).toBe('≤ 250.0ms');
+  });
+
+  it('labels the +Inf bucket by the previous bound', () => {
+    expect(bucketLabel('+Inf', '10', 'seconds')).toBe('> 10.00s');
+    expect(bucketLabel('+Inf', undefined)).toBe('+Inf');
+  });
+});
+
+describe('heatmapColor', () => {
+  it('leaves empty cells uncolored', () => {
+    expect(heatmapColor(0, 10)).toBeUndefined();
+    expect(heatmapColor(NaN, 10)).toBeUndefined();
+    expect(heatmapColor(5, 0)).toBeUndefined();
+  });
+
+  it('scales from light to dark', () => {
+    expect(heatmapColor(10, 10)).toBe('rgb(185, 28, 28)');
+    expect(heatmapColor(1e-9, 10)).not.toBe(heatmapColor(10, 10));
+  });
+});
@@ -0,0 +1,67 @@
+import type { QueryResult } from '../types';
+import { formatValue } from './units';
+
+export interface HeatmapData {
+  // Bucket upper bounds ("le" labels), ascending.
+  buckets: string[];
+  // Unix seconds, ascending.
+  times: number[];
+  // cells[bucket][time]; NaN where the bucket has no sample.
+  cells: number[][];
+  max: number;
+}
+
+/**
+ * Arrange the per-bucket series returned by /api/heatmap into a
+ * bucket x time grid. The server already sorts series by upper bound.
+ */
+export function buildHeatmap(results: QueryResult[]): HeatmapData {
+  const timeSet = new Set<number>();
+  for (const r of results) {
+    for (const [t] of r.values) timeSet.add(t);
+  }
+  const times = [...timeSet].sort((a, b) => a - b);
+  const index = new Map(times.map((t, i) => [t, i]));
+
+  let max = 0;
+  const cells = results.map((r) => {
+    const row = new Array<number>(times.length).fill(NaN);
+    for (const [t, v] of r.values) {
+      const value = parseFloat(v);
+      row[index.get(t)!] = value;
+      if (value > max) max = value;
+    }
+    return row;
+  });
+
+  return {
+    buckets: results.map((r) => r.metric.le ?? ''),
+    times,
+    cells,
+    max,
+  };
+}
+
+/** Human-readable label of a bucket given its and the previous upper bound. */
+export function bucketLabel(le: string, prevLe: string | undefined, unit?: string): string {
+  const upper = parseFloat(le);
+  if (!Number.isFinite(upper)) {
+    return prevLe === undefined ? '+Inf' : `> ${formatValue(parseFloat(prevLe), unit)}`;
+  }
+  return `≤ ${formatValue(upper, unit)}`;
+}
+
+const LOW: [number, number, number] = [254, 243, 199];
+const HIGH: [number, number, number] = [185, 28, 28];
+
+/**
+ * Cell color for value on a square-root scale between a pale yellow and a
+ * dark red, so sparse buckets stay visible next to dominant ones. Empty
+ * cells (zero or missing) get no color.
+ */
+export function heatmapColor(value: number, max: number): string | undefined {
+  if (!(value > 0) || !(max > 0)) return undefined;
+  const f = Math.sqrt(Math.min(1, value / max));
+  const [r, g, b] = LOW.map((lo, i) => Math.round(lo + (HIGH[i] - lo) * f));
+  return `rgb(${r}, ${g}, ${b})`;
+}
@@ -0,0 +1,25 @@
+import { describe, it, expect } from 'vitest';
+import { mergeLogStreams } from './logs';
+
+describe('mergeLogStreams', () => {
+  it('merges streams newest first', () => {
+    const entries = mergeLogStreams([
+      { stream: { app: 'web' }, values: [['3000000000', 'web c'], ['1000000000', 'web a']] },
+      { stream: { app: 'api' }, values: [['2000000000', 'api b']] },
+    ]);
+    expect(entries.map((e) => e.line)).toEqual(['web c', 'api b', 'web a']);
+    expect(entries[1].labels).toEqual({ app: 'api' });
+  });
+
+  it('compares nanosecond timestamps precisely', () => {
+    const entries = mergeLogStreams([
+      { stream: {}, values: [['1700000000000000001', 'later']] },
+      { stream: {}, values: [['1700000000000000000', 'earlier']] },
+    ]);
+    expect(entries.map((e) => e.line)).toEqual(['later', 'earlier']);
+  });
+
+  it('returns empty list for no streams', () => {
+    expect(mergeLogStreams([])).toEqual([]);
+  });
+});
@@ -0,0 +1,26 @@
+import type { LogStream } from '../types';
+
+export interface LogEntry {
+  timestamp: string; // Unix nanoseconds
+  line: string;
+  labels: Record<string, string>;
+}
+
+/**
+ * Flatten Loki streams into a single list of log lines sorted newest first.
+ * Timestamps are nanosecond strings, so they are compared as BigInt.
+ */
+export function mergeLogStreams(streams: LogStream[]): LogEntry[] {
+  const entries: LogEntry[] = [];
+  for (const s of streams) {
+    for (const [timestamp, line] of s.values) {
+      entries.push({ timestamp, line, labels: s.stream });
+    }
+  }
+  entries.sort((a, b) => {
+    const ta = BigInt(a.timestamp);
+    const tb = BigInt(b.timestamp);
+    return ta < tb ? 1 : ta > tb ? -1 : 0;
+  });
+  return entries;
+}
@@ -0,0 +1,26 @@
+import { describe, it, expect } from 'vitest';
+import { thresholdColor } from './stat';
+
+describe('thresholdColor', () => {
+  const thresholds = [
+    { value: 80, color: '#ef4444' },
+    { value: 50, color: '#f59e0b' },
+  ];
+
+  it('returns undefined below all thresholds', () => {
+    expect(thresholdColor(10, thresholds)).toBeUndefined();
+  });
+
+  it('picks the highest threshold reached regardless of order', () => {
+    expect(thresholdColor(60, thresholds)).toBe('#f59e0b');
+    expect(thresholdColor(80, thresholds)).toBe('#ef4444');
+  });
+
+  it('falls back to the default color', () => {
+    expect(thresholdColor(5, [{ value: 1 }])).toBe('#ef4444');
+  });
+
+  it('handles missing thresholds', () => {
+    expect(thresholdColor(5)).toBeUndefined();
+  });
+});
@@ -0,0 +1,16 @@
+import type { Threshold } from '../types';
+
+/**
+ * Return the color of the highest threshold the value reaches, or undefined
+ * when it is below all thresholds.
+ */
+export function thresholdColor(value: number, thresholds?: Threshold[]): string | undefined {
+  if (!thresholds || thresholds.length === 0) return undefined;
+  let matched: Threshold | undefined;
+  for (const th of [...thresholds].sort((a, b) => a.value - b.value)) {
+    if (value >= th.value) {
+      matched = th;
+    }
+  }
+  return matched ? matched.color || '#ef4444' : undefined;
+}
@@ -0,0 +1,72 @@
+import { describe, it, expect } from 'vitest';
+import { buildTable, sortRows } from './table';
+
+const cpu = [
+  { metric: { pod: 'web-1', node: 'a' }, value: [0, '0.5'] as [number, string] },
+  { metric: { pod: 'web-2', node: 'b' }, value: [0, '0.9'] as [number, string] },
+];
+const mem = [
+  { metric: { pod: 'web-1' }, value: [0, '100'] as [number, string] },
+  { metric: { pod: 'web-3' }, value: [0, '300'] as [number, string] },
+];
+
+describe('buildTable', () => {
+  it('makes one row per series for a single query', () => {
+    const table = buildTable([cpu], [{ name: 'Value' }]);
+    expect(table.columns.map((c) => c.name)).toEqual(['node', 'pod', 'Value']);
+    expect(table.rows).toEqual([
+      { pod: 'web-1', node: 'a', Value: 0.5 },
+      { pod: 'web-2', node: 'b', Value: 0.9 },
+    ]);
+  });
+
+  it('joins several queries on shared labels', () => {
+    const table = buildTable([cpu, mem], [{ name: 'CPU', unit: 'percent' }, { name: 'Memory', unit: 'bytes' }]);
+    expect(table.rows).toEqual([
+      { pod: 'web-1', node: 'a', CPU: 0.5, Memory: 100 },
+      { pod: 'web-2', node: 'b', CPU: 0.9 },
+      { pod: 'web-3', Memory: 300 },
+    ]);
+    expect(table.columns.find((c) => c.name === 'Memory')).toEqual({ name: 'Memory', isValue: true, unit: 'bytes' });
+  });
+
+  it('ignores __name__ when joining', () => {
+    const a = [{ metric: { __name__: 'a', pod: 'p' }, value: [0, '1'] as [number, string] }];
+    const b = [{ metric: { __name__: 'b', pod: 'p' }, value: [0, '2'] as [number, string] }];
+    const table = buildTable([a, b], [{ name: 'A' }, { name: 'B' }], { hiddenLabels: ['__name__'] });
+    expect(table.rows).toEqual([{ pod: 'p', A: 1, B: 2 }]);
+  });
+
+  it('does not join on hidden labels', () => {
+    const rx = [{ metric: { device: 'eth0', direction: 'receive' }, value: [0, '1'] as [number, string] }];
+    const tx = [{ metric: { device: 'eth0', direction: 'transmit' }, value: [0, '2'] as [number, string] }];
+    const table = buildTable([rx, tx], [{ name: 'RX' }, { name: 'TX' }], { hiddenLabels: ['direction'] });
+    expect(table.rows).toEqual([{ device: 'eth0', RX: 1, TX: 2 }]);
+  });
+
+  it('applies column order and hidden labels', () => {
+    const table = buildTable([cpu, mem], [{ name: 'CPU' }, { name: 'Memory' }], {
+      columns: ['Memory', 'pod'],
+      hiddenLabels: ['node'],
+    });
+    expect(table.columns.map((c) => c.name)).toEqual(['Memory', 'pod', 'CPU']);
+    expect(table.rows[0]).not.toHaveProperty('node');
+  });
+});
+
+describe('sortRows', () => {
+  const rows = [
+    { pod: 'pod-10', v: 1 },
+    { pod: 'pod-2', v: 3 },
+    { pod: 'pod-1' },
+  ];
+
+  it('sorts labels naturally', () => {
+    expect(sortRows(rows, 'pod').map((r) => r.pod)).toEqual(['pod-1', 'pod-2', 'pod-10']);
+  });
+
+  it('sorts numbers descending with missing values last', () => {
+    expect(sortRows(rows, 'v', true).map((r) => r.pod)).toEqual(['pod-2', 'pod-10', 'pod-1']);
+    expect(sortRows(rows, 'v').map((r) => r.pod)).toEqual(['pod-10', 'pod-2', 'pod-1']);
+  });
+});
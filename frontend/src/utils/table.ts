@@ -0,0 +1,117 @@
+import type { InstantQueryResult } from '../types';
+
+export interface TableColumn {
+  name: string;
+  // Value columns hold query results; the others hold label values.
+  isValue: boolean;
+  unit?: string;
+}
+
+export type TableCell = string | number | undefined;
+
+export interface TableData {
+  columns: TableColumn[];
+  rows: Record<string, TableCell>[];
+}
+
+export interface ValueColumnDef {
+  name: string;
+  unit?: string;
+}
+
+export interface TableOptions {
+  columns?: string[];
+  hiddenLabels?: string[];
+}
+
+/**
+ * Join the instant query results of a table panel into rows. results[i]
+ * belongs to valueColumns[i]. Series are joined on the labels that every
+ * query's results share (ignoring __name__ and hidden labels), so e.g. a CPU
+ * query and a memory query that both return a `pod` label end up in one row
+ * per pod.
+ */
+export function buildTable(
+  results: InstantQueryResult[][],
+  valueColumns: ValueColumnDef[],
+  options: TableOptions = {},
+): TableData {
+  const hidden = new Set(options.hiddenLabels || []);
+
+  // Join key: label names present in the results of every query.
+  let joinLabels: string[] | null = null;
+  for (const series of results) {
+    const names = new Set<string>();
+    for (const s of series) {
+      Object.keys(s.metric).forEach((k) => names.add(k));
+    }
+    joinLabels = joinLabels === null ? [...names] : joinLabels.filter((n) => names.has(n));
+  }
+  const keyLabels = (joinLabels || []).filter((n) => n !== '__name__' && !hidden.has(n)).sort();
+
+  const rowsByKey = new Map<string, Record<string, TableCell>>();
+  const labelNames = new Set<string>();
+  results.forEach((series, qi) => {
+    for (const s of series) {
+      // With a single query every series is its own row.
+      const key = results.length === 1
+        ? JSON.stringify(Object.entries(s.metric).sort(([a], [b]) => a.localeCompare(b)))
+        : JSON.stringify(keyLabels.map((n) => s.metric[n] ?? ''));
+      let row = rowsByKey.get(key);
+      if (!row) {
+        row = {};
+        rowsByKey.set(key, row);
+      }
+      for (const [name, value] of Object.entries(s.metric)) {
+        if (hidden.has(name)) continue;
+        labelNames.add(name);
+        if (row[name] === undefined) {
+          row[name] = value;
+        }
+      }
+      row[valueColumns[qi].name] = parseFloat(s.value[1]);
+    }
+  });
+
+  const defaultOrder: TableColumn[] = [
+    ...[...labelNames].sort().map((name) => ({ name, isValue: false })),
+    ...valueColumns.map((c) => ({ name: c.name, isValue: true, unit: c.unit })),
+  ];
+  const byName = new Map(defaultOrder.map((c) => [c.name, c]));
+  const ordered: TableColumn[] = [];
+  for (const name of options.columns || []) {
+    const col = byName.get(name);
+    if (col) {
+      ordered.push(col);
+      byName.delete(name);
+    }
+  }
+  for (const col of defaultOrder) {
+    if (byName.has(col.name)) {
+      ordered.push(col);
+    }
+  }
+
+  return { columns: ordered, rows: [...rowsByKey.values()] };
+}
+
+/**
+ * Sort rows by a column. Numbers compare numerically, strings naturally
+ * (so pod-2 < pod-10), and missing cells always sort last.
+ */
+export function sortRows(rows: Record<string, TableCell>[], column: string, desc = false): Record<string, TableCell>[] {
+  const collator = new Intl.Collator(undefined, { numeric: true });
+  return [...rows].sort((a, b) => {
+    const va = a[column];
+    const vb = b[column];
+    const aMissing = va === undefined || (typeof va === 'number' && Number.isNaN(va));
+    const bMissing = vb === undefined || (typeof vb === 'number' && Number.isNaN(vb));
+    if (aMissing || bMissing) {
+      return aMissing === bMissing ? 0 : aMissing ? 1 : -1;
+    }
+    const cmp = typeof va === 'number' && typeof vb === 'number'
+      ? va - vb
+      : collator.compare(String(va), String(vb));
+    return desc ? -cmp : cmp;
+  });
+}
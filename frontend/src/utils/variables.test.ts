@@ -1,29 +1,23 @@
 import { describe, it, expect } from 'vitest';
-import { parseLabelValuesQuery, substituteVariables } from './variables';
-
-describe('parseLabelValuesQuery', () => {
-  it('parses a standard query', () => {
-    const result = parseLabelValuesQuery('label_values(up, instance)');
-    expect(result).toEqual({ metric: 'up', label: 'instance' });
-  });
-
-  it('handles extra whitespace', () => {
-    const result = parseLabelValuesQuery('label_values(  up  ,  instance  )');
-    expect(result).toEqual({ metric: 'up', label: 'instance' });
-  });
-
-  it('handles metric with braces', () => {
-    const result = parseLabelValuesQuery('label_values(node_cpu_seconds_total{mode="idle"}, cpu)');
-    expect(result).toEqual({ metric: 'node_cpu_seconds_total{mode="idle"}', label: 'cpu' });
-  });
-
-  it('returns null for invalid format', () => {
-    expect(parseLabelValuesQuery('not a query')).toBeNull();
-    expect(parseLabelValuesQuery('label_values()')).toBeNull();
-    expect(parseLabelValuesQuery('label_values(metric)')).toBeNull();
-    expect(parseLabelValuesQuery('')).toBeNull();
-  });
-});
+import {
+  substituteVariables,
+  ALL_VALUE,
+  escapePromRegex,
+  formatSelection,
+  initialSelection,
+  toggleSelection,
+  AUTO_INTERVAL,
+  parseOptions,
+  staticValues,
+  initialStaticSelection,
+  parseDuration,
+  formatDuration,
+  autoInterval,
+  variableReferences,
+  formatFilter,
+  parseFilter,
+  filtersSelector,
+} from './variables';
 
 describe('substituteVariables', () => {
   it('replaces ${var} form', () => {
@@ -65,3 +59,195 @@ describe('substituteVariables', () => {
     expect(substituteVariables('', { job: 'api' })).toBe('');
   });
 });
+
+describe('escapePromRegex', () => {
+  it('escapes regex metacharacters for a PromQL string', () => {
+    expect(escapePromRegex('10.0.0.1:9090')).toBe('10\\\\.0\\\\.0\\\\.1:9090');
+    expect(escapePromRegex('/api/(v1)')).toBe('/api/\\\\(v1\\\\)');
+    expect(escapePromRegex('eth0')).toBe('eth0');
+  });
+});
+
+describe('formatSelection', () => {
+  const values = ['a', 'b', 'c.d'];
+
+  it('substitutes single-value variables as-is', () => {
+    expect(formatSelection({}, ['c.d'], values)).toBe('c.d');
+  });
+
+  it('joins multi-value selections into an escaped regex', () => {
+    expect(formatSelection({ multi: true }, ['a', 'c.d'], values)).toBe('a|c\\\\.d');
+  });
+
+  it('expands All to every value', () => {
+    expect(formatSelection({ include_all: true }, [ALL_VALUE], values)).toBe('a|b|c\\\\.d');
+  });
+
+  it('uses all_value for All when set', () => {
+    expect(formatSelection({ multi: true, include_all: true, all_value: '.*' }, [ALL_VALUE], values)).toBe('.*');
+    expect(formatSelection({ multi: true, include_all: true, all_value: '.*' }, ['a'], values)).toBe('a');
+  });
+});
+
+describe('initialSelection', () => {
+  const values = ['a', 'b', 'c'];
+
+  it('defaults to the first value', () => {
+    expect(initialSelection({}, values)).toEqual(['a']);
+    expect(initialSelection({ multi: true }, values)).toEqual(['a']);
+    expect(initialSelection({}, [])).toEqual([]);
+  });
+
+  it('defaults to All for include_all variables', () => {
+    expect(initialSelection({ include_all: true }, values)).toEqual([ALL_VALUE]);
+  });
+
+  it('keeps existing URL values', () => {
+    expect(initialSelection({ multi: true }, values, ['b', 'gone', 'c'])).toEqual(['b', 'c']);
+    expect(initialSelection({}, values, ['b', 'c'])).toEqual(['b']);
+    expect(initialSelection({ include_all: true }, values, [ALL_VALUE])).toEqual([ALL_VALUE]);
+    expect(initialSelection({}, values, [ALL_VALUE])).toEqual(['a']);
+  });
+});
+
+describe('toggleSelection', () => {
+  it('adds and removes values', () => {
+    expect(toggleSelection(['a'], 'b')).toEqual(['a', 'b']);
+    expect(toggleSelection(['a', 'b'], 'a')).toEqual(['b']);
+  });
+
+  it('keeps at least one value', () => {
+    expect(toggleSelection(['a'], 'a')).toEqual(['a']);
+    expect(toggleSelection(['a'], 'a', true)).toEqual([ALL_VALUE]);
+  });
+
+  it('switches between All and single values', () => {
+    expect(toggleSelection(['a', 'b'], ALL_VALUE, true)).toEqual([ALL_VALUE]);
+    expect(toggleSelection([ALL_VALUE], 'b', true)).toEqual(['b']);
+  });
+});
+
+describe('parseOptions', () => {
+  it('splits, trims and drops empty options', () => {
+    expect(parseOptions(' prod, staging ,,dev ')).toEqual(['prod', 'staging', 'dev']);
+    expect(parseOptions(undefined)).toEqual([]);
+  });
+});
+
+describe('staticValues', () => {
+  it('returns custom options', () => {
+    expect(staticValues({ name: 'env', type: 'custom', query: 'prod,staging' })).toEqual(['prod', 'staging']);
+  });
+
+  it('prepends auto to interval options', () => {
+    expect(staticValues({ name: 'w', type: 'interval', query: '1m,5m' })).toEqual(['1m', '5m']);
+    expect(staticValues({ name: 'w', type: 'interval', query: '1m,5m', auto: true })).toEqual([AUTO_INTERVAL, '1m', '5m']);
+  });
+
+  it('returns the constant value and no textbox values', () => {
+    expect(staticValues({ name: 'job', type: 'constant', query: 'node' })).toEqual(['node']);
+    expect(staticValues({ name: 'pod', type: 'textbox' })).toEqual([]);
+  });
+
+  it('returns null for fetched variables', () => {
+    expect(staticValues({ name: 'x', query: 'label_values(up, x)' })).toBeNull();
+    expect(staticValues({ name: 'ds', type: 'datasource' })).toBeNull();
+  });
+});
+
+describe('initialStaticSelection', () => {
+  it('ignores the URL for constants', () => {
+    expect(initialStaticSelection({ name: 'job', type: 'constant', query: 'node' }, ['node'], ['other'])).toEqual(['node']);
+  });
+
+  it('uses the URL or default text for textboxes', () => {
+    const def = { name: 'pod', type: 'textbox' as const, query: 'web-.*' };
+    expect(initialStaticSelection(def, [], ['api-1'])).toEqual(['api-1']);
+    expect(initialStaticSelection(def, [], undefined)).toEqual(['web-.*']);
+    expect(initialStaticSelection({ name: 'pod', type: 'textbox' }, [], undefined)).toEqual(['']);
+  });
+
+  it('defaults interval variables to auto', () => {
+    const def = { name: 'w', type: 'interval' as const, query: '1m,5m', auto: true };
+    expect(initialStaticSelection(def, [AUTO_INTERVAL, '1m', '5m'], undefined)).toEqual([AUTO_INTERVAL]);
+    expect(initialStaticSelection(def, [AUTO_INTERVAL, '1m', '5m'], ['5m'])).toEqual(['5m']);
+  });
+});
+
+describe('parseDuration', () => {
+  it('parses Prometheus durations', () => {
+    expect(parseDuration('30s')).toBe(30);
+    expect(parseDuration('1h30m')).toBe(5400);
+    expect(parseDuration('1d')).toBe(86400);
+    expect(parseDuration('500ms')).toBe(0.5);
+  });
+
+  it('rejects invalid durations', () => {
+    expect(parseDuration('')).toBeNull();
+    expect(parseDuration('5 minutes')).toBeNull();
+    expect(parseDuration('60')).toBeNull();
+  });
+});
+
+describe('formatDuration', () => {
+  it('uses the largest exact unit', () => {
+    expect(formatDuration(15)).toBe('15s');
+    expect(formatDuration(300)).toBe('5m');
+    expect(formatDuration(90)).toBe('90s');
+    expect(formatDuration(7200)).toBe('2h');
+    expect(formatDuration(86400)).toBe('1d');
+  });
+});
+
+describe('autoInterval', () => {
+  it('divides the range and rounds up', () => {
+    // 1h / 30 = 120s
+    expect(autoInterval(3600, 60)).toBe('2m');
+    // 24h / 30 = 48m -> 1h
+    expect(autoInterval(86400, 900)).toBe('1h');
+  });
+
+  it('is never finer than the step or the minimum', () => {
+    expect(autoInterval(900, 15)).toBe('30s');
+    expect(autoInterval(900, 120)).toBe('2m');
+    expect(autoInterval(60, 1, 30, 60)).toBe('1m');
+  });
+
+  it('honours the interval count', () => {
+    expect(autoInterval(3600, 15, 60)).toBe('1m');
+  });
+});
+
+describe('variableReferences', () => {
+  it('finds referenced variables in order', () => {
+    const query = 'label_values(kube_pod_info{namespace="$namespace", node=~"${node}", x="$namespace"}, pod)';
+    expect(variableReferences(query, ['node', 'namespace', 'pod'])).toEqual(['namespace', 'node']);
+  });
+
+  it('ignores unknown names and longer identifiers', () => {
+    expect(variableReferences('m{a="$namespaced", b="$other"}', ['namespace'])).toEqual([]);
+  });
+});
+
+describe('ad-hoc filters', () => {
+  it('formats filters as label matchers', () => {
+    expect(formatFilter({ key: 'instance', op: '=', value: 'web-3' })).toBe('instance="web-3"');
+    expect(formatFilter({ key: 'path', op: '=~', value: '/api/"x"\\d+' })).toBe('path=~"/api/\\"x\\"\\\\d+"');
+  });
+
+  it('parses what it formats', () => {
+    const filter = { key: 'path', op: '!~', value: '/api/"x"\\d+' } as const;
+    expect(parseFilter(formatFilter(filter))).toEqual(filter);
+  });
+
+  it('rejects malformed filters', () => {
+    expect(parseFilter('instance=web-3')).toBeNull();
+    expect(parseFilter('in-stance="a"')).toBeNull();
+    expect(parseFilter('instance=="a"')).toBeNull();
+  });
+
+  it('builds a selector from several filters', () => {
+    expect(filtersSelector([])).toBe('');
+    expect(filtersSelector(['a="1"', 'b!="2"'])).toBe('{a="1", b!="2"}');
+  });
+});
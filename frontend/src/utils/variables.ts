@@ -1,12 +1,4 @@
-/**
- * Parses a label_values(metric, label) query string.
- * Returns the metric and label, or null if the format doesn't match.
- */
-export function parseLabelValuesQuery(query: string): { metric: string; label: string } | null {
-  const match = query.match(/^label_values\(\s*([^,]+?)\s*,\s*([^)]+?)\s*\)$/);
-  if (!match) return null;
-  return { metric: match[1], label: match[2] };
-}
+import type { Variable } from '../types';
 
 /**
  * Substitutes template variables in a string.
@@ -31,3 +23,199 @@ export function substituteVariables(template: string, variables: Record<string,
 
   return result;
 }
+
+/**
+ * The variables, among names, that a query refers to as $name or ${name}, in
+ * order of first appearance.
+ */
+export function variableReferences(query: string, names: string[]): string[] {
+  const refs: string[] = [];
+  for (const [, braced, bare] of query.matchAll(/\$(?:\{(\w+)\}|(\w+))/g)) {
+    const name = braced ?? bare;
+    if (names.includes(name) && !refs.includes(name)) refs.push(name);
+  }
+  return refs;
+}
+
+/** Selection sentinel meaning "every value" for include_all variables. */
+export const ALL_VALUE = '$__all';
+
+interface SelectionOptions {
+  multi?: boolean;
+  include_all?: boolean;
+  all_value?: string;
+}
+
+/**
+ * Escapes regex metacharacters in a value for use inside a double-quoted
+ * PromQL =~ matcher. The backslash itself is doubled because PromQL string
+ * literals consume one level of escaping.
+ */
+export function escapePromRegex(value: string): string {
+  return value.replace(/[.*+?^${}()|[\]\\]/g, '\\\\$&');
+}
+
+/** The concrete values a selection stands for: every value when "All" is selected. */
+export function resolveSelection(selected: string[], values: string[]): string[] {
+  return selected.includes(ALL_VALUE) ? values : selected;
+}
+
+/**
+ * The text substituted for a variable in queries and titles. Multi-value and
+ * include_all variables become a regex alternation ("a|b|c") of escaped
+ * values, meant for =~ matchers; "All" becomes all_value when set.
+ */
+export function formatSelection(options: SelectionOptions, selected: string[], values: string[]): string {
+  if (options.all_value !== undefined && selected.includes(ALL_VALUE)) {
+    return options.all_value;
+  }
+  const resolved = resolveSelection(selected, values);
+  if (!options.multi && !options.include_all) {
+    return resolved[0] ?? '';
+  }
+  return resolved.map(escapePromRegex).join('|');
+}
+
+/**
+ * The initial selection once a variable's values are known: the values from
+ * the URL that still exist, otherwise "All" for include_all variables,
+ * otherwise the first value.
+ */
+export function initialSelection(options: SelectionOptions, values: string[], urlValues?: string[]): string[] {
+  const valid = (urlValues || []).filter((v) => values.includes(v) || (v === ALL_VALUE && options.include_all));
+  if (valid.length > 0) {
+    return valid.includes(ALL_VALUE) ? [ALL_VALUE] : options.multi ? valid : [valid[0]];
+  }
+  if (options.include_all) return [ALL_VALUE];
+  return values.length > 0 ? [values[0]] : [];
+}
+
+/**
+ * Toggles value in a multi-value selection. Picking "All" or a value while
+ * "All" is selected replaces the selection; the last value cannot be
+ * unchecked unless "All" is available to fall back to.
+ */
+export function toggleSelection(selected: string[], value: string, includeAll?: boolean): string[] {
+  if (value === ALL_VALUE) return [ALL_VALUE];
+  if (selected.includes(ALL_VALUE)) return [value];
+  if (!selected.includes(value)) return [...selected, value];
+  const next = selected.filter((v) => v !== value);
+  if (next.length > 0) return next;
+  return includeAll ? [ALL_VALUE] : selected;
+}
+
+/** Selection sentinel for the "auto" option of interval variables. */
+export const AUTO_INTERVAL = '$__auto';
+
+/** Splits the comma-separated options of a custom or interval variable. */
+export function parseOptions(query: string | undefined): string[] {
+  return (query || '')
+    .split(',')
+    .map((o) => o.trim())
+    .filter((o) => o !== '');
+}
+
+/**
+ * The values of a variable that is defined by the dashboard itself rather
+ * than fetched: custom and interval options, or the value of a constant.
+ * Returns null for variables whose values come from a datasource.
+ */
+export function staticValues(def: Variable): string[] | null {
+  switch (def.type) {
+    case 'custom':
+      return parseOptions(def.query);
+    case 'interval':
+      return def.auto ? [AUTO_INTERVAL, ...parseOptions(def.query)] : parseOptions(def.query);
+    case 'constant':
+      return [def.query || ''];
+    case 'textbox':
+    case 'adhoc_filters':
+      return [];
+    default:
+      return null;
+  }
+}
+
+/**
+ * The initial selection of a static variable: a constant always has its
+ * value, a textbox starts with the URL value or its default text.
+ */
+export function initialStaticSelection(def: Variable, values: string[], urlValues?: string[]): string[] {
+  switch (def.type) {
+    case 'constant':
+      return values;
+    case 'textbox':
+      return [urlValues?.[0] ?? def.query ?? ''];
+    case 'adhoc_filters':
+      return (urlValues ?? []).filter((f) => parseFilter(f) !== null);
+    default:
+      return initialSelection(def, values, urlValues);
+  }
+}
+
+const DURATION_UNITS: Record<string, number> = {
+  ms: 0.001, s: 1, m: 60, h: 3600, d: 86400, w: 604800, y: 31536000,
+};
+
+/** Parses a Prometheus duration such as "30s" or "1h30m" into seconds; null if invalid. */
+export function parseDuration(s: string): number | null {
+  if (!/^(\d+(ms|[smhdwy]))+$/.test(s)) return null;
+  let seconds = 0;
+  for (const [, n, unit] of s.matchAll(/(\d+)(ms|[smhdwy])/g)) {
+    seconds += Number(n) * DURATION_UNITS[unit];
+  }
+  return seconds;
+}
+
+// Intervals "auto" rounds up to, in seconds.
+const AUTO_STEPS = [10, 15, 30, 60, 120, 300, 600, 900, 1800, 3600, 7200, 21600, 43200, 86400, 604800];
+
+/** Formats whole seconds as the largest exact Prometheus duration unit, e.g. 300 -> "5m". */
+export function formatDuration(seconds: number): string {
+  for (const [unit, size] of [['w', 604800], ['d', 86400], ['h', 3600], ['m', 60]] as const) {
+    if (seconds >= size && seconds % size === 0) return `${seconds / size}${unit}`;
+  }
+  return `${seconds}s`;
+}
+
+/**
+ * The interval "auto" stands for: the time range divided into count
+ * intervals, but no shorter than the query step or minSeconds, rounded up to
+ * a round duration.
+ */
+export function autoInterval(durationSeconds: number, stepSeconds: number, count = 30, minSeconds = 10): string {
+  const raw = Math.max(durationSeconds / count, stepSeconds, minSeconds);
+  const step = AUTO_STEPS.find((s) => s >= raw);
+  return formatDuration(step ?? Math.ceil(raw));
+}
+
+export const FILTER_OPERATORS = ['=', '!=', '=~', '!~'] as const;
+
+export interface AdhocFilter {
+  key: string;
+  op: (typeof FILTER_OPERATORS)[number];
+  value: string;
+}
+
+/** Formats an ad-hoc filter as a PromQL label matcher, e.g. instance="web-3". */
+export function formatFilter(filter: AdhocFilter): string {
+  return `${filter.key}${filter.op}${JSON.stringify(filter.value)}`;
+}
+
+/** Parses a label matcher written by formatFilter, or returns null. */
+export function parseFilter(s: string): AdhocFilter | null {
+  const m = s.match(/^([a-zA-Z_][a-zA-Z0-9_]*)(=~|!~|!=|=)(".*")$/);
+  if (!m) return null;
+  try {
+    const value = JSON.parse(m[3]);
+    if (typeof value !== 'string') return null;
+    return { key: m[1], op: m[2] as AdhocFilter['op'], value };
+  } catch {
+    return null;
+  }
+}
+
+/** The selector sent with panel queries for the given filters, or '' for none. */
+export function filtersSelector(filters: string[]): string {
+  return filters.length > 0 ? `{${filters.join(', ')}}` : '';
+}
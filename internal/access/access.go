@@ -0,0 +1,123 @@
+// Package access applies the per-user policies of the config: it resolves the
+// groups of a user, which dashboard access rules refer to, and the label
+// matchers that label policies force into their queries.
+package access
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/tokuhirom/dashyard/internal/auth"
+	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/promql"
+)
+
+const (
+	groupsKey   = "groups"
+	matchersKey = "label_matchers"
+)
+
+// Policies holds the groups and label policies of the config.
+type Policies struct {
+	groups   map[string][]string // user ID -> group names
+	policies []policy
+	// used holds the groups that the config defines or label policies list.
+	used map[string]bool
+}
+
+type policy struct {
+	users    []string
+	groups   []string
+	matchers []*labels.Matcher
+}
+
+// New builds the Policies of cfg.
+func New(cfg *config.Config) (*Policies, error) {
+	p := &Policies{groups: make(map[string][]string), used: make(map[string]bool)}
+	for _, g := range cfg.Groups {
+		p.used[g.Name] = true
+		for _, member := range g.Members {
+			p.groups[member] = append(p.groups[member], g.Name)
+		}
+	}
+	for _, lp := range cfg.LabelPolicies {
+		matchers, err := promql.ParseMatchers(lp.Matchers)
+		if err != nil {
+			return nil, err
+		}
+		p.policies = append(p.policies, policy{users: lp.Users, groups: lp.Groups, matchers: matchers})
+		for _, g := range lp.Groups {
+			p.used[g] = true
+		}
+	}
+	return p, nil
+}
+
+// Groups returns the names of the groups userID is a member of.
+func (p *Policies) Groups(userID string) []string {
+	return p.groups[userID]
+}
+
+// UsesGroup reports whether the config defines the group or a label policy
+// lists it.
+func (p *Policies) UsesGroup(name string) bool {
+	return p.used[name]
+}
+
+// Matchers returns the label matchers forced into the queries of userID, a
+// member of groups: those of every label policy that lists the user or one of
+// their groups. All of them apply, so a user under several policies sees the
+// intersection.
+func (p *Policies) Matchers(userID string, groups []string) []*labels.Matcher {
+	var out []*labels.Matcher
+	for _, lp := range p.policies {
+		applies := slices.Contains(lp.users, userID)
+		for _, g := range groups {
+			applies = applies || slices.Contains(lp.groups, g)
+		}
+		if applies {
+			out = append(out, lp.matchers...)
+		}
+	}
+	return out
+}
+
+// Middleware returns a Gin middleware that stores the groups and label
+// matchers of the authenticated user in the Gin context. The groups of the
+// user are those of the config along with any that the identity provider
+// reported, such as the groups header of an authenticating proxy. It must run
+// after auth.AuthMiddleware.
+func Middleware(p *Policies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := auth.GetUserID(c)
+		groups := slices.Clip(p.Groups(userID))
+		for _, g := range auth.GetProviderGroups(c) {
+			if !slices.Contains(groups, g) {
+				groups = append(groups, g)
+			}
+		}
+		if len(groups) > 0 {
+			c.Set(groupsKey, groups)
+		}
+		if matchers := p.Matchers(userID, groups); len(matchers) > 0 {
+			c.Set(matchersKey, matchers)
+		}
+		c.Next()
+	}
+}
+
+// GetGroups retrieves the groups of the current user from the Gin context.
+func GetGroups(c *gin.Context) []string {
+	v, _ := c.Get(groupsKey)
+	groups, _ := v.([]string)
+	return groups
+}
+
+// GetMatchers retrieves the label matchers forced into the queries of the
+// current user from the Gin context, or nil if no label policy applies.
+func GetMatchers(c *gin.Context) []*labels.Matcher {
+	v, _ := c.Get(matchersKey)
+	matchers, _ := v.([]*labels.Matcher)
+	return matchers
+}
@@ -0,0 +1,142 @@
+package access
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/tokuhirom/dashyard/internal/auth"
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func testPolicies(t *testing.T) *Policies {
+	t.Helper()
+	p, err := New(&config.Config{
+		Groups: []config.Group{
+			{Name: "payments", Members: []string{"alice", "bob"}},
+			{Name: "prod", Members: []string{"bob"}},
+		},
+		LabelPolicies: []config.LabelPolicy{
+			{Groups: []string{"payments"}, Matchers: `team="payments"`},
+			{Groups: []string{"prod"}, Matchers: `{env="prod"}`},
+			{Users: []string{"carol"}, Matchers: `team=~"search|ads"`},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return p
+}
+
+func matcherStrings(ms []*labels.Matcher) []string {
+	out := make([]string, len(ms))
+	for i, m := range ms {
+		out[i] = m.String()
+	}
+	return out
+}
+
+func TestMatchers(t *testing.T) {
+	p := testPolicies(t)
+
+	tests := []struct {
+		user string
+		want []string
+	}{
+		{"alice", []string{`team="payments"`}},
+		{"bob", []string{`team="payments"`, `env="prod"`}},
+		{"carol", []string{`team=~"search|ads"`}},
+		{"admin", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.user, func(t *testing.T) {
+			if got := matcherStrings(p.Matchers(tt.user, p.Groups(tt.user))); !slices.Equal(got, tt.want) {
+				t.Errorf("Matchers(%q) = %v, want %v", tt.user, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroups(t *testing.T) {
+	p := testPolicies(t)
+	if got := p.Groups("bob"); !slices.Equal(got, []string{"payments", "prod"}) {
+		t.Errorf("Groups(bob) = %v", got)
+	}
+	if got := p.Groups("admin"); len(got) != 0 {
+		t.Errorf("Groups(admin) = %v, want none", got)
+	}
+}
+
+func TestUsesGroup(t *testing.T) {
+	p, err := New(&config.Config{
+		Groups:        []config.Group{{Name: "payments", Members: []string{"alice"}}},
+		LabelPolicies: []config.LabelPolicy{{Groups: []string{"sre"}, Matchers: `team="sre"`}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for group, want := range map[string]bool{"payments": true, "sre": true, "sales": false} {
+		if got := p.UsesGroup(group); got != want {
+			t.Errorf("UsesGroup(%q) = %v, want %v", group, got, want)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	sm := auth.NewSessionManager("test-secret-that-is-32bytes!!", false)
+	router := gin.New()
+	router.Use(auth.AuthMiddleware(sm, nil, nil), Middleware(testPolicies(t)))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"groups": GetGroups(c), "matchers": matcherStrings(GetMatchers(c))})
+	})
+
+	for user, want := range map[string]string{
+		"alice": `{"groups":["payments"],"matchers":["team=\"payments\""]}`,
+		"admin": `{"groups":null,"matchers":[]}`,
+	} {
+		login := httptest.NewRecorder()
+		if err := sm.CreateSession(httptest.NewRequest(http.MethodGet, "/", nil), login, user); err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		for _, cookie := range login.Result().Cookies() {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Body.String() != want {
+			t.Errorf("%s: got %s, want %s", user, w.Body.String(), want)
+		}
+	}
+}
+
+func TestMiddlewareProviderGroups(t *testing.T) {
+	sm := auth.NewSessionManager("test-secret-that-is-32bytes!!", false)
+	router := gin.New()
+	router.Use(auth.AuthMiddleware(sm, nil, nil), Middleware(testPolicies(t)))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"groups": GetGroups(c), "matchers": matcherStrings(GetMatchers(c))})
+	})
+
+	// Groups passed by an authenticating proxy count like configured ones.
+	login := httptest.NewRecorder()
+	if err := sm.CreateSessionWithGroups(httptest.NewRequest(http.MethodGet, "/", nil), login, "alice", []string{"prod"}); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	for _, cookie := range login.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if want := `{"groups":["payments","prod"],"matchers":["team=\"payments\"","env=\"prod\""]}`; w.Body.String() != want {
+		t.Errorf("got %s, want %s", w.Body.String(), want)
+	}
+}
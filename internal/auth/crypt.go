@@ -1,13 +1,123 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
 	"github.com/GehirnInc/crypt"
 	_ "github.com/GehirnInc/crypt/sha512_crypt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hash algorithms.
+const (
+	AlgoSHA512   = "sha512"
+	AlgoBcrypt   = "bcrypt"
+	AlgoArgon2id = "argon2id"
 )
 
-// VerifyPassword checks a plaintext password against a SHA-512 crypt hash ($6$ format).
+// argon2id parameters of new hashes: the second recommended option of RFC
+// 9106, which uses 64 MiB of memory.
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// hashAlgo returns the algorithm of a password hash by its prefix, or "" if
+// it is not supported.
+func hashAlgo(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$6$"):
+		return AlgoSHA512
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return AlgoBcrypt
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return AlgoArgon2id
+	default:
+		return ""
+	}
+}
+
+// SupportedHash reports whether VerifyPassword can check passwords against
+// hash.
+func SupportedHash(hash string) bool {
+	return hashAlgo(hash) != ""
+}
+
+// VerifyPassword checks a plaintext password against a SHA-512 crypt ($6$),
+// bcrypt ($2a$, $2b$ or $2y$) or argon2id ($argon2id$) hash.
 func VerifyPassword(password, hash string) bool {
-	c := crypt.SHA512.New()
-	err := c.Verify(hash, []byte(password))
-	return err == nil
+	switch hashAlgo(hash) {
+	case AlgoSHA512:
+		return crypt.SHA512.New().Verify(hash, []byte(password)) == nil
+	case AlgoBcrypt:
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case AlgoArgon2id:
+		return verifyArgon2id(password, hash)
+	default:
+		return false
+	}
+}
+
+// HashPassword hashes a password with the given algorithm, in the format
+// VerifyPassword accepts.
+func HashPassword(password, algo string) (string, error) {
+	switch algo {
+	case AlgoSHA512:
+		return crypt.SHA512.New().Generate([]byte(password), nil)
+	case AlgoBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", err
+		}
+		// $2y$ is what htpasswd writes; the variants differ only in bugs of
+		// old PHP and OpenBSD implementations.
+		return "$2y$" + strings.TrimPrefix(string(hash), "$2a$"), nil
+	case AlgoArgon2id:
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, argon2Time, argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	default:
+		return "", fmt.Errorf("unsupported algorithm %q", algo)
+	}
+}
+
+// verifyArgon2id checks a password against an argon2id hash in the PHC
+// string format: $argon2id$v=19$m=<KiB>,t=<passes>,p=<threads>$<salt>$<key>,
+// with the salt and key in unpadded base64.
+func verifyArgon2id(password, hash string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil || time == 0 || threads == 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false
+	}
+	got := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(got, key) == 1
 }
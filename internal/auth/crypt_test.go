@@ -1,10 +1,12 @@
 package auth
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/GehirnInc/crypt"
 	_ "github.com/GehirnInc/crypt/sha512_crypt"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func generateHash(password string) string {
@@ -33,3 +35,96 @@ func TestVerifyPasswordInvalidHash(t *testing.T) {
 		t.Error("expected invalid hash to fail verification")
 	}
 }
+
+func TestHashPassword(t *testing.T) {
+	tests := []struct {
+		algo   string
+		prefix string
+	}{
+		{AlgoSHA512, "$6$"},
+		{AlgoBcrypt, "$2y$"},
+		{AlgoArgon2id, "$argon2id$v=19$m=65536,t=3,p=4$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algo, func(t *testing.T) {
+			hash, err := HashPassword("correctpassword", tt.algo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(hash, tt.prefix) {
+				t.Errorf("expected hash with prefix %q, got %q", tt.prefix, hash)
+			}
+			if !VerifyPassword("correctpassword", hash) {
+				t.Error("expected password to verify successfully")
+			}
+			if VerifyPassword("wrongpassword", hash) {
+				t.Error("expected wrong password to fail verification")
+			}
+		})
+	}
+}
+
+func TestHashPasswordUnsupportedAlgorithm(t *testing.T) {
+	if _, err := HashPassword("password", "md5"); err == nil {
+		t.Error("expected error for unsupported algorithm")
+	}
+}
+
+func TestVerifyPasswordBcryptVariants(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		h := prefix + strings.TrimPrefix(string(hash), "$2a$")
+		if !VerifyPassword("password", h) {
+			t.Errorf("expected %s hash to verify", prefix)
+		}
+	}
+}
+
+func TestVerifyPasswordArgon2id(t *testing.T) {
+	// Parameters other than those HashPassword uses are read from the hash.
+	hash := "$argon2id$v=19$m=1024,t=2,p=1$c29tZXNhbHRzb21lc2FsdA$CKGe5/bX9YnCq2rxjW5yQXKxn31v1GKzhDCrMc6r6vA"
+	if !VerifyPassword("password", hash) {
+		t.Error("expected password to verify successfully")
+	}
+	if VerifyPassword("Password", hash) {
+		t.Error("expected wrong password to fail verification")
+	}
+
+	malformed := []string{
+		"$argon2id$v=16$m=1024,t=2,p=1$c29tZXNhbHRzb21lc2FsdA$CKGe5/bX9YnCq2rxjW5yQXKxn31v1GKzhDCrMc6r6vA",
+		"$argon2id$v=19$m=1024,t=0,p=1$c29tZXNhbHRzb21lc2FsdA$CKGe5/bX9YnCq2rxjW5yQXKxn31v1GKzhDCrMc6r6vA",
+		"$argon2id$v=19$m=1024,t=2$c29tZXNhbHRzb21lc2FsdA$CKGe5/bX9YnCq2rxjW5yQXKxn31v1GKzhDCrMc6r6vA",
+		"$argon2id$v=19$m=1024,t=2,p=1$!!!$CKGe5/bX9YnCq2rxjW5yQXKxn31v1GKzhDCrMc6r6vA",
+		"$argon2id$v=19$m=1024,t=2,p=1$c29tZXNhbHRzb21lc2FsdA$",
+		"$argon2id$v=19$m=1024,t=2,p=1$c29tZXNhbHRzb21lc2FsdA",
+	}
+	for _, h := range malformed {
+		if VerifyPassword("password", h) {
+			t.Errorf("expected malformed hash %q to fail verification", h)
+		}
+	}
+}
+
+func TestSupportedHash(t *testing.T) {
+	tests := []struct {
+		hash string
+		want bool
+	}{
+		{"$6$salt$hash", true},
+		{"$2y$10$abc", true},
+		{"$2a$10$abc", true},
+		{"$argon2id$v=19$m=1024,t=2,p=1$salt$key", true},
+		{"$argon2i$v=19$m=1024,t=2,p=1$salt$key", false},
+		{"$apr1$salt$hash", false},
+		{"{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", false},
+		{"plaintext", false},
+	}
+	for _, tt := range tests {
+		if got := SupportedHash(tt.hash); got != tt.want {
+			t.Errorf("SupportedHash(%q) = %v, want %v", tt.hash, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/markbates/goth"
+)
+
+// defaultTeamCacheTTL is how long the GitHub teams of a user are remembered
+// after being listed, so that logging in again, e.g. from another browser or
+// once the session expires, does not list them through the API each time,
+// unless team_cache_ttl says otherwise.
+const defaultTeamCacheTTL = 10 * time.Minute
+
+// FetchGitHubTeams retrieves the teams of the authenticated user, across all
+// organizations, as "org/team-slug". If baseURL is set, it uses the GitHub
+// Enterprise API endpoint instead of api.github.com. Listing teams needs the
+// read:org scope.
+func FetchGitHubTeams(accessToken string, baseURL string) ([]string, error) {
+	var names []string
+	err := fetchPages(gitHubAPIURL(baseURL)+"/user/teams?per_page=100", gitHubAuth(accessToken), func(resp *http.Response) error {
+		var teams []struct {
+			Slug         string `json:"slug"`
+			Organization struct {
+				Login string `json:"login"`
+			} `json:"organization"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&teams); err != nil {
+			return err
+		}
+		for _, t := range teams {
+			names = append(names, t.Organization.Login+"/"+t.Slug)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GitHub API: %w", err)
+	}
+	return names, nil
+}
+
+// teamCache remembers the GitHub teams of users for the team_cache_ttl of
+// their provider. It is shared by the sessions of a user, as the teams are
+// only needed when logging in, before there is a session.
+type teamCache struct {
+	mu      sync.Mutex
+	entries map[string]teamCacheEntry
+	now     func() time.Time
+}
+
+type teamCacheEntry struct {
+	teams  []string
+	listed time.Time
+}
+
+// gitHubTeams is the cache of the teams of the users who logged in.
+var gitHubTeams = newTeamCache()
+
+func newTeamCache() *teamCache {
+	return &teamCache{entries: make(map[string]teamCacheEntry), now: time.Now}
+}
+
+// teams returns the teams of a GitHub user, from the cache if they were
+// listed less than ttl ago. A zero ttl means defaultTeamCacheTTL and a
+// negative one always lists the teams.
+func (c *teamCache) teams(user goth.User, baseURL string, ttl time.Duration) ([]string, error) {
+	if ttl == 0 {
+		ttl = defaultTeamCacheTTL
+	}
+	if user.UserID == "" || ttl < 0 {
+		return FetchGitHubTeams(user.AccessToken, baseURL)
+	}
+	// The numeric user ID, unlike the login, survives renames.
+	key := gitHubAPIURL(baseURL) + " " + user.UserID
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Sub(entry.listed) < ttl {
+		return entry.teams, nil
+	}
+
+	teams, err := FetchGitHubTeams(user.AccessToken, baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.entries {
+		if now.Sub(e.listed) >= ttl {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = teamCacheEntry{teams: teams, listed: now}
+	return teams, nil
+}
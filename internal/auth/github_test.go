@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/markbates/goth"
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+// newGitHubTeamsServer serves two pages of teams under the GitHub Enterprise
+// API path and counts the requests for the first page.
+func newGitHubTeamsServer(t *testing.T, listings *atomic.Int64) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/user/teams" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("expected Bearer test-token, got %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = w.Write([]byte(`[{"slug":"sre","organization":{"login":"Acme"}}]`))
+			return
+		}
+		listings.Add(1)
+		w.Header().Set("Link", `<`+server.URL+`/api/v3/user/teams?per_page=100&page=2>; rel="next"`)
+		_, _ = w.Write([]byte(`[{"slug":"developers","organization":{"login":"Acme"}},{"slug":"docs","organization":{"login":"other"}}]`))
+	}))
+	return server
+}
+
+func TestFetchGitHubTeams(t *testing.T) {
+	var listings atomic.Int64
+	server := newGitHubTeamsServer(t, &listings)
+	defer server.Close()
+
+	teams, err := FetchGitHubTeams("test-token", server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"Acme/developers", "other/docs", "Acme/sre"}
+	if !slices.Equal(teams, want) {
+		t.Errorf("expected %v, got %v", want, teams)
+	}
+}
+
+func TestTeamCache(t *testing.T) {
+	var listings atomic.Int64
+	server := newGitHubTeamsServer(t, &listings)
+	defer server.Close()
+
+	now := time.Unix(1700000000, 0)
+	cache := newTeamCache()
+	cache.now = func() time.Time { return now }
+	user := goth.User{UserID: "12345", AccessToken: "test-token"}
+
+	for range 2 {
+		if _, err := cache.teams(user, server.URL, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := listings.Load(); got != 1 {
+		t.Errorf("expected the second lookup to be cached, got %d listings", got)
+	}
+
+	// Another user is listed separately.
+	if _, err := cache.teams(goth.User{UserID: "67890", AccessToken: "test-token"}, server.URL, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := listings.Load(); got != 2 {
+		t.Errorf("expected another user to be listed, got %d listings", got)
+	}
+
+	// Once expired, the teams are listed again.
+	now = now.Add(defaultTeamCacheTTL)
+	if _, err := cache.teams(user, server.URL, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := listings.Load(); got != 3 {
+		t.Errorf("expected an expired entry to be listed again, got %d listings", got)
+	}
+
+	// A shorter team_cache_ttl expires the entry sooner.
+	now = now.Add(time.Minute)
+	if _, err := cache.teams(user, server.URL, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := listings.Load(); got != 4 {
+		t.Errorf("expected the entry to expire after team_cache_ttl, got %d listings", got)
+	}
+
+	// A negative team_cache_ttl always lists the teams.
+	if _, err := cache.teams(user, server.URL, -1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := listings.Load(); got != 5 {
+		t.Errorf("expected a negative team_cache_ttl to skip the cache, got %d listings", got)
+	}
+}
+
+func TestCheckUserAllowedByTeam(t *testing.T) {
+	var listings atomic.Int64
+	server := newGitHubTeamsServer(t, &listings)
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		teams []string
+		want  bool
+	}{
+		{"team on the second page", []string{"acme/sre"}, true},
+		{"other team", []string{"acme/security"}, false},
+		{"same slug in another org", []string{"other/sre"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := goth.User{NickName: "someone", UserID: "12345", AccessToken: "test-token"}
+			cfg := config.OAuthProviderConfig{
+				Provider:     "github",
+				BaseURL:      server.URL,
+				AllowedTeams: tt.teams,
+			}
+			allowed, err := CheckUserAllowed(user, cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if allowed != tt.want {
+				t.Errorf("expected allowed=%v, got %v", tt.want, allowed)
+			}
+		})
+	}
+	if got := listings.Load(); got != 1 {
+		t.Errorf("expected the teams to be listed once, got %d listings", got)
+	}
+}
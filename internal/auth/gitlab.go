@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/gitlab"
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+// gitLabURL is the GitLab instance used unless base_url points to a
+// self-hosted one.
+const gitLabURL = "https://gitlab.com"
+
+// newGitLabProvider creates the goth provider for GitLab.com or, with
+// base_url, a self-hosted GitLab. Listing the groups of a user for
+// allowed_groups takes the read_api scope on top of the default read_user.
+func newGitLabProvider(p config.OAuthProviderConfig) goth.Provider {
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"read_user"}
+		if len(p.AllowedGroups) > 0 {
+			scopes = append(scopes, "read_api")
+		}
+	}
+	base := gitLabBaseURL(p.BaseURL)
+	authURL := base + "/oauth/authorize"
+	tokenURL := base + "/oauth/token"
+	profileURL := base + "/api/v4/user"
+	return gitlab.NewCustomisedURL(p.ClientID, p.ClientSecret, p.RedirectURL, authURL, tokenURL, profileURL, scopes...)
+}
+
+func gitLabBaseURL(baseURL string) string {
+	if baseURL == "" {
+		return gitLabURL
+	}
+	return strings.TrimRight(baseURL, "/")
+}
+
+// FetchGitLabGroups retrieves the full paths (e.g. "infra/sre") of the groups
+// the authenticated user is a member of, directly or through a parent group.
+// If baseURL is set, it uses that GitLab instance instead of gitlab.com.
+func FetchGitLabGroups(accessToken string, baseURL string) ([]string, error) {
+	var paths []string
+	err := fetchPages(gitLabBaseURL(baseURL)+"/api/v4/groups?min_access_level=10&per_page=100", func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}, func(resp *http.Response) error {
+		var groups []struct {
+			FullPath string `json:"full_path"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&groups); err != nil {
+			return err
+		}
+		for _, g := range groups {
+			paths = append(paths, g.FullPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GitLab API: %w", err)
+	}
+	return paths, nil
+}
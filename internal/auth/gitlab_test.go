@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+func TestFetchGitLabGroups(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("expected Bearer test-token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/api/v4/groups" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", `<`+server.URL+`/api/v4/groups?page=2>; rel="next", <`+server.URL+`/api/v4/groups?page=3>; rel="last"`)
+			_, _ = w.Write([]byte(`[{"full_path":"infra"},{"full_path":"infra/sre"}]`))
+		case "2":
+			// A link to another host must not receive the token.
+			w.Header().Set("Link", `<https://evil.example/api/v4/groups?page=3>; rel="next"`)
+			_, _ = w.Write([]byte(`[{"full_path":"apps"}]`))
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	}))
+	defer server.Close()
+
+	groups, err := FetchGitLabGroups("test-token", server.URL+"/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"infra", "infra/sre", "apps"}
+	if len(groups) != len(want) {
+		t.Fatalf("expected %v, got %v", want, groups)
+	}
+	for i := range want {
+		if groups[i] != want[i] {
+			t.Errorf("groups[%d] = %q, want %q", i, groups[i], want[i])
+		}
+	}
+}
+
+func TestFetchGitLabGroupsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	if _, err := FetchGitLabGroups("test-token", server.URL); err == nil {
+		t.Error("expected an error for a 403 response")
+	}
+}
+
+func TestInitGothProvidersGitLab(t *testing.T) {
+	providers := []config.OAuthProviderConfig{
+		{
+			Provider:     "gitlab",
+			ClientID:     "test-id",
+			ClientSecret: "test-secret",
+			RedirectURL:  "http://localhost:8080/auth/gitlab/callback",
+			BaseURL:      "https://gitlab.example.com",
+		},
+	}
+	if err := InitGothProviders(providers); err != nil {
+		t.Fatalf("InitGothProviders failed: %v", err)
+	}
+
+	p, err := goth.GetProvider("gitlab")
+	if err != nil {
+		t.Fatalf("expected gitlab provider to be registered: %v", err)
+	}
+	sess, err := p.BeginAuth("state")
+	if err != nil {
+		t.Fatalf("BeginAuth failed: %v", err)
+	}
+	authURL, _ := sess.GetAuthURL()
+	if !strings.HasPrefix(authURL, "https://gitlab.example.com/oauth/authorize?") {
+		t.Errorf("expected auth URL on the self-hosted instance, got %q", authURL)
+	}
+}
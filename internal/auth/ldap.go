@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+// ErrInvalidCredentials is returned when a user ID and password do not
+// authenticate a user who may sign in.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// LDAPAuth checks passwords against an LDAP directory. It searches for the
+// entry of the user, binds as that entry with the password, and lists the
+// groups of the user.
+type LDAPAuth struct {
+	cfg       config.LDAPConfig
+	tlsConfig *tls.Config
+	usesGroup func(name string) bool
+}
+
+// NewLDAPAuth creates an LDAPAuth for the directory of cfg. usesGroup reports
+// whether the config or the dashboards refer to a group: only those groups
+// of a user, and the allowed groups, are returned, as they are kept in the
+// session cookie, which cannot hold the hundreds of groups of some
+// directories. A nil usesGroup keeps every group.
+func NewLDAPAuth(cfg config.LDAPConfig, usesGroup func(name string) bool) (*LDAPAuth, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP url: %w", err)
+	}
+	if u.Scheme == "ldap" && !cfg.StartTLS {
+		slog.Warn("LDAP passwords are sent in cleartext; use an ldaps:// url or start_tls", "url", cfg.URL)
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading LDAP CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in LDAP CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return &LDAPAuth{cfg: cfg, tlsConfig: tlsConfig, usesGroup: usesGroup}, nil
+}
+
+// Authenticate checks the password of userID and returns the groups of the
+// user. It returns ErrInvalidCredentials if the user is not found, the
+// password is wrong, or the user is in none of the allowed groups.
+func (a *LDAPAuth) Authenticate(userID, password string) ([]string, error) {
+	// A simple bind with an empty password is an unauthenticated bind, which
+	// many servers accept for any DN.
+	if userID == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := a.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if err := a.bindService(conn); err != nil {
+		return nil, err
+	}
+
+	filter := strings.ReplaceAll(a.cfg.UserFilter, "{user}", ldap.EscapeFilter(userID))
+	res, err := conn.Search(ldap.NewSearchRequest(
+		a.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, 0, false, filter, []string{"dn"}, nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("searching LDAP user: %w", err)
+	}
+	if res == nil || len(res.Entries) != 1 {
+		if res != nil && len(res.Entries) > 1 {
+			slog.Warn("LDAP user filter matches several entries", "user", userID, "filter", filter)
+		}
+		return nil, ErrInvalidCredentials
+	}
+	userDN := res.Entries[0].DN
+
+	if err := conn.Bind(userDN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("binding as LDAP user: %w", err)
+	}
+
+	// Groups are searched as the service account, since users may not be
+	// allowed to read them.
+	if err := a.bindService(conn); err != nil {
+		return nil, err
+	}
+	groups, err := a.groups(conn, userID, userDN)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(a.cfg.AllowedGroups) > 0 && !slices.ContainsFunc(groups, func(g string) bool {
+		return slices.Contains(a.cfg.AllowedGroups, g)
+	}) {
+		slog.Info("LDAP user is not in any allowed group", "user", userID, "groups", groups)
+		return nil, ErrInvalidCredentials
+	}
+	if a.usesGroup != nil {
+		groups = slices.DeleteFunc(groups, func(g string) bool {
+			return !a.usesGroup(g) && !slices.Contains(a.cfg.AllowedGroups, g)
+		})
+	}
+	return groups, nil
+}
+
+func (a *LDAPAuth) dial() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(a.cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: a.cfg.Timeout}),
+		ldap.DialWithTLSConfig(a.tlsConfig),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to LDAP: %w", err)
+	}
+	conn.SetTimeout(a.cfg.Timeout)
+	if a.cfg.StartTLS {
+		if err := conn.StartTLS(a.tlsConfig); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("LDAP StartTLS: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// bindService binds as the service account, or anonymously without one.
+func (a *LDAPAuth) bindService(conn *ldap.Conn) error {
+	var err error
+	if a.cfg.BindDN == "" {
+		err = conn.UnauthenticatedBind("")
+	} else {
+		err = conn.Bind(a.cfg.BindDN, a.cfg.BindPassword)
+	}
+	if err != nil {
+		return fmt.Errorf("binding to LDAP as %q: %w", a.cfg.BindDN, err)
+	}
+	return nil
+}
+
+// groups lists the names of the groups of the user with the given DN.
+func (a *LDAPAuth) groups(conn *ldap.Conn, userID, userDN string) ([]string, error) {
+	filter := strings.NewReplacer(
+		"{dn}", ldap.EscapeFilter(userDN),
+		"{user}", ldap.EscapeFilter(userID),
+	).Replace(a.cfg.GroupFilter)
+	res, err := conn.Search(ldap.NewSearchRequest(
+		a.cfg.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, 0, false, filter, []string{a.cfg.GroupAttribute}, nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("searching LDAP groups: %w", err)
+	}
+	var groups []string
+	for _, e := range res.Entries {
+		if name := e.GetAttributeValue(a.cfg.GroupAttribute); name != "" && !slices.Contains(groups, name) {
+			groups = append(groups, name)
+		}
+	}
+	return groups, nil
+}
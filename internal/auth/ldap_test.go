@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tokuhirom/dashyard/internal/auth/ldaptest"
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+const serviceDN = "cn=dashyard,ou=services,dc=example,dc=com"
+
+// testDirectory is a directory with the users alice (sre, developers) and
+// bob (sales), and a service account to search it.
+var testDirectory = []ldaptest.Entry{
+	{DN: serviceDN, Password: "service-secret"},
+	{
+		DN:         "uid=alice,ou=people,dc=example,dc=com",
+		Attributes: map[string][]string{"uid": {"alice"}, "mail": {"alice@example.com"}, "objectClass": {"inetOrgPerson"}},
+		Password:   "alice-secret",
+	},
+	{
+		DN:         "uid=bob,ou=people,dc=example,dc=com",
+		Attributes: map[string][]string{"uid": {"bob"}, "mail": {"bob@example.com"}, "objectClass": {"inetOrgPerson"}},
+		Password:   "bob-secret",
+	},
+	{
+		DN:         "cn=sre,ou=groups,dc=example,dc=com",
+		Attributes: map[string][]string{"cn": {"sre"}, "member": {"uid=alice,ou=people,dc=example,dc=com"}},
+	},
+	{
+		DN:         "cn=developers,ou=groups,dc=example,dc=com",
+		Attributes: map[string][]string{"cn": {"developers"}, "member": {"uid=alice,ou=people,dc=example,dc=com"}},
+	},
+	{
+		DN:         "cn=sales,ou=groups,dc=example,dc=com",
+		Attributes: map[string][]string{"cn": {"sales"}, "member": {"uid=bob,ou=people,dc=example,dc=com"}},
+	},
+}
+
+// testLDAPConfig returns the config for the directory at url, with the
+// defaults that config.Parse fills in.
+func testLDAPConfig(url string) config.LDAPConfig {
+	return config.LDAPConfig{
+		URL:            url,
+		BindDN:         serviceDN,
+		BindPassword:   "service-secret",
+		BaseDN:         "ou=people,dc=example,dc=com",
+		UserFilter:     "(uid={user})",
+		GroupBaseDN:    "ou=groups,dc=example,dc=com",
+		GroupFilter:    "(member={dn})",
+		GroupAttribute: "cn",
+		Timeout:        5 * time.Second,
+	}
+}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	srv := ldaptest.NewServer(testDirectory)
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		modify     func(*config.LDAPConfig)
+		user       string
+		password   string
+		wantGroups []string
+		wantErr    error
+	}{
+		{"valid password", nil, "alice", "alice-secret", []string{"sre", "developers"}, nil},
+		{"wrong password", nil, "alice", "bob-secret", nil, ErrInvalidCredentials},
+		{"empty password", nil, "alice", "", nil, ErrInvalidCredentials},
+		{"unknown user", nil, "carol", "carol-secret", nil, ErrInvalidCredentials},
+		{"filter injection", nil, "*", "alice-secret", nil, ErrInvalidCredentials},
+		{"in allowed groups", func(c *config.LDAPConfig) { c.AllowedGroups = []string{"sre"} }, "alice", "alice-secret", []string{"sre", "developers"}, nil},
+		{"not in allowed groups", func(c *config.LDAPConfig) { c.AllowedGroups = []string{"sre"} }, "bob", "bob-secret", nil, ErrInvalidCredentials},
+		{"custom filter", func(c *config.LDAPConfig) { c.UserFilter = "(&(objectClass=inetOrgPerson)(mail={user}))" }, "bob@example.com", "bob-secret", []string{"sales"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testLDAPConfig(srv.URL)
+			if tt.modify != nil {
+				tt.modify(&cfg)
+			}
+			a, err := NewLDAPAuth(cfg, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			groups, err := a.Authenticate(tt.user, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !slices.Equal(groups, tt.wantGroups) {
+				t.Errorf("expected groups %v, got %v", tt.wantGroups, groups)
+			}
+		})
+	}
+}
+
+func TestLDAPAuthenticateUsedGroups(t *testing.T) {
+	srv := ldaptest.NewServer(testDirectory)
+	defer srv.Close()
+
+	cfg := testLDAPConfig(srv.URL)
+	cfg.AllowedGroups = []string{"developers"}
+	a, err := NewLDAPAuth(cfg, func(name string) bool { return name == "sre" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The allowed groups are kept along with the used ones.
+	groups, err := a.Authenticate("alice", "alice-secret")
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if !slices.Equal(groups, []string{"sre", "developers"}) {
+		t.Errorf("expected groups [sre developers], got %v", groups)
+	}
+
+	cfg.AllowedGroups = nil
+	a, err = NewLDAPAuth(cfg, func(name string) bool { return name == "sre" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, err = a.Authenticate("alice", "alice-secret")
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if !slices.Equal(groups, []string{"sre"}) {
+		t.Errorf("expected groups [sre], got %v", groups)
+	}
+}
+
+func TestLDAPAuthenticateWrongServicePassword(t *testing.T) {
+	srv := ldaptest.NewServer(testDirectory)
+	defer srv.Close()
+
+	cfg := testLDAPConfig(srv.URL)
+	cfg.BindPassword = "wrong"
+	a, err := NewLDAPAuth(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A misconfigured service account is a server error, not bad credentials.
+	if _, err := a.Authenticate("alice", "alice-secret"); err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected a bind error, got %v", err)
+	}
+}
+
+func TestLDAPAuthenticateStartTLS(t *testing.T) {
+	srv := ldaptest.NewStartTLSServer(testDirectory)
+	defer srv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, srv.CertificatePEM(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testLDAPConfig(srv.URL)
+	cfg.StartTLS = true
+	cfg.CAFile = caFile
+	a, err := NewLDAPAuth(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, err := a.Authenticate("bob", "bob-secret")
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if !slices.Equal(groups, []string{"sales"}) {
+		t.Errorf("expected groups [sales], got %v", groups)
+	}
+	if want := []string{serviceDN, "uid=bob,ou=people,dc=example,dc=com", serviceDN}; !slices.Equal(srv.Binds(), want) {
+		t.Errorf("expected binds %v, got %v", want, srv.Binds())
+	}
+
+	// Without StartTLS the server refuses to bind.
+	cfg.StartTLS = false
+	a, err = NewLDAPAuth(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Authenticate("bob", "bob-secret"); err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected a bind error without StartTLS, got %v", err)
+	}
+}
+
+func TestLDAPAuthenticateUntrustedCertificate(t *testing.T) {
+	srv := ldaptest.NewStartTLSServer(testDirectory)
+	defer srv.Close()
+
+	cfg := testLDAPConfig(srv.URL)
+	cfg.StartTLS = true
+	a, err := NewLDAPAuth(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Authenticate("bob", "bob-secret"); err == nil {
+		t.Error("expected a certificate verification error")
+	}
+}
+
+func TestNewLDAPAuthInvalidCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testLDAPConfig("ldap://127.0.0.1:389")
+	cfg.CAFile = caFile
+	if _, err := NewLDAPAuth(cfg, nil); err == nil {
+		t.Error("expected an error for a CA file without certificates")
+	}
+}
@@ -0,0 +1,373 @@
+// Package ldaptest provides an in-process LDAP server for tests, in the
+// spirit of net/http/httptest. It implements just enough of LDAPv3 for
+// Dashyard's LDAP login: simple binds, searches with and, or, not, equality
+// and presence filters, and StartTLS.
+package ldaptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// LDAP protocol operations and result codes used by the server.
+const (
+	opBindRequest      = 0
+	opBindResponse     = 1
+	opUnbindRequest    = 2
+	opSearchRequest    = 3
+	opSearchEntry      = 4
+	opSearchDone       = 5
+	opExtendedRequest  = 23
+	opExtendedResponse = 24
+
+	resultSuccess                 = 0
+	resultProtocolError           = 2
+	resultSizeLimitExceeded       = 4
+	resultConfidentialityRequired = 13
+	resultInvalidCredentials      = 49
+	resultInsufficientAccess      = 50
+
+	startTLSOID = "1.3.6.1.4.1.1466.20037"
+)
+
+// Entry is an entry of the directory. Password, if set, is the password that
+// binding as the entry takes.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+	Password   string
+}
+
+// Server is an LDAP server listening on a local address.
+type Server struct {
+	// URL is the ldap:// URL of the server.
+	URL string
+
+	entries   []Entry
+	ln        net.Listener
+	tlsConfig *tls.Config
+	certPEM   []byte
+
+	mu    sync.Mutex
+	binds []string
+	wg    sync.WaitGroup
+}
+
+// NewServer starts a server holding entries. Searching requires a bind as
+// one of them.
+func NewServer(entries []Entry) *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("ldaptest: failed to listen: " + err.Error())
+	}
+	s := &Server{URL: "ldap://" + ln.Addr().String(), entries: entries, ln: ln}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// NewStartTLSServer starts a server like NewServer that requires StartTLS
+// before binding. Its self-signed certificate, for 127.0.0.1, is
+// CertificatePEM.
+func NewStartTLSServer(entries []Entry) *Server {
+	cert, certPEM := selfSignedCert()
+	s := NewServer(entries)
+	s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	s.certPEM = certPEM
+	return s
+}
+
+// CertificatePEM returns the PEM-encoded certificate of a StartTLS server.
+func (s *Server) CertificatePEM() []byte {
+	return s.certPEM
+}
+
+// Binds returns the DNs of the successful binds so far.
+func (s *Server) Binds() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.binds...)
+}
+
+// Close stops the server.
+func (s *Server) Close() {
+	_ = s.ln.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle serves the requests of a connection until it is closed.
+func (s *Server) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	var (
+		bound  string
+		secure bool
+	)
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		if len(packet.Children) < 2 {
+			return
+		}
+		id, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case opBindRequest:
+			if s.tlsConfig != nil && !secure {
+				s.write(conn, result(id, opBindResponse, resultConfidentialityRequired, "StartTLS is required"))
+				continue
+			}
+			dn, code := s.bind(op)
+			if code == resultSuccess {
+				bound = dn
+			} else {
+				bound = ""
+			}
+			s.write(conn, result(id, opBindResponse, code, ""))
+
+		case opUnbindRequest:
+			return
+
+		case opSearchRequest:
+			if bound == "" {
+				s.write(conn, result(id, opSearchDone, resultInsufficientAccess, "bind required"))
+				continue
+			}
+			s.search(conn, id, op)
+
+		case opExtendedRequest:
+			if len(op.Children) == 0 || op.Children[0].Data.String() != startTLSOID || s.tlsConfig == nil || secure {
+				s.write(conn, result(id, opExtendedResponse, resultProtocolError, "unsupported extended operation"))
+				continue
+			}
+			s.write(conn, result(id, opExtendedResponse, resultSuccess, ""))
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			secure = true
+
+		default:
+			s.write(conn, result(id, opExtendedResponse, resultProtocolError, "unsupported operation"))
+		}
+	}
+}
+
+func (s *Server) write(conn net.Conn, p *ber.Packet) {
+	_, _ = conn.Write(p.Bytes())
+}
+
+// bind checks a simple bind request and returns the DN bound to, empty for
+// an anonymous bind.
+func (s *Server) bind(op *ber.Packet) (string, int64) {
+	if len(op.Children) < 3 || op.Children[2].Tag != 0 {
+		return "", resultProtocolError
+	}
+	dn := op.Children[1].Data.String()
+	password := op.Children[2].Data.String()
+	if dn == "" && password == "" {
+		return "", resultSuccess
+	}
+	for _, e := range s.entries {
+		if strings.EqualFold(e.DN, dn) && e.Password != "" && e.Password == password {
+			s.mu.Lock()
+			s.binds = append(s.binds, e.DN)
+			s.mu.Unlock()
+			return e.DN, resultSuccess
+		}
+	}
+	return "", resultInvalidCredentials
+}
+
+// search answers a search request with the matching entries.
+func (s *Server) search(conn net.Conn, id int64, op *ber.Packet) {
+	if len(op.Children) < 8 {
+		s.write(conn, result(id, opSearchDone, resultProtocolError, "malformed search request"))
+		return
+	}
+	base := op.Children[0].Data.String()
+	scope, _ := op.Children[1].Value.(int64)
+	sizeLimit, _ := op.Children[3].Value.(int64)
+	filter := op.Children[6]
+	var attrs []string
+	for _, a := range op.Children[7].Children {
+		attrs = append(attrs, a.Data.String())
+	}
+
+	sent := int64(0)
+	for _, e := range s.entries {
+		if !inScope(e.DN, base, scope) || !matches(e, filter) {
+			continue
+		}
+		if sizeLimit > 0 && sent == sizeLimit {
+			s.write(conn, result(id, opSearchDone, resultSizeLimitExceeded, ""))
+			return
+		}
+		s.write(conn, searchEntry(id, e, attrs))
+		sent++
+	}
+	s.write(conn, result(id, opSearchDone, resultSuccess, ""))
+}
+
+// inScope reports whether dn is within base at the search scope: the base
+// object (0), its children (1), or its whole subtree (2).
+func inScope(dn, base string, scope int64) bool {
+	dn, base = strings.ToLower(dn), strings.ToLower(base)
+	switch scope {
+	case 0:
+		return dn == base
+	case 1:
+		parent, ok := strings.CutSuffix(dn, ","+base)
+		return ok && !strings.Contains(parent, ",")
+	default:
+		return dn == base || strings.HasSuffix(dn, ","+base)
+	}
+}
+
+// matches evaluates a search filter against an entry. Values compare case
+// insensitively, as with the usual matching rules of names and DNs.
+func matches(e Entry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case 0: // and
+		for _, f := range filter.Children {
+			if !matches(e, f) {
+				return false
+			}
+		}
+		return true
+	case 1: // or
+		for _, f := range filter.Children {
+			if matches(e, f) {
+				return true
+			}
+		}
+		return false
+	case 2: // not
+		return len(filter.Children) == 1 && !matches(e, filter.Children[0])
+	case 3: // equalityMatch
+		if len(filter.Children) != 2 {
+			return false
+		}
+		want := filter.Children[1].Data.String()
+		for _, v := range attribute(e, filter.Children[0].Data.String()) {
+			if strings.EqualFold(v, want) {
+				return true
+			}
+		}
+		return false
+	case 7: // present
+		return len(attribute(e, filter.Data.String())) > 0
+	default:
+		return false
+	}
+}
+
+func attribute(e Entry, name string) []string {
+	for k, v := range e.Attributes {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+func result(id int64, op ber.Tag, code int64, message string) *ber.Packet {
+	p := envelope(id)
+	r := ber.Encode(ber.ClassApplication, ber.TypeConstructed, op, nil, "")
+	r.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+	r.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	r.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, message, ""))
+	p.AppendChild(r)
+	return p
+}
+
+// searchEntry encodes an entry with the requested attributes, or all of them
+// if none are requested.
+func searchEntry(id int64, e Entry, attrs []string) *ber.Packet {
+	p := envelope(id)
+	r := ber.Encode(ber.ClassApplication, ber.TypeConstructed, opSearchEntry, nil, "")
+	r.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, ""))
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for name, values := range e.Attributes {
+		if len(attrs) > 0 && !containsFold(attrs, name) {
+			continue
+		}
+		a := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		a.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, v := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+		}
+		a.AppendChild(set)
+		list.AppendChild(a)
+	}
+	r.AppendChild(list)
+	p.AppendChild(r)
+	return p
+}
+
+func envelope(id int64) *ber.Packet {
+	p := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+	return p
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// selfSignedCert creates a certificate for 127.0.0.1 that is its own CA.
+func selfSignedCert() (tls.Certificate, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic("ldaptest: failed to generate key: " + err.Error())
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ldaptest"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		panic("ldaptest: failed to create certificate: " + err.Error())
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -1,17 +1,65 @@
 package auth
 
 import (
+	"log/slog"
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 )
 
-const userIDKey = "user_id"
+const (
+	userIDKey = "user_id"
+	groupsKey = "provider_groups"
+)
 
 // AuthMiddleware returns a Gin middleware that requires a valid session.
 // It sets the user_id in the Gin context on success.
-func AuthMiddleware(sm *SessionManager) gin.HandlerFunc {
+//
+// If proxy is not nil, requests from a trusted proxy that carry a user header
+// are authenticated by it instead, without a password: the middleware creates
+// or updates the session of that user, so that the session cookie reflects the
+// identity the proxy last asserted.
+//
+// If tokens is not nil, other requests with an "Authorization: Bearer" header
+// are authenticated by that API token alone, without a session, as the user
+// TokenUserPrefix + name and limited to the scopes of the token.
+func AuthMiddleware(sm *SessionManager, proxy *ProxyAuth, tokens *TokenAuth) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if proxy != nil {
+			if userID, groups, ok := proxy.Identity(c.Request); ok {
+				current, err := sm.ValidateSession(c.Request)
+				if err != nil || current != userID || !slices.Equal(sm.SessionGroups(c.Request), groups) {
+					if err := sm.CreateSessionWithGroups(c.Request, c.Writer, userID, groups); err != nil {
+						slog.Error("proxy session creation failed", "user", userID, "error", err)
+						c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+							"error": "session creation failed",
+						})
+						return
+					}
+				}
+				setIdentity(c, userID, groups)
+				c.Next()
+				return
+			}
+		}
+
+		if tokens != nil {
+			if bearer, ok := bearerToken(c.Request); ok {
+				token, err := tokens.Authenticate(bearer)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+						"error": err.Error(),
+					})
+					return
+				}
+				setIdentity(c, TokenUserPrefix+token.Name, nil)
+				c.Set(tokenScopesKey, token.Scopes)
+				c.Next()
+				return
+			}
+		}
+
 		userID, err := sm.ValidateSession(c.Request)
 		if err != nil {
 			// Clear the invalid/corrupt session cookie so re-login works cleanly
@@ -21,14 +69,29 @@ func AuthMiddleware(sm *SessionManager) gin.HandlerFunc {
 			})
 			return
 		}
-		c.Set(userIDKey, userID)
+		setIdentity(c, userID, sm.SessionGroups(c.Request))
 		c.Next()
 	}
 }
 
+func setIdentity(c *gin.Context, userID string, groups []string) {
+	c.Set(userIDKey, userID)
+	if len(groups) > 0 {
+		c.Set(groupsKey, groups)
+	}
+}
+
 // GetUserID retrieves the authenticated user ID from the Gin context.
 func GetUserID(c *gin.Context) string {
 	v, _ := c.Get(userIDKey)
 	s, _ := v.(string)
 	return s
 }
+
+// GetProviderGroups retrieves the groups that the identity provider, such as
+// an authenticating proxy, reported for the authenticated user.
+func GetProviderGroups(c *gin.Context) []string {
+	v, _ := c.Get(groupsKey)
+	groups, _ := v.([]string)
+	return groups
+}
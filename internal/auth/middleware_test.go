@@ -31,7 +31,7 @@ func TestAuthMiddlewareValid(t *testing.T) {
 	sm := NewSessionManager("test-secret-that-is-32bytes!!", false)
 
 	router := gin.New()
-	router.Use(AuthMiddleware(sm))
+	router.Use(AuthMiddleware(sm, nil, nil))
 	router.GET("/test", func(c *gin.Context) {
 		userID := GetUserID(c)
 		c.JSON(http.StatusOK, gin.H{"user_id": userID})
@@ -61,7 +61,7 @@ func TestAuthMiddlewareNoCookie(t *testing.T) {
 	sm := NewSessionManager("test-secret-that-is-32bytes!!", false)
 
 	router := gin.New()
-	router.Use(AuthMiddleware(sm))
+	router.Use(AuthMiddleware(sm, nil, nil))
 	router.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	})
@@ -99,7 +99,7 @@ func TestAuthMiddlewareInvalidCookie(t *testing.T) {
 	sm := NewSessionManager("test-secret-that-is-32bytes!!", false)
 
 	router := gin.New()
-	router.Use(AuthMiddleware(sm))
+	router.Use(AuthMiddleware(sm, nil, nil))
 	router.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	})
@@ -135,7 +135,7 @@ func TestAuthMiddlewareWrongSecret(t *testing.T) {
 
 	// Try to use it with instance 2
 	router := gin.New()
-	router.Use(AuthMiddleware(sm2))
+	router.Use(AuthMiddleware(sm2, nil, nil))
 	router.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	})
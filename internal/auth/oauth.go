@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"slices"
 	"strings"
 
@@ -13,7 +14,8 @@ import (
 )
 
 // InitGothProviders configures goth with the OAuth providers from config.
-func InitGothProviders(providers []config.OAuthProviderConfig) {
+// OIDC providers fetch their discovery document, which may fail.
+func InitGothProviders(providers []config.OAuthProviderConfig) error {
 	var gothProviders []goth.Provider
 	for _, p := range providers {
 		switch p.Provider {
@@ -21,6 +23,9 @@ func InitGothProviders(providers []config.OAuthProviderConfig) {
 			scopes := p.Scopes
 			if len(scopes) == 0 {
 				scopes = []string{"user:email"}
+				if len(p.AllowedTeams) > 0 {
+					scopes = append(scopes, "read:org")
+				}
 			}
 			var gp *github.Provider
 			if p.BaseURL != "" {
@@ -34,22 +39,52 @@ func InitGothProviders(providers []config.OAuthProviderConfig) {
 				gp = github.New(p.ClientID, p.ClientSecret, p.RedirectURL, scopes...)
 			}
 			gothProviders = append(gothProviders, gp)
+		case "gitlab":
+			gothProviders = append(gothProviders, newGitLabProvider(p))
+		case "google":
+			if p.DiscoveryURL == "" {
+				p.DiscoveryURL = googleDiscoveryURL
+			}
+			op, err := newOIDCProvider(p)
+			if err != nil {
+				return err
+			}
+			gothProviders = append(gothProviders, op)
+		case "oidc":
+			op, err := newOIDCProvider(p)
+			if err != nil {
+				return err
+			}
+			gothProviders = append(gothProviders, op)
 		}
 	}
 	goth.UseProviders(gothProviders...)
+	return nil
+}
+
+// OAuthUserID returns the Dashyard user ID of a goth user: the login name
+// (GitHub and GitLab username, OIDC preferred_username) or else the email
+// address, which is what identifies Google users.
+func OAuthUserID(user goth.User) string {
+	if user.NickName != "" {
+		return user.NickName
+	}
+	return user.Email
 }
 
 // CheckUserAllowed checks whether a goth user is permitted by the provider's allowlist.
-// If no allowed_users and no allowed_orgs are configured, all authenticated users are allowed.
+// If no allowed_users, allowed_orgs, allowed_teams, allowed_groups or allowed_domains
+// are configured, all authenticated users are allowed.
 func CheckUserAllowed(user goth.User, providerCfg config.OAuthProviderConfig) (bool, error) {
-	hasRestrictions := len(providerCfg.AllowedUsers) > 0 || len(providerCfg.AllowedOrgs) > 0
+	hasRestrictions := len(providerCfg.AllowedUsers) > 0 || len(providerCfg.AllowedOrgs) > 0 ||
+		len(providerCfg.AllowedTeams) > 0 || len(providerCfg.AllowedGroups) > 0 || len(providerCfg.AllowedDomains) > 0
 
 	if !hasRestrictions {
 		return true, nil
 	}
 
 	// Check allowed users
-	if slices.Contains(providerCfg.AllowedUsers, user.NickName) {
+	if slices.Contains(providerCfg.AllowedUsers, OAuthUserID(user)) {
 		return true, nil
 	}
 
@@ -66,45 +101,143 @@ func CheckUserAllowed(user goth.User, providerCfg config.OAuthProviderConfig) (b
 		}
 	}
 
+	// Check allowed teams (GitHub-specific)
+	if len(providerCfg.AllowedTeams) > 0 && providerCfg.Provider == "github" {
+		teams, err := gitHubTeams.teams(user, providerCfg.BaseURL, providerCfg.TeamCacheTTL)
+		if err != nil {
+			return false, fmt.Errorf("fetching GitHub teams: %w", err)
+		}
+		for _, team := range teams {
+			// Organization logins are case-insensitive.
+			if slices.ContainsFunc(providerCfg.AllowedTeams, func(allowed string) bool { return strings.EqualFold(allowed, team) }) {
+				return true, nil
+			}
+		}
+	}
+
+	// Check allowed groups (OIDC groups claim)
+	if len(providerCfg.AllowedGroups) > 0 && providerCfg.Provider == "oidc" {
+		for _, group := range oidcGroups(user, providerCfg.GroupsClaim) {
+			if slices.Contains(providerCfg.AllowedGroups, group) {
+				return true, nil
+			}
+		}
+	}
+
+	// Check allowed groups (GitLab-specific)
+	if len(providerCfg.AllowedGroups) > 0 && providerCfg.Provider == "gitlab" {
+		groups, err := FetchGitLabGroups(user.AccessToken, providerCfg.BaseURL)
+		if err != nil {
+			return false, fmt.Errorf("fetching GitLab groups: %w", err)
+		}
+		for _, group := range groups {
+			if slices.Contains(providerCfg.AllowedGroups, group) {
+				return true, nil
+			}
+		}
+	}
+
+	// Check allowed domains (Google Workspace hd claim)
+	if len(providerCfg.AllowedDomains) > 0 && providerCfg.Provider == "google" {
+		if hd, _ := user.RawData["hd"].(string); hd != "" && slices.Contains(providerCfg.AllowedDomains, hd) {
+			return true, nil
+		}
+	}
+
 	return false, nil
 }
 
 // FetchGitHubOrgs retrieves the list of organization login names for the authenticated user.
 // If baseURL is set, it uses the GitHub Enterprise API endpoint instead of api.github.com.
 func FetchGitHubOrgs(accessToken string, baseURL string) ([]string, error) {
-	orgsURL := "https://api.github.com/user/orgs"
-	if baseURL != "" {
-		orgsURL = strings.TrimRight(baseURL, "/") + "/api/v3/user/orgs"
-	}
-	req, err := http.NewRequest("GET", orgsURL, nil)
+	var names []string
+	err := fetchPages(gitHubAPIURL(baseURL)+"/user/orgs?per_page=100", gitHubAuth(accessToken), func(resp *http.Response) error {
+		var orgs []struct {
+			Login string `json:"login"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&orgs); err != nil {
+			return err
+		}
+		for _, o := range orgs {
+			names = append(names, o.Login)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("GitHub API: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	return names, nil
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+// gitHubAPIURL returns the REST API root of github.com or, if baseURL is set,
+// of a GitHub Enterprise instance.
+func gitHubAPIURL(baseURL string) string {
+	if baseURL == "" {
+		return "https://api.github.com"
 	}
-	defer func() { _ = resp.Body.Close() }()
+	return strings.TrimRight(baseURL, "/") + "/api/v3"
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+// gitHubAuth prepares GitHub API requests with the access token of a user.
+func gitHubAuth(accessToken string) func(*http.Request) {
+	return func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
 	}
+}
 
-	var orgs []struct {
-		Login string `json:"login"`
+// fetchPages GETs pageURL and the pages that follow it through the
+// rel="next" links of the Link header, as GitHub and GitLab paginate their
+// APIs, passing each successful response to decode. Links to other hosts are
+// not followed, so that the credentials set by prepare stay with the API.
+func fetchPages(pageURL string, prepare func(*http.Request), decode func(*http.Response) error) error {
+	first, err := url.Parse(pageURL)
+	if err != nil {
+		return err
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&orgs); err != nil {
-		return nil, err
+	for pageURL != "" {
+		req, err := http.NewRequest("GET", pageURL, nil)
+		if err != nil {
+			return err
+		}
+		prepare(req)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		err = decode(resp)
+		_ = resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		pageURL = ""
+		if next, err := url.Parse(nextLink(resp.Header.Get("Link"))); err == nil && next.Host == first.Host && next.Scheme == first.Scheme {
+			pageURL = next.String()
+		}
 	}
+	return nil
+}
 
-	names := make([]string, len(orgs))
-	for i, o := range orgs {
-		names[i] = o.Login
+// nextLink returns the rel="next" URL of a Link header, or "".
+func nextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.ReplaceAll(strings.TrimSpace(param), " ", "") == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
 	}
-	return names, nil
+	return ""
 }
 
 // FindOAuthProvider finds a provider config by provider name.
@@ -54,6 +54,83 @@ func TestCheckUserDeniedByUsername(t *testing.T) {
 	}
 }
 
+func TestCheckUserAllowedByGroup(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		claim  string
+		want   bool
+	}{
+		{"list claim", map[string]interface{}{"groups": []interface{}{"dev", "sre"}}, "", true},
+		{"string claim", map[string]interface{}{"groups": "sre"}, "", true},
+		{"other groups", map[string]interface{}{"groups": []interface{}{"dev"}}, "", false},
+		{"missing claim", map[string]interface{}{}, "", false},
+		{"custom claim", map[string]interface{}{"roles": []interface{}{"sre"}, "groups": []interface{}{"dev"}}, "roles", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := goth.User{NickName: "someone", RawData: tt.claims}
+			cfg := config.OAuthProviderConfig{
+				Provider:      "oidc",
+				GroupsClaim:   tt.claim,
+				AllowedGroups: []string{"sre"},
+			}
+			allowed, err := CheckUserAllowed(user, cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if allowed != tt.want {
+				t.Errorf("expected allowed=%v, got %v", tt.want, allowed)
+			}
+		})
+	}
+}
+
+func TestCheckUserAllowedByDomain(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		want   bool
+	}{
+		{"allowed domain", map[string]interface{}{"hd": "example.com"}, true},
+		{"other domain", map[string]interface{}{"hd": "other.example"}, false},
+		{"consumer account", map[string]interface{}{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := goth.User{Email: "someone@example.com", RawData: tt.claims}
+			cfg := config.OAuthProviderConfig{
+				Provider:       "google",
+				AllowedDomains: []string{"example.com"},
+			}
+			allowed, err := CheckUserAllowed(user, cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if allowed != tt.want {
+				t.Errorf("expected allowed=%v, got %v", tt.want, allowed)
+			}
+		})
+	}
+}
+
+func TestCheckUserAllowedByEmail(t *testing.T) {
+	// Google users have no nickname; allowed_users lists their email.
+	user := goth.User{Email: "someone@example.com"}
+	cfg := config.OAuthProviderConfig{
+		Provider:     "google",
+		AllowedUsers: []string{"someone@example.com"},
+	}
+
+	allowed, err := CheckUserAllowed(user, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !allowed {
+		t.Error("expected user to be allowed by email")
+	}
+}
+
 func TestInitGothProviders(t *testing.T) {
 	providers := []config.OAuthProviderConfig{
 		{
@@ -65,7 +142,9 @@ func TestInitGothProviders(t *testing.T) {
 	}
 
 	// Should not panic
-	InitGothProviders(providers)
+	if err := InitGothProviders(providers); err != nil {
+		t.Fatalf("InitGothProviders failed: %v", err)
+	}
 
 	// Verify provider was registered
 	p, err := goth.GetProvider("github")
@@ -89,7 +168,9 @@ func TestInitGothProvidersWithBaseURL(t *testing.T) {
 	}
 
 	// Should not panic
-	InitGothProviders(providers)
+	if err := InitGothProviders(providers); err != nil {
+		t.Fatalf("InitGothProviders failed: %v", err)
+	}
 
 	// Verify provider was registered
 	p, err := goth.GetProvider("github")
@@ -245,3 +326,20 @@ func TestFindOAuthProvider(t *testing.T) {
 		t.Error("expected nil for unknown provider")
 	}
 }
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{`<https://api.example/x?page=2>; rel="next"`, "https://api.example/x?page=2"},
+		{`<https://api.example/x?page=1>; rel="prev", <https://api.example/x?page=3>; rel="next"`, "https://api.example/x?page=3"},
+		{`<https://api.example/x?page=9>; rel="last"`, ""},
+	}
+	for _, tt := range tests {
+		if got := nextLink(tt.header); got != tt.want {
+			t.Errorf("nextLink(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/openidConnect"
+	"github.com/tokuhirom/dashyard/internal/config"
+	"golang.org/x/oauth2"
+)
+
+// defaultGroupsClaim is the claim that lists the groups of an OIDC user
+// unless groups_claim says otherwise, as Keycloak's and Dex's group mappers
+// name it.
+const defaultGroupsClaim = "groups"
+
+// googleDiscoveryURL is the discovery document of Google's OpenID Connect
+// issuer, through which the google provider signs users in.
+const googleDiscoveryURL = "https://accounts.google.com/.well-known/openid-configuration"
+
+// newOIDCProvider creates the goth provider for an OpenID Connect issuer. The
+// discovery document is fetched right away, so a wrong discovery_url is
+// reported at startup rather than at the first login.
+func newOIDCProvider(p config.OAuthProviderConfig) (goth.Provider, error) {
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+	op, err := openidConnect.New(p.ClientID, p.ClientSecret, p.RedirectURL, p.DiscoveryURL, scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("fetching OIDC discovery document %s: %w", p.DiscoveryURL, err)
+	}
+	op.SetName(p.Provider)
+	return &pkceProvider{Provider: op}, nil
+}
+
+// oidcGroups returns the groups listed by the groups claim of an OIDC user,
+// from the ID token or the userinfo response. The claim may hold a list of
+// strings or a single string.
+func oidcGroups(user goth.User, claim string) []string {
+	if claim == "" {
+		claim = defaultGroupsClaim
+	}
+	switch v := user.RawData[claim].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		groups := make([]string, 0, len(v))
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+		return groups
+	}
+	return nil
+}
+
+// pkceProvider adds PKCE (RFC 7636) to the authorization code flow of an
+// OpenID Connect provider: each flow gets its own code verifier, kept in the
+// goth session, and the authorization URL carries its S256 challenge.
+type pkceProvider struct {
+	*openidConnect.Provider
+}
+
+// pkceSession is an OpenID Connect session along with its code verifier.
+type pkceSession struct {
+	*openidConnect.Session
+	Verifier string
+}
+
+// BeginAuth returns a session whose authorization URL has the code challenge.
+func (p *pkceProvider) BeginAuth(state string) (goth.Session, error) {
+	sess, err := p.Provider.BeginAuth(state)
+	if err != nil {
+		return nil, err
+	}
+	s := sess.(*openidConnect.Session)
+	u, err := url.Parse(s.AuthURL)
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+	q := u.Query()
+	q.Set("code_challenge", oauth2.S256ChallengeFromVerifier(verifier))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	s.AuthURL = u.String()
+	return &pkceSession{Session: s, Verifier: verifier}, nil
+}
+
+// FetchUser fetches the user of the OpenID Connect session.
+func (p *pkceProvider) FetchUser(session goth.Session) (goth.User, error) {
+	return p.Provider.FetchUser(session.(*pkceSession).Session)
+}
+
+// UnmarshalSession restores a session stored by Marshal.
+func (p *pkceProvider) UnmarshalSession(data string) (goth.Session, error) {
+	s := &pkceSession{Session: &openidConnect.Session{}}
+	err := json.Unmarshal([]byte(data), s)
+	return s, err
+}
+
+// Authorize exchanges the authorization code along with the code verifier.
+func (s *pkceSession) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	return s.Session.Authorize(provider.(*pkceProvider).Provider, verifierParams{Params: params, verifier: s.Verifier})
+}
+
+// Marshal encodes the session, code verifier included.
+func (s *pkceSession) Marshal() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func (s *pkceSession) String() string {
+	return s.Marshal()
+}
+
+// verifierParams are the callback parameters with the code verifier of the
+// session, which the OpenID Connect session sends to the token endpoint.
+type verifierParams struct {
+	goth.Params
+	verifier string
+}
+
+func (p verifierParams) Get(key string) string {
+	if key == "code_verifier" {
+		return p.verifier
+	}
+	return p.Params.Get(key)
+}
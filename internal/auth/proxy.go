@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+// ProxyAuth authenticates requests by the identity that an authenticating
+// reverse proxy, such as oauth2-proxy behind Traefik, passes in headers. The
+// headers are trusted only on requests whose remote address is one of the
+// trusted proxies, since anyone else could set them.
+type ProxyAuth struct {
+	userHeader   string
+	groupsHeader string
+	trusted      []netip.Prefix
+}
+
+// NewProxyAuth creates a ProxyAuth trusting the headers of cfg from the
+// trusted proxies, given as IP addresses or CIDR ranges.
+func NewProxyAuth(cfg config.ProxyAuthConfig, trustedProxies []string) (*ProxyAuth, error) {
+	p := &ProxyAuth{userHeader: cfg.UserHeader, groupsHeader: cfg.GroupsHeader}
+	for _, s := range trustedProxies {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(s)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		p.trusted = append(p.trusted, prefix.Masked())
+	}
+	return p, nil
+}
+
+// Identity returns the user ID and groups the headers of r carry. ok is false
+// when r does not come from a trusted proxy or has no user header.
+func (p *ProxyAuth) Identity(r *http.Request) (userID string, groups []string, ok bool) {
+	if !p.trustedRemote(r.RemoteAddr) {
+		return "", nil, false
+	}
+	userID = strings.TrimSpace(r.Header.Get(p.userHeader))
+	if userID == "" {
+		return "", nil, false
+	}
+	if p.groupsHeader != "" {
+		for _, g := range strings.Split(r.Header.Get(p.groupsHeader), ",") {
+			if g = strings.TrimSpace(g); g != "" && !slices.Contains(groups, g) {
+				groups = append(groups, g)
+			}
+		}
+	}
+	return userID, groups, true
+}
+
+// trustedRemote reports whether the remote address, host:port, is a trusted
+// proxy.
+func (p *ProxyAuth) trustedRemote(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range p.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
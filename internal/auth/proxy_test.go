@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+func newTestProxyAuth(t *testing.T) *ProxyAuth {
+	t.Helper()
+	p, err := NewProxyAuth(config.ProxyAuthConfig{
+		UserHeader:   "X-Forwarded-User",
+		GroupsHeader: "X-Forwarded-Groups",
+	}, []string{"10.0.0.0/8", "192.168.1.10", "fd00::/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProxyAuthIdentity(t *testing.T) {
+	p := newTestProxyAuth(t)
+	tests := []struct {
+		name       string
+		remoteAddr string
+		user       string
+		groups     string
+		wantOK     bool
+		wantUser   string
+		wantGroups []string
+	}{
+		{"trusted range", "10.1.2.3:4567", "alice", "", true, "alice", nil},
+		{"trusted address", "192.168.1.10:4567", "alice", "", true, "alice", nil},
+		{"trusted IPv6", "[fd00::1]:4567", "alice", "", true, "alice", nil},
+		{"IPv4-mapped IPv6", "[::ffff:10.1.2.3]:4567", "alice", "", true, "alice", nil},
+		{"untrusted address", "192.168.1.11:4567", "alice", "", false, "", nil},
+		{"no user header", "10.1.2.3:4567", "", "sre", false, "", nil},
+		{"groups", "10.1.2.3:4567", "alice", "sre, payments,,sre", true, "alice", []string{"sre", "payments"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.user != "" {
+				r.Header.Set("X-Forwarded-User", tt.user)
+			}
+			if tt.groups != "" {
+				r.Header.Set("X-Forwarded-Groups", tt.groups)
+			}
+			user, groups, ok := p.Identity(r)
+			if ok != tt.wantOK || user != tt.wantUser || !slices.Equal(groups, tt.wantGroups) {
+				t.Errorf("Identity() = %q, %v, %v; want %q, %v, %v", user, groups, ok, tt.wantUser, tt.wantGroups, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewProxyAuthInvalidTrustedProxy(t *testing.T) {
+	if _, err := NewProxyAuth(config.ProxyAuthConfig{UserHeader: "X-Forwarded-User"}, []string{"proxy.example"}); err == nil {
+		t.Error("expected an error for a trusted proxy that is not an address or range")
+	}
+}
+
+func TestAuthMiddlewareProxy(t *testing.T) {
+	sm := NewSessionManager("test-secret-that-is-32bytes!!", false)
+	router := gin.New()
+	router.Use(AuthMiddleware(sm, newTestProxyAuth(t), nil))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": GetUserID(c), "groups": GetProviderGroups(c)})
+	})
+
+	serve := func(remoteAddr, user, groups string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = remoteAddr
+		if user != "" {
+			req.Header.Set("X-Forwarded-User", user)
+			req.Header.Set("X-Forwarded-Groups", groups)
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The proxy's headers sign the user in and create a session.
+	w := serve("10.0.0.1:1234", "alice", "sre", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if want := `{"groups":["sre"],"user_id":"alice"}`; w.Body.String() != want {
+		t.Errorf("got %s, want %s", w.Body.String(), want)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "dashyard_session" {
+		t.Fatalf("expected a session cookie, got %v", cookies)
+	}
+
+	// The same identity does not rewrite the session.
+	if w := serve("10.0.0.1:1234", "alice", "sre", cookies); len(w.Result().Cookies()) != 0 {
+		t.Errorf("expected no new cookie for an unchanged identity, got %v", w.Result().Cookies())
+	}
+
+	// The session carries the identity on requests without the headers.
+	w = serve("10.0.0.1:1234", "", "", cookies)
+	if want := `{"groups":["sre"],"user_id":"alice"}`; w.Body.String() != want {
+		t.Errorf("session: got %s, want %s", w.Body.String(), want)
+	}
+
+	// Another identity from the proxy replaces the session.
+	w = serve("10.0.0.1:1234", "bob", "", cookies)
+	if want := `{"groups":null,"user_id":"bob"}`; w.Body.String() != want {
+		t.Errorf("switched user: got %s, want %s", w.Body.String(), want)
+	}
+	if len(w.Result().Cookies()) != 1 {
+		t.Errorf("expected the session to be replaced, got %v", w.Result().Cookies())
+	}
+
+	// Headers from anyone else are ignored.
+	if w := serve("203.0.113.5:1234", "alice", "sre", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("untrusted remote: expected 401, got %d", w.Code)
+	}
+}
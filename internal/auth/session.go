@@ -10,6 +10,7 @@ import (
 const (
 	sessionName   = "dashyard_session"
 	sessionUserID = "user_id"
+	sessionGroups = "groups"
 	sessionMaxAge = 86400 // 24 hours
 )
 
@@ -33,6 +34,13 @@ func NewSessionManager(secret string, secure bool) *SessionManager {
 
 // CreateSession saves a session with the given user ID.
 func (sm *SessionManager) CreateSession(r *http.Request, w http.ResponseWriter, userID string) error {
+	return sm.CreateSessionWithGroups(r, w, userID, nil)
+}
+
+// CreateSessionWithGroups saves a session with the given user ID and the
+// groups an identity provider reported for the user, which count along with
+// the groups of the config.
+func (sm *SessionManager) CreateSessionWithGroups(r *http.Request, w http.ResponseWriter, userID string, groups []string) error {
 	session, err := sm.store.Get(r, sessionName)
 	if err != nil {
 		// If the existing cookie is corrupt, create a fresh session
@@ -42,6 +50,11 @@ func (sm *SessionManager) CreateSession(r *http.Request, w http.ResponseWriter,
 		}
 	}
 	session.Values[sessionUserID] = userID
+	if len(groups) > 0 {
+		session.Values[sessionGroups] = groups
+	} else {
+		delete(session.Values, sessionGroups)
+	}
 	return session.Save(r, w)
 }
 
@@ -61,6 +74,16 @@ func (sm *SessionManager) ValidateSession(r *http.Request) (string, error) {
 	return userID, nil
 }
 
+// SessionGroups returns the identity provider groups stored in the session.
+func (sm *SessionManager) SessionGroups(r *http.Request) []string {
+	session, err := sm.store.Get(r, sessionName)
+	if err != nil {
+		return nil
+	}
+	groups, _ := session.Values[sessionGroups].([]string)
+	return groups
+}
+
 // ClearSession removes the session.
 func (sm *SessionManager) ClearSession(r *http.Request, w http.ResponseWriter) error {
 	session, err := sm.store.Get(r, sessionName)
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/metrics"
+)
+
+// Lockout scopes, as returned by LoginThrottle.Fail.
+const (
+	ScopeIP   = "ip"
+	ScopeUser = "user"
+)
+
+// sweepInterval is how often LoginThrottle forgets the failures of clients
+// and user IDs that stopped failing, so that attempts with made-up user IDs
+// do not grow it without bound.
+const sweepInterval = time.Minute
+
+// LoginThrottle slows down password guessing by delaying and then locking out
+// the client IPs and user IDs of failed logins, as set by
+// config.LoginRateLimitConfig. Every attempt that Allow lets through counts
+// towards the limits until it is finished with Fail, Succeed or Release, so
+// that concurrent attempts cannot get around them. A nil LoginThrottle allows
+// every attempt. It is safe for concurrent use.
+type LoginThrottle struct {
+	cfg config.LoginRateLimitConfig
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*throttleEntry // by scope and key, e.g. "ip:192.0.2.1"
+	lastSweep time.Time
+}
+
+// throttleEntry holds the recent failures of a client IP or user ID.
+type throttleEntry struct {
+	failures    int
+	pending     int
+	lastFailure time.Time
+	blockedTill time.Time
+}
+
+// NewLoginThrottle creates a LoginThrottle with the given limits.
+func NewLoginThrottle(cfg config.LoginRateLimitConfig) *LoginThrottle {
+	return &LoginThrottle{cfg: cfg, now: time.Now, entries: make(map[string]*throttleEntry)}
+}
+
+// Allow reports whether a login as userID from the client at ip may be
+// attempted now, and otherwise how long the client must wait. An allowed
+// attempt must be finished with Fail, Succeed or Release.
+func (t *LoginThrottle) Allow(ip, userID string) (time.Duration, bool) {
+	if t == nil {
+		return 0, true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	t.sweep(now)
+
+	ipEntry, userEntry := t.entry(ScopeIP, ip, now), t.entry(ScopeUser, userID, now)
+	wait := max(ipEntry.wait(now, t.cfg.MaxFailuresPerIP, t.cfg.Backoff),
+		userEntry.wait(now, t.cfg.MaxFailuresPerUser, t.cfg.Backoff))
+	if wait > 0 {
+		metrics.LoginFailuresTotal.WithLabelValues("throttled").Inc()
+		return wait, false
+	}
+	ipEntry.pending++
+	userEntry.pending++
+	return 0, true
+}
+
+// Fail finishes an attempt that failed on bad credentials. It returns the
+// scopes, ScopeIP or ScopeUser, that it locked out.
+func (t *LoginThrottle) Fail(ip, userID string) []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	metrics.LoginFailuresTotal.WithLabelValues("invalid_credentials").Inc()
+
+	var locked []string
+	if t.entry(ScopeIP, ip, now).fail(now, t.cfg.MaxFailuresPerIP, t.cfg) {
+		locked = append(locked, ScopeIP)
+	}
+	if t.entry(ScopeUser, userID, now).fail(now, t.cfg.MaxFailuresPerUser, t.cfg) {
+		locked = append(locked, ScopeUser)
+	}
+	for _, scope := range locked {
+		metrics.LoginLockoutsTotal.WithLabelValues(scope).Inc()
+	}
+	return locked
+}
+
+// Succeed finishes an attempt that succeeded, forgetting the failures of the
+// user ID. Those of the client IP are kept, so that an attacker with an
+// account of their own cannot reset them.
+func (t *LoginThrottle) Succeed(ip, userID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	t.entry(ScopeIP, ip, now).pending--
+	e := t.entry(ScopeUser, userID, now)
+	*e = throttleEntry{pending: e.pending - 1}
+}
+
+// Release finishes an attempt that neither succeeded nor failed, such as one
+// the directory server could not answer.
+func (t *LoginThrottle) Release(ip, userID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	t.entry(ScopeIP, ip, now).pending--
+	t.entry(ScopeUser, userID, now).pending--
+}
+
+// entry returns the entry of a client IP or user ID, creating it if needed
+// and forgetting failures older than the lockout.
+func (t *LoginThrottle) entry(scope, key string, now time.Time) *throttleEntry {
+	k := scope + ":" + key
+	e, ok := t.entries[k]
+	if !ok {
+		e = &throttleEntry{}
+		t.entries[k] = e
+	}
+	if e.failures > 0 && now.Sub(e.lastFailure) >= t.cfg.Lockout {
+		e.failures = 0
+	}
+	return e
+}
+
+// sweep drops the entries that hold nothing worth keeping.
+func (t *LoginThrottle) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < sweepInterval {
+		return
+	}
+	t.lastSweep = now
+	for k, e := range t.entries {
+		if e.pending <= 0 && now.Sub(e.lastFailure) >= t.cfg.Lockout && !now.Before(e.blockedTill) {
+			delete(t.entries, k)
+		}
+	}
+}
+
+// wait returns how long the next attempt must wait. Attempts in progress
+// count as failures against the limit; while they could reach it, the next
+// attempt must wait for them, which takes about retry.
+func (e *throttleEntry) wait(now time.Time, maxFailures int, retry time.Duration) time.Duration {
+	if d := e.blockedTill.Sub(now); d > 0 {
+		return d
+	}
+	if e.failures+e.pending >= maxFailures {
+		return retry
+	}
+	return 0
+}
+
+// fail records a failure, blocking further attempts for the backoff, or for
+// the lockout once the failures reach maxFailures. It reports whether it
+// locked the entry out.
+func (e *throttleEntry) fail(now time.Time, maxFailures int, cfg config.LoginRateLimitConfig) bool {
+	e.pending--
+	e.failures++
+	e.lastFailure = now
+	if e.failures >= maxFailures {
+		e.blockedTill = now.Add(cfg.Lockout)
+		return true
+	}
+	backoff := cfg.Backoff
+	for i := 1; i < e.failures && backoff < cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	e.blockedTill = now.Add(min(backoff, cfg.MaxBackoff))
+	return false
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+// newTestThrottle returns a LoginThrottle whose clock is *now.
+func newTestThrottle(now *time.Time) *LoginThrottle {
+	t := NewLoginThrottle(config.LoginRateLimitConfig{
+		MaxFailuresPerIP:   6,
+		MaxFailuresPerUser: 3,
+		Backoff:            time.Second,
+		MaxBackoff:         4 * time.Second,
+		Lockout:            time.Minute,
+	})
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+// failLogin makes an attempt that fails, which must be allowed.
+func failLogin(t *testing.T, th *LoginThrottle, ip, user string) []string {
+	t.Helper()
+	if wait, ok := th.Allow(ip, user); !ok {
+		t.Fatalf("expected attempt for %s from %s to be allowed, must wait %s", user, ip, wait)
+	}
+	return th.Fail(ip, user)
+}
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	th := newTestThrottle(&now)
+
+	if locked := failLogin(t, th, "192.0.2.1", "alice"); locked != nil {
+		t.Fatalf("expected no lockout, got %v", locked)
+	}
+	if wait, ok := th.Allow("192.0.2.1", "alice"); ok || wait != time.Second {
+		t.Fatalf("expected to wait 1s, got %s, %v", wait, ok)
+	}
+	// The wait applies to the user ID from any client.
+	if wait, ok := th.Allow("192.0.2.2", "alice"); ok || wait != time.Second {
+		t.Fatalf("expected to wait 1s from another IP, got %s, %v", wait, ok)
+	}
+	// And to the client for any user ID.
+	if wait, ok := th.Allow("192.0.2.1", "bob"); ok || wait != time.Second {
+		t.Fatalf("expected to wait 1s as another user, got %s, %v", wait, ok)
+	}
+
+	now = now.Add(time.Second)
+	failLogin(t, th, "192.0.2.1", "alice")
+	if wait, _ := th.Allow("192.0.2.1", "alice"); wait != 2*time.Second {
+		t.Errorf("expected the backoff to double to 2s, got %s", wait)
+	}
+}
+
+func TestLoginThrottleMaxBackoff(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	th := newTestThrottle(&now)
+
+	// Spread the failures over user IDs so that only the client backs off.
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+	for i, w := range want {
+		failLogin(t, th, "192.0.2.1", string(rune('a'+i)))
+		wait, _ := th.Allow("192.0.2.1", "other")
+		if wait != w {
+			t.Errorf("after failure %d: expected to wait %s, got %s", i+1, w, wait)
+		}
+		now = now.Add(wait)
+	}
+}
+
+func TestLoginThrottleLockout(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	th := newTestThrottle(&now)
+
+	var locked []string
+	for range 3 {
+		locked = failLogin(t, th, "192.0.2.1", "alice")
+		now = now.Add(10 * time.Second)
+	}
+	if !slices.Equal(locked, []string{ScopeUser}) {
+		t.Fatalf("expected the user to be locked out, got %v", locked)
+	}
+	if wait, ok := th.Allow("192.0.2.9", "alice"); ok || wait != 50*time.Second {
+		t.Errorf("expected to wait out the lockout for 50s, got %s, %v", wait, ok)
+	}
+
+	// After the lockout the failures are forgotten.
+	now = now.Add(50 * time.Second)
+	for range 2 {
+		if locked := failLogin(t, th, "192.0.2.2", "alice"); locked != nil {
+			t.Fatalf("expected no lockout, got %v", locked)
+		}
+		now = now.Add(10 * time.Second)
+	}
+}
+
+func TestLoginThrottleLockoutPerIP(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	th := newTestThrottle(&now)
+
+	var locked []string
+	for i := range 6 {
+		locked = failLogin(t, th, "192.0.2.1", string(rune('a'+i)))
+		now = now.Add(5 * time.Second)
+	}
+	if !slices.Equal(locked, []string{ScopeIP}) {
+		t.Fatalf("expected the client to be locked out, got %v", locked)
+	}
+	if _, ok := th.Allow("192.0.2.1", "zed"); ok {
+		t.Error("expected the client to be locked out for any user")
+	}
+	if _, ok := th.Allow("192.0.2.2", "zed"); !ok {
+		t.Error("expected other clients to be allowed")
+	}
+}
+
+func TestLoginThrottleSucceedResetsUser(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	th := newTestThrottle(&now)
+
+	failLogin(t, th, "192.0.2.1", "alice")
+	now = now.Add(time.Second)
+	failLogin(t, th, "192.0.2.2", "alice")
+	now = now.Add(2 * time.Second)
+	if _, ok := th.Allow("192.0.2.3", "alice"); !ok {
+		t.Fatal("expected attempt to be allowed")
+	}
+	th.Succeed("192.0.2.3", "alice")
+
+	// The next failure would have locked alice out without the success, and
+	// starts over from the first backoff.
+	if locked := failLogin(t, th, "192.0.2.4", "alice"); locked != nil {
+		t.Errorf("expected no lockout after a success, got %v", locked)
+	}
+	if wait, _ := th.Allow("192.0.2.5", "alice"); wait != time.Second {
+		t.Errorf("expected to wait 1s, got %s", wait)
+	}
+}
+
+func TestLoginThrottleConcurrentAttempts(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	th := newTestThrottle(&now)
+
+	// Attempts in progress count against the limit, so a burst of them
+	// cannot get more guesses than the lockout allows.
+	var mu sync.Mutex
+	allowed := 0
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := th.Allow("192.0.2.1", "alice"); ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 3 {
+		t.Errorf("expected 3 attempts to be allowed, got %d", allowed)
+	}
+
+	// Released attempts free their place.
+	th.Release("192.0.2.1", "alice")
+	if _, ok := th.Allow("192.0.2.1", "alice"); !ok {
+		t.Error("expected an attempt to be allowed after a release")
+	}
+}
+
+func TestLoginThrottleSweep(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	th := newTestThrottle(&now)
+
+	for i := range 5 {
+		ip := "192.0.2." + string(rune('1'+i))
+		if _, ok := th.Allow(ip, "nobody"+ip); !ok {
+			t.Fatal("expected attempt to be allowed")
+		}
+		th.Fail(ip, "nobody"+ip)
+	}
+	now = now.Add(2 * time.Minute)
+	th.Allow("198.51.100.1", "alice")
+	// Only the entries of the attempt in progress are left.
+	if len(th.entries) != 2 {
+		t.Errorf("expected 2 entries after the sweep, got %d", len(th.entries))
+	}
+}
+
+func TestLoginThrottleNil(t *testing.T) {
+	var th *LoginThrottle
+	if _, ok := th.Allow("192.0.2.1", "alice"); !ok {
+		t.Error("expected a nil throttle to allow every attempt")
+	}
+	if locked := th.Fail("192.0.2.1", "alice"); locked != nil {
+		t.Errorf("expected no lockout, got %v", locked)
+	}
+	th.Succeed("192.0.2.1", "alice")
+	th.Release("192.0.2.1", "alice")
+}
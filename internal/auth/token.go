@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/metrics"
+)
+
+const (
+	// tokenPrefix starts every generated token, so that leaked tokens are
+	// easy to recognize, e.g. by secret scanners.
+	tokenPrefix = "dashyard_"
+	// TokenUserPrefix starts the user ID of requests authenticated by an API
+	// token, followed by the token name, e.g. "token:wall-display". Label
+	// policies and access rules refer to tokens by this ID.
+	TokenUserPrefix = "token:"
+
+	tokenScopesKey = "token_scopes"
+)
+
+var (
+	errInvalidToken = errors.New("invalid token")
+	errExpiredToken = errors.New("token expired")
+)
+
+// GenerateToken returns a new random API token.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex-encoded SHA-256 hash of an API token, which is
+// what the config keeps. Tokens are random, so unlike passwords they need no
+// salt or slow hash.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenAuth authenticates requests by the API tokens of the config, sent as
+// "Authorization: Bearer <token>".
+type TokenAuth struct {
+	tokens map[string]config.APIToken // by token hash
+	now    func() time.Time
+}
+
+// NewTokenAuth creates a TokenAuth accepting the given tokens.
+func NewTokenAuth(tokens []config.APIToken) *TokenAuth {
+	a := &TokenAuth{tokens: make(map[string]config.APIToken, len(tokens)), now: time.Now}
+	for _, t := range tokens {
+		a.tokens[t.TokenHash] = t
+	}
+	return a
+}
+
+// bearerToken returns the bearer token of r, if any.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// Authenticate returns the API token that token is, recording the request in
+// the token metrics.
+func (a *TokenAuth) Authenticate(token string) (config.APIToken, error) {
+	t, ok := a.tokens[HashToken(token)]
+	if !ok {
+		metrics.APITokenRequestsTotal.WithLabelValues("", "invalid").Inc()
+		return config.APIToken{}, errInvalidToken
+	}
+	now := a.now()
+	if t.ExpiresAt != nil && !now.Before(*t.ExpiresAt) {
+		metrics.APITokenRequestsTotal.WithLabelValues(t.Name, "expired").Inc()
+		return config.APIToken{}, errExpiredToken
+	}
+	metrics.APITokenRequestsTotal.WithLabelValues(t.Name, "ok").Inc()
+	metrics.APITokenLastUsed.WithLabelValues(t.Name).Set(float64(now.Unix()))
+	return t, nil
+}
+
+// HasScope reports whether the request may do what scope allows. Requests
+// authenticated by a session may do anything; those authenticated by an API
+// token only what its scopes allow.
+func HasScope(c *gin.Context, scope string) bool {
+	v, ok := c.Get(tokenScopesKey)
+	if !ok {
+		return true
+	}
+	scopes, _ := v.([]string)
+	return slices.Contains(scopes, scope)
+}
+
+// RequireScope returns a Gin middleware that rejects requests with 403 unless
+// they may do what scope allows.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasScope(c, scope) {
+			forbidToken(c, scope)
+			return
+		}
+		c.Next()
+	}
+}
+
+// QueryScope returns a Gin middleware for the query endpoints. Requests that
+// may run any query go through anyQuery; those of API tokens with only the
+// dashboards scope through dashboardQuery, which lets only the queries of
+// dashboards pass, such as handler.RequireDashboardQuery.
+func QueryScope(anyQuery, dashboardQuery gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch {
+		case HasScope(c, config.ScopeQuery):
+			anyQuery(c)
+		case HasScope(c, config.ScopeDashboards):
+			dashboardQuery(c)
+		default:
+			forbidToken(c, config.ScopeQuery)
+		}
+	}
+}
+
+func forbidToken(c *gin.Context, scope string) {
+	metrics.APITokenRequestsTotal.WithLabelValues(strings.TrimPrefix(GetUserID(c), TokenUserPrefix), "forbidden").Inc()
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error": "token lacks the " + strconv.Quote(scope) + " scope",
+	})
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/metrics"
+)
+
+func TestGenerateToken(t *testing.T) {
+	a, err := GenerateToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := GenerateToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(a, "dashyard_") || len(a) != len("dashyard_")+43 {
+		t.Errorf("unexpected token %q", a)
+	}
+	if a == b {
+		t.Error("expected different tokens")
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	// echo -n dashyard_test | sha256sum
+	if got, want := HashToken("dashyard_test"), "919ff6a901e732413ace1da7ef78b2a20a7bb5b56c89924c9530b39cb30c1fbd"; got != want {
+		t.Errorf("HashToken() = %s, want %s", got, want)
+	}
+}
+
+// newTokenRouter returns a router authenticating by the tokens "dashyard_wall"
+// (dashboards scope), "dashyard_script" (query scope) and "dashyard_old"
+// (expired), with a dashboards route and a query route whose dashboard
+// queries are those with query=up.
+func newTokenRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	expired := time.Now().Add(-time.Hour)
+	tokens := NewTokenAuth([]config.APIToken{
+		{Name: "wall", TokenHash: HashToken("dashyard_wall"), Scopes: []string{config.ScopeDashboards}},
+		{Name: "script", TokenHash: HashToken("dashyard_script"), Scopes: []string{config.ScopeQuery}},
+		{Name: "old", TokenHash: HashToken("dashyard_old"), Scopes: []string{config.ScopeDashboards}, ExpiresAt: &expired},
+	})
+	sm := NewSessionManager("test-secret-that-is-32bytes!!", false)
+
+	dashboardQuery := func(c *gin.Context) {
+		if c.Query("query") != "up" {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Next()
+	}
+	ok := func(c *gin.Context) {
+		c.String(http.StatusOK, GetUserID(c))
+	}
+	router := gin.New()
+	router.Use(AuthMiddleware(sm, nil, tokens))
+	router.GET("/dashboards", RequireScope(config.ScopeDashboards), ok)
+	router.GET("/query", QueryScope(func(c *gin.Context) { c.Next() }, dashboardQuery), ok)
+	return router
+}
+
+func TestAuthMiddlewareToken(t *testing.T) {
+	router := newTokenRouter(t)
+	tests := []struct {
+		name     string
+		path     string
+		auth     string
+		wantCode int
+		wantBody string
+	}{
+		{"dashboards token", "/dashboards", "Bearer dashyard_wall", http.StatusOK, "token:wall"},
+		{"lowercase scheme", "/dashboards", "bearer dashyard_wall", http.StatusOK, "token:wall"},
+		{"dashboards token runs dashboard query", "/query?query=up", "Bearer dashyard_wall", http.StatusOK, "token:wall"},
+		{"dashboards token runs other query", "/query?query=secret", "Bearer dashyard_wall", http.StatusForbidden, ""},
+		{"query token runs any query", "/query?query=secret", "Bearer dashyard_script", http.StatusOK, "token:script"},
+		{"query token opens dashboards", "/dashboards", "Bearer dashyard_script", http.StatusForbidden, `{"error":"token lacks the \"dashboards\" scope"}`},
+		{"expired token", "/dashboards", "Bearer dashyard_old", http.StatusUnauthorized, `{"error":"token expired"}`},
+		{"unknown token", "/dashboards", "Bearer dashyard_nope", http.StatusUnauthorized, `{"error":"invalid token"}`},
+		{"basic auth", "/dashboards", "Basic d2FsbDp3YWxs", http.StatusUnauthorized, `{"error":"unauthorized"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", tt.auth)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("expected body %s, got %s", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareTokenMetrics(t *testing.T) {
+	router := newTokenRouter(t)
+	serve := func(path, token string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	okBefore := testutil.ToFloat64(metrics.APITokenRequestsTotal.WithLabelValues("wall", "ok"))
+	forbiddenBefore := testutil.ToFloat64(metrics.APITokenRequestsTotal.WithLabelValues("script", "forbidden"))
+	expiredBefore := testutil.ToFloat64(metrics.APITokenRequestsTotal.WithLabelValues("old", "expired"))
+	invalidBefore := testutil.ToFloat64(metrics.APITokenRequestsTotal.WithLabelValues("", "invalid"))
+
+	start := time.Now().Unix()
+	serve("/dashboards", "dashyard_wall")
+	serve("/dashboards", "dashyard_script")
+	serve("/dashboards", "dashyard_old")
+	serve("/dashboards", "dashyard_nope")
+
+	if got := testutil.ToFloat64(metrics.APITokenRequestsTotal.WithLabelValues("wall", "ok")) - okBefore; got != 1 {
+		t.Errorf("expected 1 accepted request of wall, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.APITokenRequestsTotal.WithLabelValues("script", "forbidden")) - forbiddenBefore; got != 1 {
+		t.Errorf("expected 1 forbidden request of script, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.APITokenRequestsTotal.WithLabelValues("old", "expired")) - expiredBefore; got != 1 {
+		t.Errorf("expected 1 expired request of old, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.APITokenRequestsTotal.WithLabelValues("", "invalid")) - invalidBefore; got != 1 {
+		t.Errorf("expected 1 invalid request, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.APITokenLastUsed.WithLabelValues("wall")); got < float64(start) {
+		t.Errorf("expected last used time of wall to be at least %d, got %v", start, got)
+	}
+}
+
+func TestHasScopeSession(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(userIDKey, "alice")
+	if !HasScope(c, config.ScopeDashboards) || !HasScope(c, config.ScopeQuery) {
+		t.Error("expected a session to have every scope")
+	}
+}
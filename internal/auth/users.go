@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+// UserStore holds the local users who log in with a password: those of the
+// config and those of an optional htpasswd file, which Watch reloads when it
+// changes. A user of the config takes precedence over one of the file with
+// the same ID. It is safe for concurrent use.
+type UserStore struct {
+	users    []config.User
+	debounce time.Duration
+
+	mu        sync.RWMutex
+	file      string
+	fileUsers map[string]config.User
+}
+
+// NewUserStore creates a UserStore with the users of the config.
+func NewUserStore(users []config.User) *UserStore {
+	return &UserStore{users: users, debounce: 500 * time.Millisecond}
+}
+
+// Find returns the user with the given ID.
+func (s *UserStore) Find(id string) (config.User, bool) {
+	for _, u := range s.users {
+		if u.ID == id {
+			return u, true
+		}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.fileUsers[id]
+	return u, ok
+}
+
+// ValidateUsers checks that the password hash of every user of the config is
+// one that VerifyPassword supports, so that a mistyped hash fails at startup
+// rather than locking the user out.
+func ValidateUsers(users []config.User) error {
+	for i, u := range users {
+		if !SupportedHash(u.PasswordHash) {
+			return fmt.Errorf("users[%d]: unsupported password_hash for user %q (use bcrypt, argon2id or SHA-512 crypt)", i, u.ID)
+		}
+	}
+	return nil
+}
+
+// LoadHtpasswd loads the users of an htpasswd file, replacing those loaded
+// before. Watch reloads the file when it changes.
+func (s *UserStore) LoadHtpasswd(path string) error {
+	users, err := ReadHtpasswd(path)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.file = path
+	s.fileUsers = users
+	return nil
+}
+
+// ReadHtpasswd reads an htpasswd file: lines of "user:hash", where hash is
+// one that VerifyPassword supports, such as those of "htpasswd -B" or
+// "dashyard mkpasswd". Blank lines and lines starting with # are ignored.
+func ReadHtpasswd(path string) (map[string]config.User, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading htpasswd file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	users := make(map[string]config.User)
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, hash, ok := strings.Cut(line, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("%s:%d: expected user:hash", path, n)
+		}
+		if !SupportedHash(hash) {
+			return nil, fmt.Errorf("%s:%d: unsupported password hash for user %q (use bcrypt, argon2id or SHA-512 crypt)", path, n, id)
+		}
+		if _, dup := users[id]; dup {
+			return nil, fmt.Errorf("%s:%d: duplicate user %q", path, n, id)
+		}
+		users[id] = config.User{ID: id, PasswordHash: hash}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading htpasswd file: %w", err)
+	}
+	return users, nil
+}
+
+// Watch blocks until ctx is cancelled, reloading the htpasswd file when it
+// changes. A file that fails to load keeps the users loaded before. It
+// returns at once if no htpasswd file was loaded.
+func (s *UserStore) Watch(ctx context.Context) error {
+	s.mu.RLock()
+	file := s.file
+	s.mu.RUnlock()
+	if file == "" {
+		return nil
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = fsw.Close() }()
+
+	// Watch the directory rather than the file, which many editors replace
+	// rather than write to.
+	if err := fsw.Add(filepath.Dir(file)); err != nil {
+		return err
+	}
+	slog.Info("watching htpasswd file for changes", "file", file)
+
+	var timer *time.Timer
+	var timerC <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil
+
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(file) {
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(s.debounce)
+				timerC = timer.C
+			} else {
+				timer.Reset(s.debounce)
+			}
+
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Error("filesystem watcher error", "error", err)
+
+		case <-timerC:
+			timer = nil
+			timerC = nil
+			if err := s.LoadHtpasswd(file); err != nil {
+				slog.Error("failed to reload htpasswd file, keeping old users", "error", err)
+				continue
+			}
+			s.mu.RLock()
+			count := len(s.fileUsers)
+			s.mu.RUnlock()
+			slog.Info("reloaded htpasswd file", "file", file, "users", count)
+		}
+	}
+}
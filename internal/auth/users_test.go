@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+func writeHtpasswd(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadHtpasswd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	aliceHash := generateHash("alice-secret")
+	writeHtpasswd(t, path,
+		"# managed by ops",
+		"",
+		"alice:"+aliceHash,
+		"  bob:$2y$10$abcdefghijklmnopqrstuuWb0Xf3n8wHkq0cU1nxGm7aSx0p0nZ7C  ",
+	)
+
+	users, err := ReadHtpasswd(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users, got %d", len(users))
+	}
+	if users["alice"].ID != "alice" || users["alice"].PasswordHash != aliceHash {
+		t.Errorf("unexpected alice: %+v", users["alice"])
+	}
+	if !strings.HasPrefix(users["bob"].PasswordHash, "$2y$") {
+		t.Errorf("unexpected bob: %+v", users["bob"])
+	}
+}
+
+func TestReadHtpasswdErrors(t *testing.T) {
+	hash := generateHash("password")
+	tests := []struct {
+		name    string
+		lines   []string
+		wantErr string
+	}{
+		{"missing colon", []string{"alice"}, "htpasswd:1: expected user:hash"},
+		{"empty user", []string{":" + hash}, "htpasswd:1: expected user:hash"},
+		{"unsupported hash", []string{"# apache MD5", "alice:$apr1$salt$hash"}, `htpasswd:2: unsupported password hash for user "alice"`},
+		{"duplicate user", []string{"alice:" + hash, "alice:" + hash}, `htpasswd:2: duplicate user "alice"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "htpasswd")
+			writeHtpasswd(t, path, tt.lines...)
+			_, err := ReadHtpasswd(path)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %q", tt.wantErr, err.Error())
+			}
+		})
+	}
+
+	if _, err := ReadHtpasswd(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestValidateUsers(t *testing.T) {
+	users := []config.User{
+		{ID: "admin", PasswordHash: "$6$salt$hash"},
+		{ID: "viewer", PasswordHash: "plaintext"},
+	}
+	err := ValidateUsers(users)
+	if err == nil || !strings.Contains(err.Error(), `users[1]: unsupported password_hash for user "viewer"`) {
+		t.Errorf("expected an unsupported password_hash error naming viewer, got %v", err)
+	}
+	if err := ValidateUsers(users[:1]); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUserStoreFind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	configHash := generateHash("config-secret")
+	writeHtpasswd(t, path,
+		"admin:"+generateHash("file-secret"),
+		"alice:"+generateHash("alice-secret"),
+	)
+
+	s := NewUserStore([]config.User{{ID: "admin", PasswordHash: configHash}})
+	if err := s.LoadHtpasswd(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Users of the config take precedence over those of the file.
+	if u, ok := s.Find("admin"); !ok || u.PasswordHash != configHash {
+		t.Errorf("expected admin of the config, got %+v, %v", u, ok)
+	}
+	if u, ok := s.Find("alice"); !ok || !VerifyPassword("alice-secret", u.PasswordHash) {
+		t.Errorf("expected alice of the file, got %+v, %v", u, ok)
+	}
+	if _, ok := s.Find("bob"); ok {
+		t.Error("expected bob to be unknown")
+	}
+}
+
+func TestUserStoreWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	writeHtpasswd(t, path, "alice:"+generateHash("alice-secret"))
+
+	s := NewUserStore(nil)
+	if err := s.LoadHtpasswd(path); err != nil {
+		t.Fatal(err)
+	}
+	s.debounce = 100 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		if err := s.Watch(ctx); err != nil {
+			t.Errorf("watcher error: %v", err)
+		}
+	}()
+
+	// Give the watcher time to start.
+	time.Sleep(200 * time.Millisecond)
+
+	waitFor := func(condition func() bool) bool {
+		deadline := time.Now().Add(3 * time.Second)
+		for time.Now().Before(deadline) {
+			if condition() {
+				return true
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		return false
+	}
+
+	writeHtpasswd(t, path, "bob:"+generateHash("bob-secret"))
+	if !waitFor(func() bool {
+		_, ok := s.Find("bob")
+		return ok
+	}) {
+		t.Fatal("expected bob after rewriting the file")
+	}
+	if _, ok := s.Find("alice"); ok {
+		t.Error("expected alice to be removed")
+	}
+
+	// A broken file keeps the users loaded before.
+	writeHtpasswd(t, path, "carol")
+	time.Sleep(500 * time.Millisecond)
+	if _, ok := s.Find("bob"); !ok {
+		t.Error("expected bob to remain after a failed reload")
+	}
+}
@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/tokuhirom/dashyard/internal/promql"
 	"gopkg.in/yaml.v3"
 )
 
@@ -58,11 +61,125 @@ type OAuthProviderConfig struct {
 	Scopes       []string `yaml:"scopes,omitempty"`
 	AllowedUsers []string `yaml:"allowed_users,omitempty"`
 	AllowedOrgs  []string `yaml:"allowed_orgs,omitempty"`
+	// AllowedTeams are the GitHub teams, as "org/team-slug", whose members
+	// may sign in.
+	AllowedTeams []string `yaml:"allowed_teams,omitempty"`
+	// TeamCacheTTL is how long the GitHub teams of a user are remembered
+	// after a login, so that logging in again does not list them through
+	// the API. A user removed from a team can still log in for that long.
+	// Defaults to 10m; a negative value lists the teams on every login.
+	TeamCacheTTL time.Duration `yaml:"team_cache_ttl,omitempty"`
+	// DiscoveryURL is the OpenID Connect discovery document of an oidc
+	// provider, e.g. https://sso.example.com/realms/main/.well-known/openid-configuration.
+	// It defaults to Google's for google.
+	DiscoveryURL string `yaml:"discovery_url,omitempty"`
+	// GroupsClaim is the ID token or userinfo claim that lists the groups of
+	// an oidc user. Defaults to "groups".
+	GroupsClaim string `yaml:"groups_claim,omitempty"`
+	// AllowedGroups are the oidc groups, or the full paths of the GitLab
+	// groups, whose members may sign in.
+	AllowedGroups []string `yaml:"allowed_groups,omitempty"`
+	// AllowedDomains are the Google Workspace domains (the hd claim) whose
+	// users may sign in.
+	AllowedDomains []string `yaml:"allowed_domains,omitempty"`
+}
+
+// ProxyAuthConfig holds settings for authentication by a reverse proxy, such
+// as oauth2-proxy, that signs users in and passes their identity in headers.
+type ProxyAuthConfig struct {
+	// UserHeader carries the user ID. Defaults to X-Forwarded-User.
+	UserHeader string `yaml:"user_header,omitempty"`
+	// GroupsHeader, if set, carries the comma-separated groups of the user.
+	GroupsHeader string `yaml:"groups_header,omitempty"`
+}
+
+// LDAPConfig holds settings for checking the passwords of users, who are not
+// in users, against an LDAP directory.
+type LDAPConfig struct {
+	// URL is the directory server, e.g. ldap://ldap.example.com or
+	// ldaps://ldap.example.com.
+	URL string `yaml:"url"`
+	// StartTLS upgrades an ldap:// connection to TLS before binding.
+	StartTLS bool `yaml:"start_tls,omitempty"`
+	// CAFile, if set, is a PEM file of the CA certificates that verify the
+	// server, instead of the system ones.
+	CAFile string `yaml:"ca_file,omitempty"`
+	// InsecureSkipVerify disables verification of the server certificate.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty"`
+	// BindDN and BindPassword are the account that searches for users and
+	// their groups. Searches are anonymous if BindDN is empty.
+	BindDN       string `yaml:"bind_dn,omitempty"`
+	BindPassword string `yaml:"bind_password,omitempty"`
+	// BaseDN is where users are searched.
+	BaseDN string `yaml:"base_dn"`
+	// UserFilter finds the entry of a user, with {user} replaced by the user
+	// ID. Defaults to (uid={user}).
+	UserFilter string `yaml:"user_filter,omitempty"`
+	// GroupBaseDN is where groups are searched. Defaults to BaseDN.
+	GroupBaseDN string `yaml:"group_base_dn,omitempty"`
+	// GroupFilter finds the groups of a user, with {dn} replaced by the DN of
+	// the user's entry and {user} by the user ID. Defaults to (member={dn}).
+	GroupFilter string `yaml:"group_filter,omitempty"`
+	// GroupAttribute is the attribute of a group entry that names it.
+	// Defaults to cn.
+	GroupAttribute string `yaml:"group_attribute,omitempty"`
+	// AllowedGroups, if set, are the groups whose members may sign in.
+	AllowedGroups []string `yaml:"allowed_groups,omitempty"`
+	// Timeout bounds connecting to the server and each request. Defaults to
+	// 10s.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// API token scopes.
+const (
+	// ScopeDashboards allows viewing dashboards, running only the queries of
+	// their panels and variables.
+	ScopeDashboards = "dashboards"
+	// ScopeQuery allows running any query.
+	ScopeQuery = "query"
+)
+
+// APIToken is a named bearer token for unattended access to the API, such as
+// from scripts or wall displays. Only the SHA-256 hash of the token is kept.
+type APIToken struct {
+	Name string `yaml:"name"`
+	// TokenHash is the hex-encoded SHA-256 hash of the token, as printed by
+	// "dashyard mktoken".
+	TokenHash string `yaml:"token_hash"`
+	// Scopes are what the token allows: ScopeDashboards, ScopeQuery or both.
+	// Defaults to ScopeDashboards.
+	Scopes []string `yaml:"scopes,omitempty"`
+	// ExpiresAt, if set, is when the token stops being accepted.
+	ExpiresAt *time.Time `yaml:"expires_at,omitempty"`
+}
+
+// LoginRateLimitConfig holds the throttling of failed password logins, which
+// applies to each client IP and to each user ID. After a failure, the next
+// attempt must wait Backoff, doubling with each further failure up to
+// MaxBackoff; after MaxFailuresPerIP or MaxFailuresPerUser consecutive
+// failures, the client IP or user ID is locked out for Lockout. Failures are
+// forgotten after Lockout without any.
+type LoginRateLimitConfig struct {
+	// MaxFailuresPerIP defaults to 20, as many users may share an IP behind
+	// NAT.
+	MaxFailuresPerIP int `yaml:"max_failures_per_ip,omitempty"`
+	// MaxFailuresPerUser defaults to 5.
+	MaxFailuresPerUser int `yaml:"max_failures_per_user,omitempty"`
+	// Backoff defaults to 1s.
+	Backoff time.Duration `yaml:"backoff,omitempty"`
+	// MaxBackoff defaults to 30s.
+	MaxBackoff time.Duration `yaml:"max_backoff,omitempty"`
+	// Lockout defaults to 15m.
+	Lockout time.Duration `yaml:"lockout,omitempty"`
 }
 
 // AuthConfig holds authentication settings.
 type AuthConfig struct {
-	OAuth []OAuthProviderConfig `yaml:"oauth,omitempty"`
+	OAuth          []OAuthProviderConfig `yaml:"oauth,omitempty"`
+	Proxy          *ProxyAuthConfig      `yaml:"proxy,omitempty"`
+	LDAP           *LDAPConfig           `yaml:"ldap,omitempty"`
+	APITokens      []APIToken            `yaml:"api_tokens,omitempty"`
+	LoginRateLimit LoginRateLimitConfig  `yaml:"login_rate_limit,omitempty"`
 }
 
 // ServerConfig holds HTTP server settings.
@@ -70,6 +187,9 @@ type ServerConfig struct {
 	SessionSecret  string   `yaml:"session_secret"`
 	CookieSecure   bool     `yaml:"cookie_secure"`
 	TrustedProxies []string `yaml:"trusted_proxies,omitempty"`
+	// LockedQueries rejects queries that no loaded dashboard sends, so that
+	// users can only run the dashboards' queries rather than arbitrary PromQL.
+	LockedQueries bool `yaml:"locked_queries,omitempty"`
 }
 
 // HeaderConfig represents a single HTTP header as a name/value pair.
@@ -78,14 +198,38 @@ type HeaderConfig struct {
 	Value string `yaml:"value"`
 }
 
+// QueryCacheConfig holds settings for the in-process query result cache of a datasource.
+type QueryCacheConfig struct {
+	MaxEntries   int           `yaml:"max_entries"`
+	TTL          time.Duration `yaml:"ttl"`
+	RecentTTL    time.Duration `yaml:"recent_ttl"`
+	RecentWindow time.Duration `yaml:"recent_window"`
+}
+
 // DatasourceConfig holds settings for a single named datasource.
 type DatasourceConfig struct {
-	Name    string         `yaml:"name"`
-	Type    string         `yaml:"type"`
-	URL     string         `yaml:"url"`
-	Timeout time.Duration  `yaml:"timeout"`
-	Default bool           `yaml:"default"`
-	Headers []HeaderConfig `yaml:"headers,omitempty"`
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type"`
+	URL     string            `yaml:"url"`
+	Timeout time.Duration     `yaml:"timeout"`
+	Default bool              `yaml:"default"`
+	Headers []HeaderConfig    `yaml:"headers,omitempty"`
+	Cache   *QueryCacheConfig `yaml:"cache,omitempty"`
+}
+
+// Group is a named set of users, by user ID, that policies can refer to.
+type Group struct {
+	Name    string   `yaml:"name"`
+	Members []string `yaml:"members"`
+}
+
+// LabelPolicy forces label matchers into every query and label values request
+// of the listed users and members of the listed groups. Matchers is a list of
+// label matchers such as `{team="payments"}`; the braces are optional.
+type LabelPolicy struct {
+	Users    []string `yaml:"users,omitempty"`
+	Groups   []string `yaml:"groups,omitempty"`
+	Matchers string   `yaml:"matchers"`
 }
 
 // Config is the top-level application configuration.
@@ -95,16 +239,30 @@ type Config struct {
 	Server      ServerConfig       `yaml:"server"`
 	Datasources []DatasourceConfig `yaml:"datasources"`
 	Users       []User             `yaml:"users"`
-	Auth        AuthConfig         `yaml:"auth"`
+	// UsersFile is an htpasswd file of more users, reloaded when it changes.
+	// A relative path is relative to the directory of the config file.
+	UsersFile     string        `yaml:"users_file,omitempty"`
+	Auth          AuthConfig    `yaml:"auth"`
+	Groups        []Group       `yaml:"groups,omitempty"`
+	LabelPolicies []LabelPolicy `yaml:"label_policies,omitempty"`
 }
 
-// Load reads and parses a YAML config file, applying defaults for missing values.
+// Load reads and parses a YAML config file, applying defaults for missing
+// values. The paths of files the config refers to are resolved against the
+// directory of path, so that they do not depend on the working directory.
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading config file: %w", err)
 	}
-	return Parse(data)
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.UsersFile != "" && !filepath.IsAbs(cfg.UsersFile) {
+		cfg.UsersFile = filepath.Join(filepath.Dir(path), cfg.UsersFile)
+	}
+	return cfg, nil
 }
 
 // Parse parses YAML config data, applying defaults for missing values.
@@ -148,6 +306,13 @@ func Parse(data []byte) (*Config, error) {
 			cfg.Users[i].PasswordHash = v
 		}
 	}
+	for i, t := range cfg.Auth.APITokens {
+		if v, err := expandEnvBraces(t.TokenHash); err != nil {
+			return nil, fmt.Errorf("auth.api_tokens[%d].token_hash: %w", i, err)
+		} else {
+			cfg.Auth.APITokens[i].TokenHash = v
+		}
+	}
 	for i, p := range cfg.Auth.OAuth {
 		if v, err := expandEnvBraces(p.ClientID); err != nil {
 			return nil, fmt.Errorf("auth.oauth[%d].client_id: %w", i, err)
@@ -169,6 +334,28 @@ func Parse(data []byte) (*Config, error) {
 		} else {
 			cfg.Auth.OAuth[i].BaseURL = v
 		}
+		if v, err := expandEnvBraces(p.DiscoveryURL); err != nil {
+			return nil, fmt.Errorf("auth.oauth[%d].discovery_url: %w", i, err)
+		} else {
+			cfg.Auth.OAuth[i].DiscoveryURL = v
+		}
+	}
+	if l := cfg.Auth.LDAP; l != nil {
+		if v, err := expandEnvBraces(l.URL); err != nil {
+			return nil, fmt.Errorf("auth.ldap.url: %w", err)
+		} else {
+			l.URL = v
+		}
+		if v, err := expandEnvBraces(l.BindDN); err != nil {
+			return nil, fmt.Errorf("auth.ldap.bind_dn: %w", err)
+		} else {
+			l.BindDN = v
+		}
+		if v, err := expandEnvBraces(l.BindPassword); err != nil {
+			return nil, fmt.Errorf("auth.ldap.bind_password: %w", err)
+		} else {
+			l.BindPassword = v
+		}
 	}
 	if v, err := expandEnvBraces(cfg.Server.SessionSecret); err != nil {
 		return nil, fmt.Errorf("server.session_secret: %w", err)
@@ -180,6 +367,33 @@ func Parse(data []byte) (*Config, error) {
 		return nil, err
 	}
 
+	if p := cfg.Auth.Proxy; p != nil {
+		if len(cfg.Server.TrustedProxies) == 0 {
+			return nil, fmt.Errorf("auth.proxy: server.trusted_proxies is required, as only the proxies listed there are trusted to set the user header")
+		}
+		if p.UserHeader == "" {
+			p.UserHeader = "X-Forwarded-User"
+		}
+	}
+
+	if l := cfg.Auth.LDAP; l != nil {
+		if err := validateLDAPConfig(l); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateAPITokens(cfg.Auth.APITokens); err != nil {
+		return nil, err
+	}
+
+	if err := validateLoginRateLimit(&cfg.Auth.LoginRateLimit); err != nil {
+		return nil, err
+	}
+
+	if err := validateLabelPolicies(cfg.Groups, cfg.LabelPolicies); err != nil {
+		return nil, err
+	}
+
 	// Provide a default datasource when none configured
 	if len(cfg.Datasources) == 0 {
 		cfg.Datasources = []DatasourceConfig{
@@ -227,7 +441,7 @@ func validateDatasources(datasources []DatasourceConfig) error {
 		}
 		seen[ds.Name] = true
 
-		validTypes := map[string]bool{"prometheus": true}
+		validTypes := map[string]bool{"prometheus": true, "loki": true}
 		if !validTypes[ds.Type] {
 			return fmt.Errorf("datasources[%d]: unsupported type %q", i, ds.Type)
 		}
@@ -237,6 +451,11 @@ func validateDatasources(datasources []DatasourceConfig) error {
 		if ds.Default {
 			defaultCount++
 		}
+		if ds.Cache != nil {
+			if err := validateQueryCache(ds.Cache); err != nil {
+				return fmt.Errorf("datasources[%d].cache: %w", i, err)
+			}
+		}
 	}
 
 	// If only one datasource, auto-set as default
@@ -255,6 +474,38 @@ func validateDatasources(datasources []DatasourceConfig) error {
 	return nil
 }
 
+// validateQueryCache checks the cache settings and fills in defaults for unset values.
+func validateQueryCache(c *QueryCacheConfig) error {
+	if c.MaxEntries < 0 {
+		return fmt.Errorf("max_entries must not be negative")
+	}
+	if c.TTL < 0 || c.RecentTTL < 0 || c.RecentWindow < 0 {
+		return fmt.Errorf("durations must not be negative")
+	}
+	if c.MaxEntries == 0 {
+		c.MaxEntries = 1000
+	}
+	if c.TTL == 0 {
+		c.TTL = 5 * time.Minute
+	}
+	if c.RecentTTL == 0 {
+		c.RecentTTL = 10 * time.Second
+	}
+	if c.RecentWindow == 0 {
+		c.RecentWindow = 5 * time.Minute
+	}
+	return nil
+}
+
+// oauthProviderOptions lists the provider-specific options each supported
+// OAuth provider takes.
+var oauthProviderOptions = map[string][]string{
+	"github": {"base_url", "allowed_orgs", "allowed_teams", "team_cache_ttl"},
+	"gitlab": {"base_url", "allowed_groups"},
+	"google": {"discovery_url", "allowed_domains"},
+	"oidc":   {"discovery_url", "groups_claim", "allowed_groups"},
+}
+
 func validateOAuthConfig(providers []OAuthProviderConfig) error {
 	seen := make(map[string]bool)
 	for i, p := range providers {
@@ -271,6 +522,181 @@ func validateOAuthConfig(providers []OAuthProviderConfig) error {
 			return fmt.Errorf("auth.oauth[%d]: duplicate provider %q", i, p.Provider)
 		}
 		seen[p.Provider] = true
+
+		options, ok := oauthProviderOptions[p.Provider]
+		if !ok {
+			return fmt.Errorf("auth.oauth[%d]: unsupported provider %q (expected github, gitlab, google or oidc)", i, p.Provider)
+		}
+		for _, o := range []struct {
+			name string
+			set  bool
+		}{
+			{"base_url", p.BaseURL != ""},
+			{"allowed_orgs", len(p.AllowedOrgs) > 0},
+			{"allowed_teams", len(p.AllowedTeams) > 0},
+			{"team_cache_ttl", p.TeamCacheTTL != 0},
+			{"discovery_url", p.DiscoveryURL != ""},
+			{"groups_claim", p.GroupsClaim != ""},
+			{"allowed_groups", len(p.AllowedGroups) > 0},
+			{"allowed_domains", len(p.AllowedDomains) > 0},
+		} {
+			if o.set && !slices.Contains(options, o.name) {
+				return fmt.Errorf("auth.oauth[%d]: %s is not supported by provider %q", i, o.name, p.Provider)
+			}
+		}
+		if p.Provider == "oidc" && p.DiscoveryURL == "" {
+			return fmt.Errorf("auth.oauth[%d]: discovery_url is required for provider %q", i, p.Provider)
+		}
+		for j, team := range p.AllowedTeams {
+			if org, slug, ok := strings.Cut(team, "/"); !ok || org == "" || slug == "" || strings.Contains(slug, "/") {
+				return fmt.Errorf("auth.oauth[%d].allowed_teams[%d]: %q is not of the form org/team-slug", i, j, team)
+			}
+		}
+	}
+	return nil
+}
+
+// validateLDAPConfig checks the LDAP settings and fills in defaults for unset
+// values.
+func validateLDAPConfig(l *LDAPConfig) error {
+	if l.URL == "" {
+		return fmt.Errorf("auth.ldap: url is required")
+	}
+	scheme, _, _ := strings.Cut(l.URL, "://")
+	switch scheme {
+	case "ldap":
+	case "ldaps":
+		if l.StartTLS {
+			return fmt.Errorf("auth.ldap: start_tls cannot be used with an ldaps:// url, which is TLS already")
+		}
+	default:
+		return fmt.Errorf("auth.ldap: url must start with ldap:// or ldaps://, got %q", l.URL)
+	}
+	if l.BaseDN == "" {
+		return fmt.Errorf("auth.ldap: base_dn is required")
+	}
+	if l.BindDN != "" && l.BindPassword == "" {
+		return fmt.Errorf("auth.ldap: bind_password is required with bind_dn")
+	}
+	if l.UserFilter == "" {
+		l.UserFilter = "(uid={user})"
+	}
+	if !strings.Contains(l.UserFilter, "{user}") {
+		return fmt.Errorf("auth.ldap: user_filter must contain {user}")
+	}
+	if l.GroupBaseDN == "" {
+		l.GroupBaseDN = l.BaseDN
+	}
+	if l.GroupFilter == "" {
+		l.GroupFilter = "(member={dn})"
+	}
+	if l.GroupAttribute == "" {
+		l.GroupAttribute = "cn"
+	}
+	if l.Timeout < 0 {
+		return fmt.Errorf("auth.ldap: timeout must not be negative")
+	}
+	if l.Timeout == 0 {
+		l.Timeout = 10 * time.Second
+	}
+	return nil
+}
+
+// validateLoginRateLimit checks the login throttling settings and fills in
+// defaults for unset values.
+func validateLoginRateLimit(l *LoginRateLimitConfig) error {
+	if l.MaxFailuresPerIP < 0 || l.MaxFailuresPerUser < 0 {
+		return fmt.Errorf("auth.login_rate_limit: max_failures_per_ip and max_failures_per_user must not be negative")
+	}
+	if l.Backoff < 0 || l.MaxBackoff < 0 || l.Lockout < 0 {
+		return fmt.Errorf("auth.login_rate_limit: durations must not be negative")
+	}
+	if l.MaxFailuresPerIP == 0 {
+		l.MaxFailuresPerIP = 20
+	}
+	if l.MaxFailuresPerUser == 0 {
+		l.MaxFailuresPerUser = 5
+	}
+	if l.Backoff == 0 {
+		l.Backoff = time.Second
+	}
+	if l.MaxBackoff == 0 {
+		l.MaxBackoff = 30 * time.Second
+	}
+	if l.Lockout == 0 {
+		l.Lockout = 15 * time.Minute
+	}
+	if l.MaxBackoff < l.Backoff {
+		return fmt.Errorf("auth.login_rate_limit: max_backoff (%s) must not be less than backoff (%s)", l.MaxBackoff, l.Backoff)
+	}
+	if l.Lockout < l.MaxBackoff {
+		return fmt.Errorf("auth.login_rate_limit: lockout (%s) must not be less than max_backoff (%s)", l.Lockout, l.MaxBackoff)
+	}
+	return nil
+}
+
+var tokenHashRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// validateAPITokens checks the API tokens and defaults their scopes to
+// ScopeDashboards.
+func validateAPITokens(tokens []APIToken) error {
+	names := make(map[string]bool, len(tokens))
+	hashes := make(map[string]bool, len(tokens))
+	for i, t := range tokens {
+		if t.Name == "" {
+			return fmt.Errorf("auth.api_tokens[%d]: name is required", i)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("auth.api_tokens[%d]: duplicate name %q", i, t.Name)
+		}
+		names[t.Name] = true
+		if !tokenHashRe.MatchString(t.TokenHash) {
+			return fmt.Errorf("auth.api_tokens[%d]: token_hash must be a hex-encoded SHA-256 hash, as printed by dashyard mktoken", i)
+		}
+		if hashes[t.TokenHash] {
+			return fmt.Errorf("auth.api_tokens[%d]: duplicate token_hash", i)
+		}
+		hashes[t.TokenHash] = true
+		for _, scope := range t.Scopes {
+			if scope != ScopeDashboards && scope != ScopeQuery {
+				return fmt.Errorf("auth.api_tokens[%d]: unsupported scope %q (expected %s or %s)", i, scope, ScopeDashboards, ScopeQuery)
+			}
+		}
+		if len(t.Scopes) == 0 {
+			tokens[i].Scopes = []string{ScopeDashboards}
+		}
+	}
+	return nil
+}
+
+func validateLabelPolicies(groups []Group, policies []LabelPolicy) error {
+	groupNames := make(map[string]bool, len(groups))
+	for i, g := range groups {
+		if g.Name == "" {
+			return fmt.Errorf("groups[%d]: name is required", i)
+		}
+		if groupNames[g.Name] {
+			return fmt.Errorf("groups[%d]: duplicate group %q", i, g.Name)
+		}
+		groupNames[g.Name] = true
+	}
+
+	for i, p := range policies {
+		if len(p.Users) == 0 && len(p.Groups) == 0 {
+			return fmt.Errorf("label_policies[%d]: users or groups is required", i)
+		}
+		for _, g := range p.Groups {
+			if !groupNames[g] {
+				return fmt.Errorf("label_policies[%d]: unknown group %q", i, g)
+			}
+		}
+		matchers, err := promql.ParseMatchers(p.Matchers)
+		if err != nil {
+			return fmt.Errorf("label_policies[%d]: %w", i, err)
+		}
+		if len(matchers) == 0 {
+			return fmt.Errorf("label_policies[%d]: matchers is required", i)
+		}
 	}
 	return nil
 }
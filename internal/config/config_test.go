@@ -1,6 +1,9 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -123,6 +126,23 @@ func TestParseCookieSecureDefault(t *testing.T) {
 	}
 }
 
+func TestParseLockedQueries(t *testing.T) {
+	input := []byte(`
+server:
+  session_secret: "test"
+  locked_queries: true
+`)
+
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !cfg.Server.LockedQueries {
+		t.Error("expected locked_queries to be true")
+	}
+}
+
 func TestParseTrustedProxies(t *testing.T) {
 	input := []byte(`
 server:
@@ -214,6 +234,29 @@ auth:
 	}
 }
 
+func TestParseOAuthConfigWithAllowedTeams(t *testing.T) {
+	input := []byte(`
+auth:
+  oauth:
+    - provider: github
+      client_id: "id"
+      client_secret: "secret"
+      allowed_teams: ["my-org/sre", "my-org/platform"]
+      team_cache_ttl: 1m
+`)
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	teams := cfg.Auth.OAuth[0].AllowedTeams
+	if len(teams) != 2 || teams[0] != "my-org/sre" || teams[1] != "my-org/platform" {
+		t.Errorf("expected allowed_teams [my-org/sre my-org/platform], got %v", teams)
+	}
+	if ttl := cfg.Auth.OAuth[0].TeamCacheTTL; ttl != time.Minute {
+		t.Errorf("expected team_cache_ttl 1m, got %s", ttl)
+	}
+}
+
 func TestParseOAuthConfigWithBaseURL(t *testing.T) {
 	input := []byte(`
 auth:
@@ -315,6 +358,513 @@ auth:
 	}
 }
 
+func TestParseOIDCConfig(t *testing.T) {
+	input := []byte(`
+auth:
+  oauth:
+    - provider: oidc
+      client_id: "dashyard"
+      client_secret: "secret"
+      redirect_url: "http://localhost:8080/auth/oidc/callback"
+      discovery_url: "https://sso.example.com/realms/main/.well-known/openid-configuration"
+      scopes: ["openid", "profile", "email", "groups"]
+      groups_claim: "roles"
+      allowed_groups: ["sre"]
+`)
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := cfg.Auth.OAuth[0]
+	if p.DiscoveryURL != "https://sso.example.com/realms/main/.well-known/openid-configuration" {
+		t.Errorf("unexpected discovery_url %q", p.DiscoveryURL)
+	}
+	if p.GroupsClaim != "roles" {
+		t.Errorf("expected groups_claim 'roles', got %q", p.GroupsClaim)
+	}
+	if len(p.AllowedGroups) != 1 || p.AllowedGroups[0] != "sre" {
+		t.Errorf("expected allowed_groups [sre], got %v", p.AllowedGroups)
+	}
+}
+
+func TestParseGitLabAndGoogleConfig(t *testing.T) {
+	input := []byte(`
+auth:
+  oauth:
+    - provider: gitlab
+      client_id: "gl-id"
+      client_secret: "gl-secret"
+      base_url: "https://gitlab.example.com"
+      allowed_groups: ["infra/sre"]
+    - provider: google
+      client_id: "g-id"
+      client_secret: "g-secret"
+      allowed_domains: ["example.com"]
+`)
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gl := cfg.Auth.OAuth[0]
+	if gl.BaseURL != "https://gitlab.example.com" || len(gl.AllowedGroups) != 1 || gl.AllowedGroups[0] != "infra/sre" {
+		t.Errorf("unexpected gitlab config %+v", gl)
+	}
+	g := cfg.Auth.OAuth[1]
+	if len(g.AllowedDomains) != 1 || g.AllowedDomains[0] != "example.com" {
+		t.Errorf("expected allowed_domains [example.com], got %v", g.AllowedDomains)
+	}
+}
+
+func TestParseOAuthProviderValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "unsupported provider",
+			input: `
+auth:
+  oauth:
+    - provider: myspace
+      client_id: "id"
+      client_secret: "secret"
+`,
+			want: `unsupported provider "myspace"`,
+		},
+		{
+			name: "oidc without discovery_url",
+			input: `
+auth:
+  oauth:
+    - provider: oidc
+      client_id: "id"
+      client_secret: "secret"
+`,
+			want: "discovery_url is required",
+		},
+		{
+			name: "allowed_orgs on oidc",
+			input: `
+auth:
+  oauth:
+    - provider: oidc
+      client_id: "id"
+      client_secret: "secret"
+      discovery_url: "https://sso.example.com/.well-known/openid-configuration"
+      allowed_orgs: ["my-org"]
+`,
+			want: "allowed_orgs is not supported",
+		},
+		{
+			name: "allowed_groups on github",
+			input: `
+auth:
+  oauth:
+    - provider: github
+      client_id: "id"
+      client_secret: "secret"
+      allowed_groups: ["sre"]
+`,
+			want: "allowed_groups is not supported",
+		},
+		{
+			name: "allowed_teams without org",
+			input: `
+auth:
+  oauth:
+    - provider: github
+      client_id: "id"
+      client_secret: "secret"
+      allowed_teams: ["sre"]
+`,
+			want: `allowed_teams[0]: "sre" is not of the form org/team-slug`,
+		},
+		{
+			name: "allowed_teams on gitlab",
+			input: `
+auth:
+  oauth:
+    - provider: gitlab
+      client_id: "id"
+      client_secret: "secret"
+      allowed_teams: ["my-org/sre"]
+`,
+			want: "allowed_teams is not supported",
+		},
+		{
+			name: "team_cache_ttl on oidc",
+			input: `
+auth:
+  oauth:
+    - provider: oidc
+      client_id: "id"
+      client_secret: "secret"
+      discovery_url: "https://sso.example.com/.well-known/openid-configuration"
+      team_cache_ttl: 1m
+`,
+			want: "team_cache_ttl is not supported",
+		},
+		{
+			name: "allowed_domains on gitlab",
+			input: `
+auth:
+  oauth:
+    - provider: gitlab
+      client_id: "id"
+      client_secret: "secret"
+      allowed_domains: ["example.com"]
+`,
+			want: "allowed_domains is not supported",
+		},
+		{
+			name: "base_url on google",
+			input: `
+auth:
+  oauth:
+    - provider: google
+      client_id: "id"
+      client_secret: "secret"
+      base_url: "https://google.example.com"
+`,
+			want: "base_url is not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParseProxyAuth(t *testing.T) {
+	input := []byte(`
+server:
+  trusted_proxies: ["10.0.0.0/8"]
+auth:
+  proxy:
+    groups_header: "X-Forwarded-Groups"
+`)
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Auth.Proxy == nil {
+		t.Fatal("expected auth.proxy to be set")
+	}
+	if cfg.Auth.Proxy.UserHeader != "X-Forwarded-User" {
+		t.Errorf("expected default user_header X-Forwarded-User, got %q", cfg.Auth.Proxy.UserHeader)
+	}
+	if cfg.Auth.Proxy.GroupsHeader != "X-Forwarded-Groups" {
+		t.Errorf("expected groups_header X-Forwarded-Groups, got %q", cfg.Auth.Proxy.GroupsHeader)
+	}
+}
+
+func TestParseProxyAuthRequiresTrustedProxies(t *testing.T) {
+	input := []byte(`
+auth:
+  proxy:
+    user_header: "X-Auth-Request-User"
+`)
+	_, err := Parse(input)
+	if err == nil || !strings.Contains(err.Error(), "trusted_proxies is required") {
+		t.Errorf("expected trusted_proxies error, got %v", err)
+	}
+}
+
+func TestParseUsersFile(t *testing.T) {
+	input := []byte(`
+datasources:
+  - name: main
+    type: prometheus
+    url: "http://prom:9090"
+users_file: /etc/dashyard/htpasswd
+`)
+
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.UsersFile != "/etc/dashyard/htpasswd" {
+		t.Errorf("expected users_file '/etc/dashyard/htpasswd', got %q", cfg.UsersFile)
+	}
+	if len(cfg.Users) != 0 {
+		t.Errorf("expected no users, got %d", len(cfg.Users))
+	}
+}
+
+func TestLoadResolvesUsersFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		usersFile string
+		want      string
+	}{
+		{"htpasswd", filepath.Join(dir, "htpasswd")},
+		{"auth/htpasswd", filepath.Join(dir, "auth", "htpasswd")},
+		{"/etc/dashyard/htpasswd", "/etc/dashyard/htpasswd"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, "config.yaml")
+		if err := os.WriteFile(path, []byte("users_file: "+tt.usersFile+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.UsersFile != tt.want {
+			t.Errorf("users_file %q: expected %q, got %q", tt.usersFile, tt.want, cfg.UsersFile)
+		}
+	}
+}
+
+func TestParseLDAPConfig(t *testing.T) {
+	t.Setenv("TEST_LDAP_PASSWORD", "service-secret")
+	input := []byte(`
+auth:
+  ldap:
+    url: "ldap://ldap.example.com"
+    start_tls: true
+    bind_dn: "cn=dashyard,ou=services,dc=example,dc=com"
+    bind_password: "${TEST_LDAP_PASSWORD}"
+    base_dn: "ou=people,dc=example,dc=com"
+    allowed_groups: ["sre"]
+`)
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l := cfg.Auth.LDAP
+	if l == nil {
+		t.Fatal("expected auth.ldap to be set")
+	}
+	if l.BindPassword != "service-secret" {
+		t.Errorf("expected expanded bind_password, got %q", l.BindPassword)
+	}
+	if l.UserFilter != "(uid={user})" {
+		t.Errorf("expected default user_filter, got %q", l.UserFilter)
+	}
+	if l.GroupBaseDN != "ou=people,dc=example,dc=com" {
+		t.Errorf("expected group_base_dn to default to base_dn, got %q", l.GroupBaseDN)
+	}
+	if l.GroupFilter != "(member={dn})" || l.GroupAttribute != "cn" {
+		t.Errorf("expected default group_filter and group_attribute, got %q and %q", l.GroupFilter, l.GroupAttribute)
+	}
+	if l.Timeout != 10*time.Second {
+		t.Errorf("expected default timeout 10s, got %v", l.Timeout)
+	}
+}
+
+func TestParseLDAPConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			"missing url",
+			"auth:\n  ldap:\n    base_dn: dc=example,dc=com\n",
+			"auth.ldap: url is required",
+		},
+		{
+			"unsupported scheme",
+			"auth:\n  ldap:\n    url: http://ldap.example.com\n    base_dn: dc=example,dc=com\n",
+			"auth.ldap: url must start with ldap:// or ldaps://",
+		},
+		{
+			"start_tls with ldaps",
+			"auth:\n  ldap:\n    url: ldaps://ldap.example.com\n    start_tls: true\n    base_dn: dc=example,dc=com\n",
+			"auth.ldap: start_tls cannot be used with an ldaps:// url",
+		},
+		{
+			"missing base_dn",
+			"auth:\n  ldap:\n    url: ldap://ldap.example.com\n",
+			"auth.ldap: base_dn is required",
+		},
+		{
+			"bind_dn without password",
+			"auth:\n  ldap:\n    url: ldap://ldap.example.com\n    base_dn: dc=example,dc=com\n    bind_dn: cn=dashyard,dc=example,dc=com\n",
+			"auth.ldap: bind_password is required with bind_dn",
+		},
+		{
+			"user_filter without placeholder",
+			"auth:\n  ldap:\n    url: ldap://ldap.example.com\n    base_dn: dc=example,dc=com\n    user_filter: (uid=%s)\n",
+			"auth.ldap: user_filter must contain {user}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseAPITokens(t *testing.T) {
+	input := []byte(`
+auth:
+  api_tokens:
+    - name: wall-display
+      token_hash: 4ac3537b7947a7f6fa93ea924fa73d249b7ef1f182e8e37e87aa3077bbbe508a
+    - name: backup-script
+      token_hash: a3438b599c3c3e539b94f3906cc50a21decb454012823f3ed2ee04e09fe742db
+      scopes: [query]
+      expires_at: 2027-01-01T00:00:00Z
+`)
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Auth.APITokens) != 2 {
+		t.Fatalf("expected 2 API tokens, got %d", len(cfg.Auth.APITokens))
+	}
+	wall := cfg.Auth.APITokens[0]
+	if !slices.Equal(wall.Scopes, []string{ScopeDashboards}) {
+		t.Errorf("expected default scopes [dashboards], got %v", wall.Scopes)
+	}
+	if wall.ExpiresAt != nil {
+		t.Errorf("expected no expiry, got %v", wall.ExpiresAt)
+	}
+	script := cfg.Auth.APITokens[1]
+	if !slices.Equal(script.Scopes, []string{ScopeQuery}) {
+		t.Errorf("expected scopes [query], got %v", script.Scopes)
+	}
+	if want := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC); script.ExpiresAt == nil || !script.ExpiresAt.Equal(want) {
+		t.Errorf("expected expires_at %v, got %v", want, script.ExpiresAt)
+	}
+}
+
+func TestParseAPITokensValidation(t *testing.T) {
+	const hash = "4ac3537b7947a7f6fa93ea924fa73d249b7ef1f182e8e37e87aa3077bbbe508a"
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			"missing name",
+			"auth:\n  api_tokens:\n    - token_hash: " + hash + "\n",
+			"auth.api_tokens[0]: name is required",
+		},
+		{
+			"duplicate name",
+			"auth:\n  api_tokens:\n    - {name: a, token_hash: " + hash + "}\n    - {name: a, token_hash: " + hash + "}\n",
+			`auth.api_tokens[1]: duplicate name "a"`,
+		},
+		{
+			"plain token instead of hash",
+			"auth:\n  api_tokens:\n    - {name: a, token_hash: dashyard_secret}\n",
+			"auth.api_tokens[0]: token_hash must be a hex-encoded SHA-256 hash",
+		},
+		{
+			"duplicate hash",
+			"auth:\n  api_tokens:\n    - {name: a, token_hash: " + hash + "}\n    - {name: b, token_hash: " + hash + "}\n",
+			"auth.api_tokens[1]: duplicate token_hash",
+		},
+		{
+			"unsupported scope",
+			"auth:\n  api_tokens:\n    - {name: a, token_hash: " + hash + ", scopes: [admin]}\n",
+			`auth.api_tokens[0]: unsupported scope "admin"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseLabelPolicies(t *testing.T) {
+	input := []byte(`
+groups:
+  - name: payments
+    members: ["alice", "bob"]
+label_policies:
+  - groups: ["payments"]
+    matchers: '{team="payments"}'
+  - users: ["carol"]
+    matchers: 'team="search", env!="dev"'
+`)
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(cfg.Groups) != 1 || cfg.Groups[0].Name != "payments" || len(cfg.Groups[0].Members) != 2 {
+		t.Errorf("unexpected groups: %+v", cfg.Groups)
+	}
+	if len(cfg.LabelPolicies) != 2 {
+		t.Fatalf("expected 2 label policies, got %d", len(cfg.LabelPolicies))
+	}
+	if cfg.LabelPolicies[1].Users[0] != "carol" || cfg.LabelPolicies[1].Matchers != `team="search", env!="dev"` {
+		t.Errorf("unexpected label policy: %+v", cfg.LabelPolicies[1])
+	}
+}
+
+func TestParseLabelPoliciesValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			"group without name",
+			"groups:\n  - members: [alice]\n",
+			"groups[0]: name is required",
+		},
+		{
+			"duplicate group",
+			"groups:\n  - name: a\n  - name: a\n",
+			`groups[1]: duplicate group "a"`,
+		},
+		{
+			"policy without users or groups",
+			"label_policies:\n  - matchers: 'team=\"a\"'\n",
+			"label_policies[0]: users or groups is required",
+		},
+		{
+			"unknown group",
+			"label_policies:\n  - groups: [nope]\n    matchers: 'team=\"a\"'\n",
+			`label_policies[0]: unknown group "nope"`,
+		},
+		{
+			"missing matchers",
+			"label_policies:\n  - users: [alice]\n",
+			"label_policies[0]: matchers is required",
+		},
+		{
+			"invalid matchers",
+			"label_policies:\n  - users: [alice]\n    matchers: 'team=a'\n",
+			"label_policies[0]: invalid label matchers",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestParseNoOAuthConfig(t *testing.T) {
 	input := []byte(`{}`)
 	cfg, err := Parse(input)
@@ -786,3 +1336,189 @@ datasources:
 		t.Error("expected error when no default is set with multiple datasources")
 	}
 }
+
+func TestParseDatasourceCache(t *testing.T) {
+	input := []byte(`
+datasources:
+  - name: main
+    type: prometheus
+    url: "http://prom:9090"
+    cache:
+      max_entries: 500
+      ttl: 10m
+  - name: other
+    type: prometheus
+    url: "http://other:9090"
+    default: true
+`)
+
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := cfg.Datasources[0].Cache
+	if c == nil {
+		t.Fatal("expected cache config to be set")
+	}
+	if c.MaxEntries != 500 {
+		t.Errorf("expected max_entries 500, got %d", c.MaxEntries)
+	}
+	if c.TTL != 10*time.Minute {
+		t.Errorf("expected ttl 10m, got %v", c.TTL)
+	}
+	if c.RecentTTL != 10*time.Second {
+		t.Errorf("expected default recent_ttl 10s, got %v", c.RecentTTL)
+	}
+	if c.RecentWindow != 5*time.Minute {
+		t.Errorf("expected default recent_window 5m, got %v", c.RecentWindow)
+	}
+	if cfg.Datasources[1].Cache != nil {
+		t.Error("expected cache to be disabled when omitted")
+	}
+}
+
+func TestParseDatasourceCacheEmptyBlockUsesDefaults(t *testing.T) {
+	input := []byte(`
+datasources:
+  - name: main
+    type: prometheus
+    url: "http://prom:9090"
+    cache: {}
+`)
+
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := cfg.Datasources[0].Cache
+	if c == nil {
+		t.Fatal("expected cache config to be set")
+	}
+	if c.MaxEntries != 1000 {
+		t.Errorf("expected default max_entries 1000, got %d", c.MaxEntries)
+	}
+	if c.TTL != 5*time.Minute {
+		t.Errorf("expected default ttl 5m, got %v", c.TTL)
+	}
+}
+
+func TestParseDatasourceCacheNegativeMaxEntries(t *testing.T) {
+	input := []byte(`
+datasources:
+  - name: main
+    type: prometheus
+    url: "http://prom:9090"
+    cache:
+      max_entries: -1
+`)
+
+	_, err := Parse(input)
+	if err == nil {
+		t.Fatal("expected error for negative max_entries")
+	}
+	if !strings.Contains(err.Error(), "max_entries") {
+		t.Errorf("expected error about max_entries, got: %v", err)
+	}
+}
+
+func TestParseLokiDatasource(t *testing.T) {
+	input := []byte(`
+datasources:
+  - name: prom
+    type: prometheus
+    url: "http://prom:9090"
+    default: true
+  - name: logs
+    type: loki
+    url: "http://loki:3100"
+`)
+
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Datasources[1].Type != "loki" {
+		t.Errorf("expected type 'loki', got %q", cfg.Datasources[1].Type)
+	}
+}
+
+func TestParseLoginRateLimit(t *testing.T) {
+	cfg, err := Parse([]byte("datasources:\n  - name: main\n    type: prometheus\n    url: http://prom:9090\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := LoginRateLimitConfig{
+		MaxFailuresPerIP:   20,
+		MaxFailuresPerUser: 5,
+		Backoff:            time.Second,
+		MaxBackoff:         30 * time.Second,
+		Lockout:            15 * time.Minute,
+	}
+	if cfg.Auth.LoginRateLimit != want {
+		t.Errorf("expected defaults %+v, got %+v", want, cfg.Auth.LoginRateLimit)
+	}
+
+	cfg, err = Parse([]byte(`
+auth:
+  login_rate_limit:
+    max_failures_per_user: 10
+    backoff: 2s
+    max_backoff: 1m
+    lockout: 1h
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = LoginRateLimitConfig{
+		MaxFailuresPerIP:   20,
+		MaxFailuresPerUser: 10,
+		Backoff:            2 * time.Second,
+		MaxBackoff:         time.Minute,
+		Lockout:            time.Hour,
+	}
+	if cfg.Auth.LoginRateLimit != want {
+		t.Errorf("expected %+v, got %+v", want, cfg.Auth.LoginRateLimit)
+	}
+}
+
+func TestParseLoginRateLimitValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			"negative max failures",
+			"auth:\n  login_rate_limit:\n    max_failures_per_ip: -1\n",
+			"auth.login_rate_limit: max_failures_per_ip and max_failures_per_user must not be negative",
+		},
+		{
+			"negative duration",
+			"auth:\n  login_rate_limit:\n    lockout: -1m\n",
+			"auth.login_rate_limit: durations must not be negative",
+		},
+		{
+			"max_backoff below backoff",
+			"auth:\n  login_rate_limit:\n    backoff: 10s\n    max_backoff: 5s\n",
+			"auth.login_rate_limit: max_backoff (5s) must not be less than backoff (10s)",
+		},
+		{
+			"lockout below max_backoff",
+			"auth:\n  login_rate_limit:\n    max_backoff: 10m\n    lockout: 5m\n",
+			"auth.login_rate_limit: lockout (5m0s) must not be less than max_backoff (10m0s)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package dashboard
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/tokuhirom/dashyard/internal/model"
+	"github.com/tokuhirom/dashyard/internal/variable"
+)
+
+// anyDatasource is the Allowlist key of the queries sent to a datasource
+// chosen by a datasource variable, which may be any configured one.
+const anyDatasource = "$"
+
+// Allowlist matches the queries that the dashboards of a Store send: the
+// queries of panels and query variables, with each variable reference standing
+// for any value a client could select for it, along with the datasource they
+// are sent to. A user is only allowed the queries of the dashboards they see.
+type Allowlist struct {
+	store *Store
+	// dashboards holds the queries of each dashboard, by path.
+	dashboards map[string]*dashboardQueries
+}
+
+// dashboardQueries holds the queries of a dashboard.
+type dashboardQueries struct {
+	// queries holds the queries per datasource, keyed by the datasource as
+	// written in the dashboard: "" for the default one, anyDatasource when
+	// it references a variable.
+	queries map[string]*regexp.Regexp
+	// labelValues holds, per datasource and label, the series selectors that
+	// the label_values variable queries pass along with it.
+	labelValues map[labelValuesKey]*regexp.Regexp
+}
+
+// labelValuesKey is the datasource and label of label_values variable queries.
+type labelValuesKey struct {
+	datasource string
+	label      string
+}
+
+// NewAllowlist builds the Allowlist of the dashboards in s.
+func NewAllowlist(s *Store) *Allowlist {
+	a := &Allowlist{store: s, dashboards: make(map[string]*dashboardQueries)}
+	for _, d := range s.List() {
+		a.dashboards[d.Path] = newDashboardQueries(d)
+	}
+	return a
+}
+
+// newDashboardQueries builds the dashboardQueries of d.
+func newDashboardQueries(d *model.Dashboard) *dashboardQueries {
+	names := variableNames(d)
+	queries := make(map[string][]string)
+	matches := make(map[labelValuesKey][]string)
+	add := func(datasource, query string) {
+		if query != "" {
+			ds := datasourceKey(datasource)
+			queries[ds] = append(queries[ds], variable.Pattern(query, names))
+		}
+	}
+	for _, v := range d.Variables {
+		if v.Type != "" && v.Type != "query" {
+			continue
+		}
+		add(v.Datasource, v.Query)
+		if q, err := variable.ParseQuery(v.Query); err == nil && q.Kind == variable.LabelValues {
+			key := labelValuesKey{datasource: datasourceKey(v.Datasource), label: q.Label}
+			matches[key] = append(matches[key], variable.Pattern(q.Match, names))
+		}
+	}
+	for _, row := range d.Rows {
+		for _, p := range row.Panels {
+			add(p.Datasource, p.Query)
+			for _, q := range p.Queries {
+				add(p.Datasource, q.Query)
+			}
+		}
+	}
+
+	dq := &dashboardQueries{
+		queries:     make(map[string]*regexp.Regexp, len(queries)),
+		labelValues: make(map[labelValuesKey]*regexp.Regexp, len(matches)),
+	}
+	for ds, patterns := range queries {
+		dq.queries[ds] = compileAlternation(patterns)
+	}
+	for key, patterns := range matches {
+		dq.labelValues[key] = compileAlternation(patterns)
+	}
+	return dq
+}
+
+// datasourceKey returns the Allowlist key of the datasource of a panel or
+// variable, which is anyDatasource when it references a variable.
+func datasourceKey(datasource string) string {
+	if strings.Contains(datasource, "$") {
+		return anyDatasource
+	}
+	return datasource
+}
+
+// variableNames returns the names of the variables a query of d can reference.
+func variableNames(d *model.Dashboard) []string {
+	names := make([]string, 0, len(d.Variables))
+	for _, v := range d.Variables {
+		if v.Type != "adhoc_filters" {
+			names = append(names, v.Name)
+		}
+	}
+	return names
+}
+
+// compileAlternation compiles patterns into one anchored regexp matching any of
+// them, or returns nil when there are none.
+func compileAlternation(patterns []string) *regexp.Regexp {
+	if len(patterns) == 0 {
+		return nil
+	}
+	slices.Sort(patterns)
+	patterns = slices.Compact(patterns)
+	return regexp.MustCompile(`^(?s:(?:` + strings.Join(patterns, `)|(?:`) + `))$`)
+}
+
+// Allows reports whether a dashboard that the user userID, a member of
+// groups, sees can send query to datasource, either as the query of a panel
+// or as a variable query, with its variables substituted. An empty datasource
+// is the default one; callers check the default datasource under both its
+// name and "".
+func (a *Allowlist) Allows(userID string, groups []string, datasource, query string) bool {
+	return a.any(userID, groups, func(dq *dashboardQueries) bool {
+		return matchString(dq.queries[datasource], query) || matchString(dq.queries[anyDatasource], query)
+	})
+}
+
+// AllowsLabelValues reports whether a label_values variable query of a
+// dashboard that the user sees lists the values of label in datasource for
+// the series selector match, which is empty when the query has none.
+func (a *Allowlist) AllowsLabelValues(userID string, groups []string, datasource, label, match string) bool {
+	return a.any(userID, groups, func(dq *dashboardQueries) bool {
+		return matchString(dq.labelValues[labelValuesKey{datasource: datasource, label: label}], match) ||
+			matchString(dq.labelValues[labelValuesKey{datasource: anyDatasource, label: label}], match)
+	})
+}
+
+// any reports whether f holds for the queries of a dashboard the user sees.
+func (a *Allowlist) any(userID string, groups []string, f func(*dashboardQueries) bool) bool {
+	for path, dq := range a.dashboards {
+		if f(dq) && a.store.Visible(path, userID, groups) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchString reports whether re is not nil and matches s.
+func matchString(re *regexp.Regexp, s string) bool {
+	return re != nil && re.MatchString(s)
+}
@@ -0,0 +1,195 @@
+package dashboard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAllowlist(t *testing.T) {
+	dir := t.TempDir()
+	yaml := `title: Service
+variables:
+  - name: job
+    query: label_values(up, job)
+  - name: instance
+    type: query
+    query: label_values(up{job="$job"}, instance)
+    multi: true
+  - name: window
+    type: interval
+    query: 1m,5m
+  - name: filters
+    type: adhoc_filters
+  - name: ds
+    type: datasource
+  - name: logjob
+    query: label_values(log_lines_total, job)
+    datasource: logs-prom
+rows:
+  - title: Overview
+    panels:
+      - title: Requests
+        type: graph
+        query: sum(rate(http_requests_total{job="$job", instance=~"$instance"}[$window]))
+      - title: Table
+        type: table
+        queries:
+          - query: up{job="$job"}
+      - title: Logs
+        type: graph
+        datasource: logs-prom
+        query: log_lines_total
+      - title: Templated
+        type: graph
+        datasource: $ds
+        query: node_load1
+`
+	if err := os.WriteFile(filepath.Join(dir, "service.yaml"), []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a := NewAllowlist(store)
+
+	queries := []struct {
+		query string
+		want  bool
+	}{
+		{`sum(rate(http_requests_total{job="api", instance=~"web-1|web-2"}[5m]))`, true},
+		{`sum(rate(http_requests_total{job="api", instance=~".*"}[1h]))`, true},
+		{`up{job="api"}`, true},
+		{`label_values(up, job)`, true},
+		{`label_values(up{job="api"}, instance)`, true},
+		{`up`, false},
+		{`up{job="api"} or secret_metric`, false},
+		{`up{job="api"} or up{job=""}`, false},
+		{`sum(rate(http_requests_total{job="api", instance=~"a"}[5m] or secret[5m]))`, false},
+		{`sum(rate(http_requests_total{job="api", instance=~"a"}[5m-secret_metric]))`, false},
+		{`sum(rate(http_requests_total{job="api", instance=~"a"}[1+secret_metric]))`, false},
+		{`label_values(secret, job)`, false},
+	}
+	for _, tt := range queries {
+		if got := a.Allows("", nil, "", tt.query); got != tt.want {
+			t.Errorf("Allows(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	labelValues := []struct {
+		label, match string
+		want         bool
+	}{
+		{"job", "up", true},
+		{"instance", `up{job="api"}`, true},
+		{"job", "", false},
+		{"instance", "secret", false},
+		{"password", "up", false},
+	}
+	for _, tt := range labelValues {
+		if got := a.AllowsLabelValues("", nil, "", tt.label, tt.match); got != tt.want {
+			t.Errorf("AllowsLabelValues(%q, %q) = %v, want %v", tt.label, tt.match, got, tt.want)
+		}
+	}
+
+	datasources := []struct {
+		datasource, query string
+		want              bool
+	}{
+		{"logs-prom", "log_lines_total", true},
+		{"", "log_lines_total", false},
+		{"logs-prom", `up{job="api"}`, false},
+		{"other", `up{job="api"}`, false},
+		{"", "node_load1", true},
+		{"other", "node_load1", true},
+	}
+	for _, tt := range datasources {
+		if got := a.Allows("", nil, tt.datasource, tt.query); got != tt.want {
+			t.Errorf("Allows(%q, %q) = %v, want %v", tt.datasource, tt.query, got, tt.want)
+		}
+	}
+	if !a.AllowsLabelValues("", nil, "logs-prom", "job", "log_lines_total") {
+		t.Error("expected the label values of the variable's datasource to be allowed")
+	}
+	if a.AllowsLabelValues("", nil, "", "job", "log_lines_total") {
+		t.Error("expected the label values of another datasource to be rejected")
+	}
+}
+
+func TestStoreHolderRebuildsAllowlist(t *testing.T) {
+	dir := t.TempDir()
+	write := func(query string) *Store {
+		t.Helper()
+		yaml := "title: T\nrows:\n  - title: R\n    panels:\n      - title: P\n        type: graph\n        query: " + query + "\n"
+		if err := os.WriteFile(filepath.Join(dir, "t.yaml"), []byte(yaml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		store, err := LoadDir(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return store
+	}
+
+	holder := NewStoreHolder(write("up"))
+	if !holder.Allowlist().Allows("", nil, "", "up") {
+		t.Fatal("expected the initial query to be allowed")
+	}
+
+	holder.Replace(write("node_load1"))
+	if holder.Allowlist().Allows("", nil, "", "up") {
+		t.Error("expected the removed query to be rejected after Replace")
+	}
+	if !holder.Allowlist().Allows("", nil, "", "node_load1") {
+		t.Error("expected the new query to be allowed after Replace")
+	}
+}
+
+func TestAllowlistVisibleDashboards(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"public.yaml":        "title: Public\nrows:\n  - title: R\n    panels:\n      - title: P\n        type: graph\n        query: up\n",
+		"payments.yaml":      "title: Payments\naccess:\n  groups: [payments]\nrows:\n  - title: R\n    panels:\n      - title: P\n        type: graph\n        query: payments_total\n",
+		"admin/_folder.yaml": "access:\n  users: [root]\n",
+		"admin/secret.yaml":  "title: Secret\nvariables:\n  - name: job\n    query: label_values(secret_metric, job)\nrows:\n  - title: R\n    panels:\n      - title: P\n        type: graph\n        query: secret_metric\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a := NewAllowlist(store)
+
+	tests := []struct {
+		user   string
+		groups []string
+		query  string
+		want   bool
+	}{
+		{"alice", nil, "up", true},
+		{"alice", nil, "payments_total", false},
+		{"alice", []string{"payments"}, "payments_total", true},
+		{"alice", []string{"payments"}, "secret_metric", false},
+		{"root", nil, "secret_metric", true},
+	}
+	for _, tt := range tests {
+		if got := a.Allows(tt.user, tt.groups, "", tt.query); got != tt.want {
+			t.Errorf("Allows(%q, %v, %q) = %v, want %v", tt.user, tt.groups, tt.query, got, tt.want)
+		}
+	}
+	if a.AllowsLabelValues("alice", nil, "", "job", "secret_metric") {
+		t.Error("expected the label values of a hidden dashboard to be rejected")
+	}
+	if !a.AllowsLabelValues("root", nil, "", "job", "secret_metric") {
+		t.Error("expected the label values of a visible dashboard to be allowed")
+	}
+}
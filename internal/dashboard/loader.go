@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
@@ -14,12 +15,18 @@ import (
 
 var validPathRe = regexp.MustCompile(`^[a-zA-Z0-9_\-/]+$`)
 
+// folderFile is the name of the file holding the settings of a directory.
+const folderFile = "_folder"
+
 // Store holds loaded dashboards and provides lookup by path.
 type Store struct {
 	dashboards map[string]*model.Dashboard
 	sources    map[string]string
 	list       []*model.Dashboard
 	tree       []*model.DashboardTreeNode
+	// access holds, per dashboard path, the access rules of its folders and
+	// of the dashboard itself; all of them must allow a user.
+	access map[string][]*model.Access
 }
 
 // LoadDir recursively loads all .yaml files from the given directory
@@ -28,7 +35,9 @@ func LoadDir(dir string) (*Store, error) {
 	store := &Store{
 		dashboards: make(map[string]*model.Dashboard),
 		sources:    make(map[string]string),
+		access:     make(map[string][]*model.Access),
 	}
+	folders := make(map[string]*model.Access) // directory -> access rule
 
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -51,6 +60,17 @@ func LoadDir(dir string) (*Store, error) {
 		dashPath := strings.TrimSuffix(relPath, ext)
 		dashPath = filepath.ToSlash(dashPath)
 
+		if filepath.Base(dashPath) == folderFile {
+			access, err := loadFolder(path)
+			if err != nil {
+				return err
+			}
+			if access != nil {
+				folders[filepath.ToSlash(filepath.Dir(relPath))] = access
+			}
+			return nil
+		}
+
 		if err := validatePath(dashPath); err != nil {
 			return fmt.Errorf("invalid dashboard path %q: %w", dashPath, err)
 		}
@@ -83,10 +103,45 @@ func LoadDir(dir string) (*Store, error) {
 		return store.list[i].Path < store.list[j].Path
 	})
 
+	// Collect the access rules of each dashboard, outermost folder first.
+	for _, d := range store.list {
+		var rules []*model.Access
+		dir := "."
+		for _, part := range strings.Split(d.Path, "/") {
+			if a := folders[dir]; a != nil {
+				rules = append(rules, a)
+			}
+			dir = strings.TrimPrefix(dir+"/"+part, "./")
+		}
+		if d.Access != nil {
+			rules = append(rules, d.Access)
+		}
+		store.access[d.Path] = rules
+	}
+
 	store.tree = buildTree(store.list)
 	return store, nil
 }
 
+// loadFolder reads the _folder.yaml at path and returns its access rule, if
+// any.
+func loadFolder(path string) (*model.Access, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var f model.Folder
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if f.Access != nil {
+		if err := f.Access.Validate(); err != nil {
+			return nil, fmt.Errorf("validating %s: %w", path, err)
+		}
+	}
+	return f.Access, nil
+}
+
 // Get returns a dashboard by its path, or nil if not found.
 func (s *Store) Get(path string) *model.Dashboard {
 	return s.dashboards[path]
@@ -108,6 +163,51 @@ func (s *Store) GetSource(path string) (string, bool) {
 	return src, ok
 }
 
+// Visible reports whether the user with userID, a member of groups, may see
+// the dashboard at path: every access rule of the folders above it and of the
+// dashboard itself must list them. Dashboards without rules are visible to
+// everyone.
+func (s *Store) Visible(path, userID string, groups []string) bool {
+	if s.dashboards[path] == nil {
+		return false
+	}
+	for _, a := range s.access[path] {
+		if !a.Allows(userID, groups) {
+			return false
+		}
+	}
+	return true
+}
+
+// UsesGroup reports whether an access rule of a dashboard or of a folder
+// above one lists the group.
+func (s *Store) UsesGroup(name string) bool {
+	for _, rules := range s.access {
+		for _, a := range rules {
+			if slices.Contains(a.Groups, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// VisibleList returns the dashboards the user may see, sorted by path.
+func (s *Store) VisibleList(userID string, groups []string) []*model.Dashboard {
+	var out []*model.Dashboard
+	for _, d := range s.list {
+		if s.Visible(d.Path, userID, groups) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// VisibleTree returns the navigation tree of the dashboards the user may see.
+func (s *Store) VisibleTree(userID string, groups []string) []*model.DashboardTreeNode {
+	return buildTree(s.VisibleList(userID, groups))
+}
+
 func validatePath(path string) error {
 	if strings.Contains(path, "..") {
 		return fmt.Errorf("path must not contain '..'")
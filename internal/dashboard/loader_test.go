@@ -3,6 +3,8 @@ package dashboard
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -186,3 +188,78 @@ func TestBuildTree(t *testing.T) {
 		t.Error("missing 'overview' node in tree")
 	}
 }
+
+func TestLoadDirAccess(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dashboardYAML := func(title, access string) string {
+		return "title: " + title + "\n" + access + "rows:\n  - title: R\n    panels:\n      - title: P\n        type: graph\n        query: up\n"
+	}
+	write("public.yaml", dashboardYAML("Public", ""))
+	write("payments/_folder.yaml", "access:\n  groups: [payments]\n")
+	write("payments/overview.yaml", dashboardYAML("Payments", ""))
+	write("payments/secret.yaml", dashboardYAML("Secret", "access:\n  users: [alice]\n"))
+	write("ops/admin.yaml", dashboardYAML("Admin", "access:\n  users: [root]\n"))
+
+	store, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.Get("payments/_folder") != nil {
+		t.Error("expected _folder.yaml not to be loaded as a dashboard")
+	}
+
+	tests := []struct {
+		user   string
+		groups []string
+		want   []string
+	}{
+		{"alice", []string{"payments"}, []string{"payments/overview", "payments/secret", "public"}},
+		{"bob", []string{"payments"}, []string{"payments/overview", "public"}},
+		{"alice", nil, []string{"public"}},
+		{"root", nil, []string{"ops/admin", "public"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, d := range store.VisibleList(tt.user, tt.groups) {
+			got = append(got, d.Path)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("VisibleList(%q, %v) = %v, want %v", tt.user, tt.groups, got, tt.want)
+		}
+	}
+
+	if store.Visible("payments/overview", "bob", nil) {
+		t.Error("expected the folder rule to hide payments/overview from bob")
+	}
+	if store.Visible("missing", "alice", nil) {
+		t.Error("expected missing dashboards not to be visible")
+	}
+	tree := store.VisibleTree("bob", nil)
+	if len(tree) != 1 || tree[0].Path != "public" {
+		t.Errorf("expected only the public dashboard in the tree, got %+v", tree)
+	}
+	if !store.UsesGroup("payments") || store.UsesGroup("sales") {
+		t.Error("expected only the groups of access rules to be used")
+	}
+}
+
+func TestLoadDirInvalidFolder(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "_folder.yaml"), []byte("access: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "access must list users or groups") {
+		t.Errorf("expected access validation error, got %v", err)
+	}
+}
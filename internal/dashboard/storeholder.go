@@ -3,24 +3,37 @@ package dashboard
 import "sync/atomic"
 
 // StoreHolder provides lock-free concurrent access to a Store that can be
-// atomically replaced (e.g. on dashboard file changes).
+// atomically replaced (e.g. on dashboard file changes), along with the
+// Allowlist of queries built from it.
 type StoreHolder struct {
-	p atomic.Pointer[Store]
+	p atomic.Pointer[snapshot]
+}
+
+// snapshot pairs a Store with its Allowlist so that both are swapped together.
+type snapshot struct {
+	store     *Store
+	allowlist *Allowlist
 }
 
 // NewStoreHolder creates a StoreHolder initialised with the given Store.
 func NewStoreHolder(s *Store) *StoreHolder {
 	h := &StoreHolder{}
-	h.p.Store(s)
+	h.Replace(s)
 	return h
 }
 
 // Store returns the current Store. Safe for concurrent use.
 func (h *StoreHolder) Store() *Store {
-	return h.p.Load()
+	return h.p.Load().store
+}
+
+// Allowlist returns the Allowlist of the current Store. Safe for concurrent use.
+func (h *StoreHolder) Allowlist() *Allowlist {
+	return h.p.Load().allowlist
 }
 
-// Replace atomically swaps the current Store with a new one.
+// Replace atomically swaps the current Store with a new one, rebuilding the
+// Allowlist.
 func (h *StoreHolder) Replace(s *Store) {
-	h.p.Store(s)
+	h.p.Store(&snapshot{store: s, allowlist: NewAllowlist(s)})
 }
@@ -0,0 +1,178 @@
+package datasource
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/metrics"
+)
+
+// cachingDatasource wraps a Datasource with a size-bounded LRU cache for
+// query_range results. Start and end are aligned to the step so that
+// requests issued a few seconds apart (e.g. several browsers showing the
+// same dashboard) share a cache entry.
+type cachingDatasource struct {
+	Datasource
+
+	name string
+	cfg  config.QueryCacheConfig
+	now  func() time.Time
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key        string
+	body       []byte
+	statusCode int
+	expires    time.Time
+}
+
+// newCachingDatasource returns a Datasource that caches successful query_range
+// responses of next according to cfg. name is used as the metrics label.
+func newCachingDatasource(name string, next Datasource, cfg config.QueryCacheConfig) *cachingDatasource {
+	return &cachingDatasource{
+		Datasource: next,
+		name:       name,
+		cfg:        cfg,
+		now:        time.Now,
+		lru:        list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// QueryRange serves the query from the cache when possible. Requests whose
+// start, end or step cannot be parsed bypass the cache entirely.
+func (c *cachingDatasource) QueryRange(ctx context.Context, query, start, end, step string) (io.ReadCloser, int, error) {
+	stepSec, ok := parseStep(step)
+	if !ok {
+		return c.Datasource.QueryRange(ctx, query, start, end, step)
+	}
+	startSec, ok1 := parseUnixSeconds(start)
+	endSec, ok2 := parseUnixSeconds(end)
+	if !ok1 || !ok2 {
+		return c.Datasource.QueryRange(ctx, query, start, end, step)
+	}
+
+	alignedStart := alignToStep(startSec, stepSec)
+	alignedEnd := alignToStep(endSec, stepSec)
+	start = strconv.FormatInt(alignedStart, 10)
+	end = strconv.FormatInt(alignedEnd, 10)
+	key := c.name + "\x00" + query + "\x00" + start + "\x00" + end + "\x00" + step
+
+	if body, statusCode, ok := c.get(key); ok {
+		metrics.QueryCacheHitsTotal.WithLabelValues(c.name).Inc()
+		return io.NopCloser(bytes.NewReader(body)), statusCode, nil
+	}
+	metrics.QueryCacheMissesTotal.WithLabelValues(c.name).Inc()
+
+	rc, statusCode, err := c.Datasource.QueryRange(ctx, query, start, end, step)
+	if err != nil {
+		return nil, 0, err
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		return rc, statusCode, nil
+	}
+	defer func() { _ = rc.Close() }()
+
+	body, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading response: %w", err)
+	}
+
+	// The tail of the range is still receiving samples, so results that reach
+	// into it are only kept briefly to keep auto-refreshing dashboards current.
+	ttl := c.cfg.TTL
+	if c.now().Sub(time.Unix(alignedEnd, 0)) < c.cfg.RecentWindow {
+		ttl = c.cfg.RecentTTL
+	}
+	c.put(key, body, statusCode, ttl)
+
+	return io.NopCloser(bytes.NewReader(body)), statusCode, nil
+}
+
+// Len returns the number of entries currently held in the cache.
+func (c *cachingDatasource) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *cachingDatasource) get(key string) ([]byte, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, 0, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.lru.Remove(el)
+		delete(c.items, key)
+		return nil, 0, false
+	}
+	c.lru.MoveToFront(el)
+	return entry.body, entry.statusCode, true
+}
+
+func (c *cachingDatasource) put(key string, body []byte, statusCode int, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, body: body, statusCode: statusCode, expires: c.now().Add(ttl)}
+	if el, ok := c.items[key]; ok {
+		el.Value = entry
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.cfg.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// parseStep parses a Prometheus step parameter (duration string or float seconds)
+// into whole seconds.
+func parseStep(step string) (int64, bool) {
+	if d, err := time.ParseDuration(step); err == nil {
+		sec := int64(d / time.Second)
+		return sec, sec > 0
+	}
+	f, err := strconv.ParseFloat(step, 64)
+	if err != nil || f < 1 || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// parseUnixSeconds parses a Prometheus timestamp given as Unix seconds or RFC 3339.
+func parseUnixSeconds(s string) (int64, bool) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, false
+		}
+		return int64(math.Floor(f)), true
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.Unix(), true
+	}
+	return 0, false
+}
+
+func alignToStep(ts, step int64) int64 {
+	return ts - ((ts%step)+step)%step
+}
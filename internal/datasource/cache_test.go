@@ -0,0 +1,255 @@
+package datasource
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+// fakeDatasource records QueryRange calls and returns a fixed response.
+type fakeDatasource struct {
+	calls      int
+	lastStart  string
+	lastEnd    string
+	statusCode int
+	body       string
+}
+
+func (f *fakeDatasource) QueryRange(_ context.Context, _, start, end, _ string) (io.ReadCloser, int, error) {
+	f.calls++
+	f.lastStart = start
+	f.lastEnd = end
+	statusCode := f.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	return io.NopCloser(strings.NewReader(f.body)), statusCode, nil
+}
+
+func (f *fakeDatasource) Query(context.Context, string, string) (io.ReadCloser, int, error) {
+	return io.NopCloser(strings.NewReader(`{"status":"success","data":{"resultType":"vector","result":[]}}`)), http.StatusOK, nil
+}
+
+func (f *fakeDatasource) Ping(context.Context) error { return nil }
+
+func (f *fakeDatasource) LabelValues(context.Context, string, string) (io.ReadCloser, int, error) {
+	return io.NopCloser(strings.NewReader(`{"status":"success","data":[]}`)), http.StatusOK, nil
+}
+
+func newTestCache(next Datasource, now time.Time) *cachingDatasource {
+	c := newCachingDatasource("test", next, config.QueryCacheConfig{
+		MaxEntries:   2,
+		TTL:          5 * time.Minute,
+		RecentTTL:    10 * time.Second,
+		RecentWindow: 5 * time.Minute,
+	})
+	c.now = func() time.Time { return now }
+	return c
+}
+
+func readAll(t *testing.T, rc io.ReadCloser) string {
+	t.Helper()
+	defer func() { _ = rc.Close() }()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("unexpected error reading body: %v", err)
+	}
+	return string(data)
+}
+
+func TestCacheHitWithinSameStep(t *testing.T) {
+	fake := &fakeDatasource{body: `{"status":"success"}`}
+	c := newTestCache(fake, time.Unix(100000, 0))
+
+	body, status, err := c.QueryRange(context.Background(), "up", "1003", "2007", "15s")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != http.StatusOK {
+		t.Errorf("expected 200, got %d", status)
+	}
+	if got := readAll(t, body); got != `{"status":"success"}` {
+		t.Errorf("unexpected body %q", got)
+	}
+	if fake.lastStart != "990" || fake.lastEnd != "1995" {
+		t.Errorf("expected aligned range 990-1995, got %s-%s", fake.lastStart, fake.lastEnd)
+	}
+
+	// Slightly shifted range aligns to the same step boundaries.
+	body, _, err = c.QueryRange(context.Background(), "up", "1000", "2000", "15s")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readAll(t, body); got != `{"status":"success"}` {
+		t.Errorf("unexpected cached body %q", got)
+	}
+	if fake.calls != 1 {
+		t.Errorf("expected 1 upstream call, got %d", fake.calls)
+	}
+}
+
+func TestCacheKeyIncludesQueryAndStep(t *testing.T) {
+	fake := &fakeDatasource{body: `{}`}
+	c := newTestCache(fake, time.Unix(100000, 0))
+
+	for _, q := range []struct{ query, step string }{
+		{"up", "15s"},
+		{"down", "15s"},
+		{"up", "30s"},
+	} {
+		body, _, err := c.QueryRange(context.Background(), q.query, "1000", "2000", q.step)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = body.Close()
+	}
+	if fake.calls != 3 {
+		t.Errorf("expected 3 upstream calls, got %d", fake.calls)
+	}
+}
+
+func TestCacheRecentTTL(t *testing.T) {
+	fake := &fakeDatasource{body: `{}`}
+	now := time.Unix(100000, 0)
+	c := newTestCache(fake, now)
+
+	// End is "now", so the entry only lives for RecentTTL.
+	for range 2 {
+		body, _, _ := c.QueryRange(context.Background(), "up", "96400", "100000", "60s")
+		_ = body.Close()
+	}
+	if fake.calls != 1 {
+		t.Fatalf("expected 1 upstream call before expiry, got %d", fake.calls)
+	}
+
+	c.now = func() time.Time { return now.Add(11 * time.Second) }
+	body, _, _ := c.QueryRange(context.Background(), "up", "96400", "100000", "60s")
+	_ = body.Close()
+	if fake.calls != 2 {
+		t.Errorf("expected recent entry to expire after RecentTTL, got %d calls", fake.calls)
+	}
+}
+
+func TestCacheHistoricalTTL(t *testing.T) {
+	fake := &fakeDatasource{body: `{}`}
+	now := time.Unix(100000, 0)
+	c := newTestCache(fake, now)
+
+	body, _, _ := c.QueryRange(context.Background(), "up", "1000", "2000", "60s")
+	_ = body.Close()
+
+	c.now = func() time.Time { return now.Add(time.Minute) }
+	body, _, _ = c.QueryRange(context.Background(), "up", "1000", "2000", "60s")
+	_ = body.Close()
+	if fake.calls != 1 {
+		t.Errorf("expected historical entry to outlive RecentTTL, got %d calls", fake.calls)
+	}
+
+	c.now = func() time.Time { return now.Add(6 * time.Minute) }
+	body, _, _ = c.QueryRange(context.Background(), "up", "1000", "2000", "60s")
+	_ = body.Close()
+	if fake.calls != 2 {
+		t.Errorf("expected historical entry to expire after TTL, got %d calls", fake.calls)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	fake := &fakeDatasource{body: `{}`}
+	c := newTestCache(fake, time.Unix(100000, 0))
+
+	query := func(q string) {
+		body, _, err := c.QueryRange(context.Background(), q, "1000", "2000", "15s")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = body.Close()
+	}
+
+	query("a")
+	query("b")
+	query("a") // hit; "b" is now least recently used
+	query("c") // evicts "b"
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+	if fake.calls != 3 {
+		t.Fatalf("expected 3 upstream calls, got %d", fake.calls)
+	}
+
+	query("a")
+	if fake.calls != 3 {
+		t.Errorf("expected 'a' to still be cached, got %d calls", fake.calls)
+	}
+	query("b")
+	if fake.calls != 4 {
+		t.Errorf("expected 'b' to have been evicted, got %d calls", fake.calls)
+	}
+}
+
+func TestCacheSkipsErrorResponses(t *testing.T) {
+	fake := &fakeDatasource{body: `{"status":"error"}`, statusCode: http.StatusBadRequest}
+	c := newTestCache(fake, time.Unix(100000, 0))
+
+	for range 2 {
+		body, status, err := c.QueryRange(context.Background(), "up{", "1000", "2000", "15s")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if status != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", status)
+		}
+		_ = body.Close()
+	}
+	if fake.calls != 2 {
+		t.Errorf("expected error responses not to be cached, got %d calls", fake.calls)
+	}
+}
+
+func TestCacheBypassesUnparseableParams(t *testing.T) {
+	fake := &fakeDatasource{body: `{}`}
+	c := newTestCache(fake, time.Unix(100000, 0))
+
+	for range 2 {
+		body, _, err := c.QueryRange(context.Background(), "up", "now-1h", "now", "15s")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = body.Close()
+	}
+	if fake.calls != 2 {
+		t.Errorf("expected unparseable requests to bypass the cache, got %d calls", fake.calls)
+	}
+	if fake.lastStart != "now-1h" {
+		t.Errorf("expected original start to be passed through, got %q", fake.lastStart)
+	}
+	if c.Len() != 0 {
+		t.Errorf("expected empty cache, got %d entries", c.Len())
+	}
+}
+
+func TestParseStep(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"15s", 15, true},
+		{"1m", 60, true},
+		{"30", 30, true},
+		{"2.5", 2, true},
+		{"500ms", 0, false},
+		{"0", 0, false},
+		{"abc", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseStep(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseStep(%q) = %d, %v; want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
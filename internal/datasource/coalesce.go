@@ -0,0 +1,90 @@
+package datasource
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/tokuhirom/dashyard/internal/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+// coalescingDatasource wraps a Datasource so that concurrent identical
+// QueryRange, Query and LabelValues calls share a single upstream request. This
+// matters when a dashboard with repeated rows is opened by many users at once.
+type coalescingDatasource struct {
+	Datasource
+
+	name  string
+	group singleflight.Group
+}
+
+type sharedResponse struct {
+	body       []byte
+	statusCode int
+}
+
+// newCoalescingDatasource returns a Datasource that deduplicates in-flight
+// requests to next. name is used as the metrics label.
+func newCoalescingDatasource(name string, next Datasource) *coalescingDatasource {
+	return &coalescingDatasource{Datasource: next, name: name}
+}
+
+// QueryRange performs the query, sharing the upstream call with any identical in-flight query.
+func (c *coalescingDatasource) QueryRange(ctx context.Context, query, start, end, step string) (io.ReadCloser, int, error) {
+	key := "query_range\x00" + query + "\x00" + start + "\x00" + end + "\x00" + step
+	return c.do(ctx, "query_range", key, func(ctx context.Context) (io.ReadCloser, int, error) {
+		return c.Datasource.QueryRange(ctx, query, start, end, step)
+	})
+}
+
+// Query performs the instant query, sharing the upstream call with any identical in-flight query.
+func (c *coalescingDatasource) Query(ctx context.Context, query, ts string) (io.ReadCloser, int, error) {
+	key := "query\x00" + query + "\x00" + ts
+	return c.do(ctx, "query", key, func(ctx context.Context) (io.ReadCloser, int, error) {
+		return c.Datasource.Query(ctx, query, ts)
+	})
+}
+
+// LabelValues fetches label values, sharing the upstream call with any identical in-flight request.
+func (c *coalescingDatasource) LabelValues(ctx context.Context, label, match string) (io.ReadCloser, int, error) {
+	key := "label_values\x00" + label + "\x00" + match
+	return c.do(ctx, "label_values", key, func(ctx context.Context) (io.ReadCloser, int, error) {
+		return c.Datasource.LabelValues(ctx, label, match)
+	})
+}
+
+func (c *coalescingDatasource) do(ctx context.Context, endpoint, key string, fn func(context.Context) (io.ReadCloser, int, error)) (io.ReadCloser, int, error) {
+	leader := false
+	ch := c.group.DoChan(key, func() (any, error) {
+		leader = true
+		// The upstream call is shared, so it must not be aborted when the
+		// caller that happened to start it goes away. The HTTP client timeout
+		// still bounds it.
+		rc, statusCode, err := fn(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = rc.Close() }()
+		body, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, fmt.Errorf("reading response: %w", err)
+		}
+		return &sharedResponse{body: body, statusCode: statusCode}, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	case res := <-ch:
+		if !leader {
+			metrics.DatasourceDeduplicatedTotal.WithLabelValues(c.name, endpoint).Inc()
+		}
+		if res.Err != nil {
+			return nil, 0, res.Err
+		}
+		resp := res.Val.(*sharedResponse)
+		return io.NopCloser(bytes.NewReader(resp.body)), resp.statusCode, nil
+	}
+}
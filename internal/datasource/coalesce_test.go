@@ -0,0 +1,206 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/tokuhirom/dashyard/internal/metrics"
+)
+
+// blockingDatasource blocks every call until release is closed.
+type blockingDatasource struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func newBlockingDatasource() *blockingDatasource {
+	return &blockingDatasource{
+		started: make(chan struct{}, 100),
+		release: make(chan struct{}),
+	}
+}
+
+func (b *blockingDatasource) wait(ctx context.Context) (io.ReadCloser, int, error) {
+	b.calls.Add(1)
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	}
+	if b.err != nil {
+		return nil, 0, b.err
+	}
+	return io.NopCloser(strings.NewReader(`{"status":"success"}`)), http.StatusOK, nil
+}
+
+func (b *blockingDatasource) QueryRange(ctx context.Context, _, _, _, _ string) (io.ReadCloser, int, error) {
+	return b.wait(ctx)
+}
+
+func (b *blockingDatasource) Query(ctx context.Context, _, _ string) (io.ReadCloser, int, error) {
+	return b.wait(ctx)
+}
+
+func (b *blockingDatasource) Ping(context.Context) error { return nil }
+
+func (b *blockingDatasource) LabelValues(ctx context.Context, _, _ string) (io.ReadCloser, int, error) {
+	return b.wait(ctx)
+}
+
+func TestCoalesceConcurrentQueryRange(t *testing.T) {
+	upstream := newBlockingDatasource()
+	c := newCoalescingDatasource("coalesce-qr", upstream)
+
+	const n = 5
+	var wg sync.WaitGroup
+	bodies := make([]string, n)
+	errs := make([]error, n)
+
+	call := func(i int) {
+		defer wg.Done()
+		rc, _, err := c.QueryRange(context.Background(), "up", "1000", "2000", "15s")
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		defer func() { _ = rc.Close() }()
+		data, _ := io.ReadAll(rc)
+		bodies[i] = string(data)
+	}
+
+	wg.Add(1)
+	go call(0)
+	<-upstream.started
+
+	wg.Add(n - 1)
+	for i := 1; i < n; i++ {
+		go call(i)
+	}
+	// Give the followers time to join the in-flight call.
+	time.Sleep(100 * time.Millisecond)
+	close(upstream.release)
+	wg.Wait()
+
+	if got := upstream.calls.Load(); got != 1 {
+		t.Errorf("expected 1 upstream call, got %d", got)
+	}
+	for i := range n {
+		if errs[i] != nil {
+			t.Errorf("caller %d: unexpected error: %v", i, errs[i])
+		}
+		if bodies[i] != `{"status":"success"}` {
+			t.Errorf("caller %d: unexpected body %q", i, bodies[i])
+		}
+	}
+	dedup := testutil.ToFloat64(metrics.DatasourceDeduplicatedTotal.WithLabelValues("coalesce-qr", "query_range"))
+	if dedup != n-1 {
+		t.Errorf("expected %d deduplicated calls, got %v", n-1, dedup)
+	}
+}
+
+func TestCoalesceDifferentKeysNotShared(t *testing.T) {
+	upstream := newBlockingDatasource()
+	close(upstream.release)
+	c := newCoalescingDatasource("coalesce-keys", upstream)
+
+	for _, label := range []string{"a", "b"} {
+		rc, _, err := c.LabelValues(context.Background(), label, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = rc.Close()
+	}
+	rc, _, err := c.QueryRange(context.Background(), "a", "1", "2", "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = rc.Close()
+	rc, _, err = c.Query(context.Background(), "a", "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = rc.Close()
+
+	if got := upstream.calls.Load(); got != 4 {
+		t.Errorf("expected 4 upstream calls, got %d", got)
+	}
+}
+
+func TestCoalesceSharesErrors(t *testing.T) {
+	upstream := newBlockingDatasource()
+	upstream.err = errors.New("connection refused")
+	c := newCoalescingDatasource("coalesce-err", upstream)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _, errs[0] = c.LabelValues(context.Background(), "job", "")
+	}()
+	<-upstream.started
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _, errs[1] = c.LabelValues(context.Background(), "job", "")
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(upstream.release)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil {
+			t.Errorf("caller %d: expected error", i)
+		}
+	}
+	if got := upstream.calls.Load(); got != 1 {
+		t.Errorf("expected 1 upstream call, got %d", got)
+	}
+}
+
+func TestCoalesceCallerCancellation(t *testing.T) {
+	upstream := newBlockingDatasource()
+	c := newCoalescingDatasource("coalesce-cancel", upstream)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := c.QueryRange(ctx, "up", "1", "2", "1")
+		done <- err
+	}()
+	<-upstream.started
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// The shared upstream call keeps running for other callers.
+	result := make(chan error, 1)
+	go func() {
+		rc, _, err := c.QueryRange(context.Background(), "up", "1", "2", "1")
+		if err == nil {
+			_ = rc.Close()
+		}
+		result <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(upstream.release)
+	if err := <-result; err != nil {
+		t.Errorf("expected follower to receive the shared result, got %v", err)
+	}
+	if got := upstream.calls.Load(); got != 1 {
+		t.Errorf("expected 1 upstream call, got %d", got)
+	}
+}
@@ -9,6 +9,20 @@ import (
 // Handlers use this interface so they are not coupled to a specific implementation.
 type Datasource interface {
 	QueryRange(ctx context.Context, query, start, end, step string) (io.ReadCloser, int, error)
+	Query(ctx context.Context, query, ts string) (io.ReadCloser, int, error)
 	Ping(ctx context.Context) error
 	LabelValues(ctx context.Context, label, match string) (io.ReadCloser, int, error)
 }
+
+// LogsDatasource is implemented by datasources that can return raw log lines
+// (currently Loki). direction is "backward" (newest first) or "forward".
+type LogsDatasource interface {
+	QueryLogs(ctx context.Context, query, start, end string, limit int, direction string) (io.ReadCloser, int, error)
+}
+
+// MetadataDatasource is implemented by datasources that can list label names
+// and label values within a time range (currently Prometheus).
+type MetadataDatasource interface {
+	LabelNames(ctx context.Context, match, start, end string) (io.ReadCloser, int, error)
+	LabelValuesInRange(ctx context.Context, label, match, start, end string) (io.ReadCloser, int, error)
+}
@@ -5,21 +5,27 @@ import (
 	"sort"
 
 	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/loki"
 	"github.com/tokuhirom/dashyard/internal/prometheus"
 )
 
 // Registry manages named datasource clients created from datasource configs.
 type Registry struct {
 	clients     map[string]Datasource
+	logs        map[string]LogsDatasource
+	metadata    map[string]MetadataDatasource
 	defaultName string
 }
 
 // NewRegistry creates a Registry from the given datasource configurations.
 func NewRegistry(datasources []config.DatasourceConfig) (*Registry, error) {
 	clients := make(map[string]Datasource, len(datasources))
+	logs := make(map[string]LogsDatasource)
+	metadata := make(map[string]MetadataDatasource)
 	var defaultName string
 
 	for _, ds := range datasources {
+		var client Datasource
 		switch ds.Type {
 		case "prometheus":
 			var opts []prometheus.ClientOption
@@ -30,10 +36,29 @@ func NewRegistry(datasources []config.DatasourceConfig) (*Registry, error) {
 				}
 				opts = append(opts, prometheus.WithHeaders(headers))
 			}
-			clients[ds.Name] = prometheus.NewClient(ds.URL, ds.Timeout, opts...)
+			pc := prometheus.NewClient(ds.URL, ds.Timeout, opts...)
+			client = pc
+			metadata[ds.Name] = pc
+		case "loki":
+			var opts []loki.ClientOption
+			if len(ds.Headers) > 0 {
+				headers := make([]loki.Header, len(ds.Headers))
+				for i, h := range ds.Headers {
+					headers[i] = loki.Header{Name: h.Name, Value: h.Value}
+				}
+				opts = append(opts, loki.WithHeaders(headers))
+			}
+			lc := loki.NewClient(ds.URL, ds.Timeout, opts...)
+			client = lc
+			logs[ds.Name] = lc
 		default:
 			return nil, fmt.Errorf("unsupported datasource type %q for %q", ds.Type, ds.Name)
 		}
+		client = newCoalescingDatasource(ds.Name, client)
+		if ds.Cache != nil {
+			client = newCachingDatasource(ds.Name, client, *ds.Cache)
+		}
+		clients[ds.Name] = client
 		if ds.Default {
 			defaultName = ds.Name
 		}
@@ -41,6 +66,8 @@ func NewRegistry(datasources []config.DatasourceConfig) (*Registry, error) {
 
 	return &Registry{
 		clients:     clients,
+		logs:        logs,
+		metadata:    metadata,
 		defaultName: defaultName,
 	}, nil
 }
@@ -58,6 +85,48 @@ func (r *Registry) Get(name string) (Datasource, error) {
 	return client, nil
 }
 
+// GetLogs returns the log-capable datasource for the given name.
+// If name is empty, the default datasource is used.
+func (r *Registry) GetLogs(name string) (LogsDatasource, error) {
+	if name == "" {
+		name = r.defaultName
+	}
+	if _, ok := r.clients[name]; !ok {
+		return nil, fmt.Errorf("unknown datasource %q", name)
+	}
+	client, ok := r.logs[name]
+	if !ok {
+		return nil, fmt.Errorf("datasource %q does not support log queries", name)
+	}
+	return client, nil
+}
+
+// GetMetadata returns the datasource for the given name as one that can list
+// label names and metric names. If name is empty, the default datasource is used.
+func (r *Registry) GetMetadata(name string) (MetadataDatasource, error) {
+	if name == "" {
+		name = r.defaultName
+	}
+	if _, ok := r.clients[name]; !ok {
+		return nil, fmt.Errorf("unknown datasource %q", name)
+	}
+	client, ok := r.metadata[name]
+	if !ok {
+		return nil, fmt.Errorf("datasource %q does not support label names or metric names queries", name)
+	}
+	return client, nil
+}
+
+// AcceptsPromQL reports whether the named datasource takes PromQL queries
+// (currently Prometheus). If name is empty, the default datasource is used.
+func (r *Registry) AcceptsPromQL(name string) bool {
+	if name == "" {
+		name = r.defaultName
+	}
+	_, ok := r.metadata[name]
+	return ok
+}
+
 // Default returns the default datasource.
 func (r *Registry) Default() Datasource {
 	return r.clients[r.defaultName]
@@ -128,3 +128,92 @@ func TestNewRegistryUnsupportedType(t *testing.T) {
 		t.Fatal("expected error for unsupported datasource type")
 	}
 }
+
+func TestNewRegistryWithCache(t *testing.T) {
+	datasources := []config.DatasourceConfig{
+		{Name: "cached", Type: "prometheus", URL: "http://a:9090", Timeout: 30 * time.Second, Default: true,
+			Cache: &config.QueryCacheConfig{MaxEntries: 10, TTL: time.Minute, RecentTTL: time.Second, RecentWindow: time.Minute}},
+		{Name: "plain", Type: "prometheus", URL: "http://b:9090", Timeout: 30 * time.Second},
+	}
+
+	reg, err := NewRegistry(datasources)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client, _ := reg.Get("cached")
+	if _, ok := client.(*cachingDatasource); !ok {
+		t.Errorf("expected cached datasource to be wrapped, got %T", client)
+	}
+	client, _ = reg.Get("plain")
+	if _, ok := client.(*cachingDatasource); ok {
+		t.Error("expected datasource without cache config not to be wrapped")
+	}
+}
+
+func TestRegistryGetLogs(t *testing.T) {
+	datasources := []config.DatasourceConfig{
+		{Name: "prom", Type: "prometheus", URL: "http://prom:9090", Timeout: 30 * time.Second, Default: true},
+		{Name: "logs", Type: "loki", URL: "http://loki:3100", Timeout: 30 * time.Second},
+	}
+
+	reg, err := NewRegistry(datasources)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := reg.Get("logs"); err != nil {
+		t.Errorf("expected loki datasource to be usable for metric queries, got %v", err)
+	}
+	if client, err := reg.GetLogs("logs"); err != nil || client == nil {
+		t.Errorf("expected logs client for 'logs', got %v, %v", client, err)
+	}
+	if _, err := reg.GetLogs("prom"); err == nil {
+		t.Error("expected error for prometheus datasource without log support")
+	}
+	if _, err := reg.GetLogs(""); err == nil {
+		t.Error("expected error when the default datasource has no log support")
+	}
+	if _, err := reg.GetLogs("nonexistent"); err == nil {
+		t.Error("expected error for unknown datasource")
+	}
+}
+
+func TestRegistryGetMetadata(t *testing.T) {
+	datasources := []config.DatasourceConfig{
+		{Name: "prom", Type: "prometheus", URL: "http://prom:9090", Timeout: 30 * time.Second, Default: true},
+		{Name: "logs", Type: "loki", URL: "http://loki:3100", Timeout: 30 * time.Second},
+	}
+
+	reg, err := NewRegistry(datasources)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if client, err := reg.GetMetadata(""); err != nil || client == nil {
+		t.Errorf("expected metadata client for the default datasource, got %v, %v", client, err)
+	}
+	if _, err := reg.GetMetadata("logs"); err == nil {
+		t.Error("expected error for loki datasource without metadata support")
+	}
+	if _, err := reg.GetMetadata("nonexistent"); err == nil {
+		t.Error("expected error for unknown datasource")
+	}
+}
+
+func TestRegistryAcceptsPromQL(t *testing.T) {
+	reg, err := NewRegistry([]config.DatasourceConfig{
+		{Name: "prom", Type: "prometheus", URL: "http://prom:9090", Timeout: 30 * time.Second, Default: true},
+		{Name: "logs", Type: "loki", URL: "http://loki:3100", Timeout: 30 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reg.AcceptsPromQL("") || !reg.AcceptsPromQL("prom") {
+		t.Error("expected the prometheus datasource to accept PromQL")
+	}
+	if reg.AcceptsPromQL("logs") || reg.AcceptsPromQL("nonexistent") {
+		t.Error("expected loki and unknown datasources not to accept PromQL")
+	}
+}
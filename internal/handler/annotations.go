@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/dashboard"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+	"github.com/tokuhirom/dashyard/internal/model"
+	"github.com/tokuhirom/dashyard/internal/series"
+)
+
+// AnnotationsHandler handles GET /api/annotations - evaluates the annotation
+// sources of a dashboard over a time range.
+type AnnotationsHandler struct {
+	holder   *dashboard.StoreHolder
+	registry *datasource.Registry
+}
+
+// NewAnnotationsHandler creates a new AnnotationsHandler.
+func NewAnnotationsHandler(holder *dashboard.StoreHolder, registry *datasource.Registry) *AnnotationsHandler {
+	return &AnnotationsHandler{holder: holder, registry: registry}
+}
+
+// annotationEvent is one marker in the response. Times are Unix seconds;
+// TimeEnd is only set for events spanning a range.
+type annotationEvent struct {
+	Source  string   `json:"source"`
+	Color   string   `json:"color,omitempty"`
+	Time    float64  `json:"time"`
+	TimeEnd float64  `json:"time_end,omitempty"`
+	Text    string   `json:"text,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// Handle processes an annotations request for the dashboard at path. Query
+// sources run a query_range over start..end; runs of consecutive non-zero
+// samples become events. A failing source is logged and skipped so the
+// remaining annotations still show up.
+func (h *AnnotationsHandler) Handle(c *gin.Context) {
+	path := c.Query("path")
+	startStr := c.Query("start")
+	endStr := c.Query("end")
+	step := c.Query("step")
+
+	if path == "" || startStr == "" || endStr == "" || step == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path, start, end, and step parameters are required"})
+		return
+	}
+	start, err1 := strconv.ParseFloat(startStr, 64)
+	end, err2 := strconv.ParseFloat(endStr, 64)
+	stepSeconds, err3 := parseStepSeconds(step)
+	if err1 != nil || err2 != nil || err3 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start and end must be Unix seconds and step a duration"})
+		return
+	}
+
+	d := visibleDashboard(c, h.holder.Store(), path)
+	if d == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "dashboard not found"})
+		return
+	}
+
+	events := []annotationEvent{}
+	for _, a := range d.Annotations {
+		if a.Query == "" {
+			events = append(events, staticEvents(a, start, end)...)
+			continue
+		}
+		// Annotation queries are restricted by the label policies of the user
+		// like any other query.
+		query, err := applyFilters(c, h.registry, a.Datasource, a.Query)
+		if err != nil {
+			slog.Error("annotation query failed", "dashboard", d.Path, "annotation", a.Name, "error", err)
+			continue
+		}
+		a.Query = query
+		queried, err := h.queryEvents(c.Request.Context(), a, startStr, endStr, step, stepSeconds)
+		if err != nil {
+			slog.Error("annotation query failed", "dashboard", d.Path, "annotation", a.Name, "error", err)
+			continue
+		}
+		events = append(events, queried...)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time < events[j].Time })
+
+	c.JSON(http.StatusOK, gin.H{"annotations": events})
+}
+
+func (h *AnnotationsHandler) queryEvents(ctx context.Context, a model.Annotation, start, end, step string, stepSeconds float64) ([]annotationEvent, error) {
+	client, err := h.registry.Get(a.Datasource)
+	if err != nil {
+		return nil, err
+	}
+	body, statusCode, err := client.QueryRange(ctx, a.Query, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = body.Close() }()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		return nil, fmt.Errorf("datasource returned status %d: %s", statusCode, data)
+	}
+	parsed, err := series.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []annotationEvent
+	for _, s := range parsed {
+		text := expandLabels(a.Text, s.Metric)
+		tags := labelTags(s.Metric)
+		for _, run := range series.NonZeroRuns(s.Samples, stepSeconds) {
+			e := annotationEvent{Source: a.Name, Color: a.Color, Time: run.Start, Text: text, Tags: tags}
+			if run.End > run.Start {
+				e.TimeEnd = run.End
+			}
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// staticEvents returns the events of a static annotation overlapping
+// start..end. Times were checked when the dashboard was loaded.
+func staticEvents(a model.Annotation, start, end float64) []annotationEvent {
+	var events []annotationEvent
+	for _, e := range a.Events {
+		t, err := model.ParseAnnotationTime(e.Time)
+		if err != nil {
+			continue
+		}
+		ev := annotationEvent{Source: a.Name, Color: a.Color, Time: unixSeconds(t), Text: e.Text, Tags: e.Tags}
+		last := ev.Time
+		if e.TimeEnd != "" {
+			if te, err := model.ParseAnnotationTime(e.TimeEnd); err == nil {
+				ev.TimeEnd = unixSeconds(te)
+				last = ev.TimeEnd
+			}
+		}
+		if last < start || ev.Time > end {
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+var labelRefRe = regexp.MustCompile(`\{(\w+)\}`)
+
+// expandLabels replaces {label} references in tmpl with the label's value.
+func expandLabels(tmpl string, metric map[string]string) string {
+	return labelRefRe.ReplaceAllStringFunc(tmpl, func(ref string) string {
+		return metric[ref[1:len(ref)-1]]
+	})
+}
+
+// labelTags renders a series' labels, except __name__, as sorted
+// "name=value" tags.
+func labelTags(metric map[string]string) []string {
+	tags := make([]string, 0, len(metric))
+	for k, v := range metric {
+		if k == "__name__" {
+			continue
+		}
+		tags = append(tags, k+"="+v)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// parseStepSeconds parses a query_range step given as a Go duration ("15s",
+// "1m") or as seconds.
+func parseStepSeconds(step string) (float64, error) {
+	if f, err := strconv.ParseFloat(step, 64); err == nil {
+		return f, nil
+	}
+	d, err := time.ParseDuration(step)
+	if err != nil {
+		return 0, err
+	}
+	return d.Seconds(), nil
+}
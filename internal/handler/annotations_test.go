@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+)
+
+const annotatedDashboard = `
+title: "Annotated"
+annotations:
+  - name: "Deploys"
+    query: 'changes(build_info[5m]) > 0'
+    text: "deploy {version}"
+    color: "#8b5cf6"
+  - name: "Broken"
+    query: 'broken'
+  - name: "Incidents"
+    events:
+      - time: "1000"
+        time_end: "1100"
+        text: "failover"
+        tags: ["db"]
+      - time: "5000"
+        text: "out of range"
+rows:
+  - title: "Row"
+    panels:
+      - title: "P"
+        type: "graph"
+        query: "up"
+`
+
+func TestAnnotationsHandler(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("query") == "broken" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"__name__":"build_info","version":"1.2.0"},"values":[[1030,"1"],[1045,"1"],[1060,"0"],[1200,"1"]]}
+		]}}`))
+	}))
+	defer promServer.Close()
+
+	holder := newTestStoreHolder(t, "annotated.yaml", annotatedDashboard)
+	router := newHandlerRouter(t, "/api/annotations", promServer.URL, func(r *datasource.Registry) gin.HandlerFunc {
+		return NewAnnotationsHandler(holder, r).Handle
+	})
+	req := httptest.NewRequest("GET", "/api/annotations?path=annotated&start=900&end=1300&step=15s", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+
+	var result struct {
+		Annotations []annotationEvent `json:"annotations"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	// The failing source is skipped and the out-of-range static event dropped.
+	expected := []annotationEvent{
+		{Source: "Incidents", Time: 1000, TimeEnd: 1100, Text: "failover", Tags: []string{"db"}},
+		{Source: "Deploys", Color: "#8b5cf6", Time: 1030, TimeEnd: 1045, Text: "deploy 1.2.0", Tags: []string{"version=1.2.0"}},
+		{Source: "Deploys", Color: "#8b5cf6", Time: 1200, Text: "deploy 1.2.0", Tags: []string{"version=1.2.0"}},
+	}
+	if len(result.Annotations) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), result.Annotations)
+	}
+	for i, want := range expected {
+		got := result.Annotations[i]
+		if got.Source != want.Source || got.Color != want.Color || got.Time != want.Time || got.TimeEnd != want.TimeEnd || got.Text != want.Text || len(got.Tags) != 1 || got.Tags[0] != want.Tags[0] {
+			t.Errorf("event %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+func TestAnnotationsHandlerBadRequests(t *testing.T) {
+	holder := newTestStoreHolder(t, "annotated.yaml", annotatedDashboard)
+	router := newHandlerRouter(t, "/api/annotations", "http://localhost:9090", func(r *datasource.Registry) gin.HandlerFunc {
+		return NewAnnotationsHandler(holder, r).Handle
+	})
+	tests := []struct {
+		name string
+		url  string
+		code int
+	}{
+		{"missing path", "/api/annotations?start=900&end=1300&step=15s", http.StatusBadRequest},
+		{"missing step", "/api/annotations?path=annotated&start=900&end=1300", http.StatusBadRequest},
+		{"invalid start", "/api/annotations?path=annotated&start=now&end=1300&step=15s", http.StatusBadRequest},
+		{"invalid step", "/api/annotations?path=annotated&start=900&end=1300&step=fast", http.StatusBadRequest},
+		{"unknown dashboard", "/api/annotations?path=nope&start=900&end=1300&step=15s", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, resp.Code)
+			}
+		})
+	}
+}
+
+func TestParseStepSeconds(t *testing.T) {
+	for input, want := range map[string]float64{"15s": 15, "1m": 60, "30": 30, "0.5": 0.5} {
+		got, err := parseStepSeconds(input)
+		if err != nil || got != want {
+			t.Errorf("%q: expected %v, got %v (err %v)", input, want, got, err)
+		}
+	}
+}
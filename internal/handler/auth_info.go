@@ -23,20 +23,26 @@ type OAuthProviderInfo struct {
 type AuthInfoHandler struct {
 	users     []config.User
 	providers []config.OAuthProviderConfig
+	// externalUsers tells whether users who are not in users log in with a
+	// password too, from an htpasswd file or an LDAP directory.
+	externalUsers bool
 }
 
-// NewAuthInfoHandler creates a new AuthInfoHandler.
-func NewAuthInfoHandler(users []config.User, providers []config.OAuthProviderConfig) *AuthInfoHandler {
+// NewAuthInfoHandler creates a new AuthInfoHandler. externalUsers tells
+// whether users who are not in users log in with a password too, from an
+// htpasswd file or an LDAP directory.
+func NewAuthInfoHandler(users []config.User, providers []config.OAuthProviderConfig, externalUsers bool) *AuthInfoHandler {
 	return &AuthInfoHandler{
-		users:     users,
-		providers: providers,
+		users:         users,
+		providers:     providers,
+		externalUsers: externalUsers,
 	}
 }
 
 // Handle returns the authentication methods available.
 func (h *AuthInfoHandler) Handle(c *gin.Context) {
 	resp := AuthInfoResponse{
-		PasswordEnabled: len(h.users) > 0,
+		PasswordEnabled: len(h.users) > 0 || h.externalUsers,
 		OAuthProviders:  make([]OAuthProviderInfo, 0, len(h.providers)),
 	}
 
@@ -12,7 +12,7 @@ import (
 
 func TestAuthInfoPasswordOnly(t *testing.T) {
 	users := []config.User{{ID: "admin", PasswordHash: "hash"}}
-	handler := NewAuthInfoHandler(users, nil)
+	handler := NewAuthInfoHandler(users, nil, false)
 
 	router := gin.New()
 	router.GET("/api/auth-info", handler.Handle)
@@ -41,7 +41,7 @@ func TestAuthInfoOAuthOnly(t *testing.T) {
 	providers := []config.OAuthProviderConfig{
 		{Provider: "github", ClientID: "id", ClientSecret: "secret"},
 	}
-	handler := NewAuthInfoHandler(nil, providers)
+	handler := NewAuthInfoHandler(nil, providers, false)
 
 	router := gin.New()
 	router.GET("/api/auth-info", handler.Handle)
@@ -77,7 +77,7 @@ func TestAuthInfoBothMethods(t *testing.T) {
 	providers := []config.OAuthProviderConfig{
 		{Provider: "github", ClientID: "id", ClientSecret: "secret"},
 	}
-	handler := NewAuthInfoHandler(users, providers)
+	handler := NewAuthInfoHandler(users, providers, false)
 
 	router := gin.New()
 	router.GET("/api/auth-info", handler.Handle)
@@ -97,3 +97,22 @@ func TestAuthInfoBothMethods(t *testing.T) {
 		t.Errorf("expected 1 oauth provider, got %d", len(result.OAuthProviders))
 	}
 }
+
+func TestAuthInfoLDAPOnly(t *testing.T) {
+	handler := NewAuthInfoHandler(nil, nil, true)
+
+	router := gin.New()
+	router.GET("/api/auth-info", handler.Handle)
+
+	req := httptest.NewRequest("GET", "/api/auth-info", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	var result AuthInfoResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.PasswordEnabled {
+		t.Error("expected password_enabled=true with LDAP and no local users")
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/access"
+	"github.com/tokuhirom/dashyard/internal/auth"
 	"github.com/tokuhirom/dashyard/internal/dashboard"
+	"github.com/tokuhirom/dashyard/internal/model"
 )
 
 // DashboardsHandler handles dashboard listing and detail requests.
@@ -19,16 +22,18 @@ func NewDashboardsHandler(holder *dashboard.StoreHolder, siteTitle string, heade
 	return &DashboardsHandler{holder: holder, siteTitle: siteTitle, headerColor: headerColor}
 }
 
-// List handles GET /api/dashboards - returns all dashboards with flat list and tree.
+// List handles GET /api/dashboards - returns the dashboards the current user
+// may see, as a flat list and a tree.
 func (h *DashboardsHandler) List(c *gin.Context) {
 	store := h.holder.Store()
+	userID, groups := auth.GetUserID(c), access.GetGroups(c)
 
 	type listItem struct {
 		Path  string `json:"path"`
 		Title string `json:"title"`
 	}
 
-	dashboards := store.List()
+	dashboards := store.VisibleList(userID, groups)
 	items := make([]listItem, len(dashboards))
 	for i, d := range dashboards {
 		items[i] = listItem{Path: d.Path, Title: d.Title}
@@ -36,13 +41,14 @@ func (h *DashboardsHandler) List(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"dashboards":   items,
-		"tree":         store.Tree(),
+		"tree":         store.VisibleTree(userID, groups),
 		"site_title":   h.siteTitle,
 		"header_color": h.headerColor,
 	})
 }
 
 // Get handles GET /api/dashboards/:path - returns a single dashboard definition.
+// Dashboards hidden from the current user are not found.
 func (h *DashboardsHandler) Get(c *gin.Context) {
 	store := h.holder.Store()
 
@@ -52,7 +58,7 @@ func (h *DashboardsHandler) Get(c *gin.Context) {
 		path = path[1:]
 	}
 
-	d := store.Get(path)
+	d := visibleDashboard(c, store, path)
 	if d == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "dashboard not found"})
 		return
@@ -71,10 +77,19 @@ func (h *DashboardsHandler) GetSource(c *gin.Context) {
 	}
 
 	src, ok := store.GetSource(path)
-	if !ok {
+	if !ok || visibleDashboard(c, store, path) == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "dashboard not found"})
 		return
 	}
 
 	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(src))
 }
+
+// visibleDashboard returns the dashboard at path, or nil if there is none or
+// its access rules hide it from the current user.
+func visibleDashboard(c *gin.Context, store *dashboard.Store, path string) *model.Dashboard {
+	if !store.Visible(path, auth.GetUserID(c), access.GetGroups(c)) {
+		return nil
+	}
+	return store.Get(path)
+}
@@ -4,9 +4,15 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/access"
+	"github.com/tokuhirom/dashyard/internal/auth"
+	"github.com/tokuhirom/dashyard/internal/config"
 	"github.com/tokuhirom/dashyard/internal/dashboard"
 )
 
@@ -151,3 +157,81 @@ func TestDashboardsGetNotFound(t *testing.T) {
 		t.Errorf("expected 404, got %d", resp.Code)
 	}
 }
+
+func TestDashboardsAccess(t *testing.T) {
+	dir := t.TempDir()
+	panels := "rows:\n  - title: R\n    panels:\n      - title: P\n        type: graph\n        query: up\n"
+	files := map[string]string{
+		"public.yaml":         "title: Public\n" + panels,
+		"team.yaml":           "title: Team\naccess:\n  groups: [payments]\n" + panels,
+		"admin/_folder.yaml":  "access:\n  users: [root]\n",
+		"admin/settings.yaml": "title: Settings\n" + panels,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := dashboard.LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	handler := NewDashboardsHandler(dashboard.NewStoreHolder(store), "Dashyard", "")
+	policies, err := access.New(&config.Config{
+		Groups: []config.Group{{Name: "payments", Members: []string{"alice"}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sm := auth.NewSessionManager("test-secret-that-is-32bytes!!", false)
+	router := gin.New()
+	router.Use(auth.AuthMiddleware(sm, nil, nil), access.Middleware(policies))
+	router.GET("/api/dashboards", handler.List)
+	router.GET("/api/dashboards/*path", handler.Get)
+	router.GET("/api/dashboard-source/*path", handler.GetSource)
+
+	listed := func(user string) []string {
+		resp := serveAs(t, router, sm, user, "/api/dashboards")
+		var result struct {
+			Dashboards []struct {
+				Path string `json:"path"`
+			} `json:"dashboards"`
+		}
+		if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		var paths []string
+		for _, d := range result.Dashboards {
+			paths = append(paths, d.Path)
+		}
+		return paths
+	}
+	if got := listed("alice"); !slices.Equal(got, []string{"public", "team"}) {
+		t.Errorf("alice sees %v", got)
+	}
+	if got := listed("root"); !slices.Equal(got, []string{"admin/settings", "public"}) {
+		t.Errorf("root sees %v", got)
+	}
+
+	tests := []struct {
+		user, target string
+		wantStatus   int
+	}{
+		{"alice", "/api/dashboards/team", http.StatusOK},
+		{"bob", "/api/dashboards/team", http.StatusNotFound},
+		{"bob", "/api/dashboard-source/team", http.StatusNotFound},
+		{"alice", "/api/dashboards/admin/settings", http.StatusNotFound},
+		{"root", "/api/dashboard-source/admin/settings", http.StatusOK},
+		{"bob", "/api/dashboards/public", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if resp := serveAs(t, router, sm, tt.user, tt.target); resp.Code != tt.wantStatus {
+			t.Errorf("%s %s: expected %d, got %d", tt.user, tt.target, tt.wantStatus, resp.Code)
+		}
+	}
+}
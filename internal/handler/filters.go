@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/access"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+	"github.com/tokuhirom/dashyard/internal/promql"
+)
+
+// errPolicyDatasource is returned when a label policy applies to the user but
+// the datasource does not take PromQL, so its queries cannot be restricted.
+var errPolicyDatasource = errors.New("label policies cannot be enforced on this datasource")
+
+// applyFilters adds the label matchers that label policies force on the
+// current user, and those of the "filters" parameter, the dashboard's ad-hoc
+// filters such as {instance="web-3"}, to every selector of query. Queries for
+// datasources that do not take PromQL are left unchanged, unless a label
+// policy applies, in which case they are refused.
+func applyFilters(c *gin.Context, registry *datasource.Registry, dsName, query string) (string, error) {
+	forced := access.GetMatchers(c)
+	filters := c.Query("filters")
+	if len(forced) == 0 && filters == "" {
+		return query, nil
+	}
+	if !registry.AcceptsPromQL(dsName) {
+		if len(forced) > 0 {
+			return "", fmt.Errorf("datasource %q: %w", dsName, errPolicyDatasource)
+		}
+		return query, nil
+	}
+	matchers, err := promql.ParseMatchers(filters)
+	if err != nil {
+		return "", err
+	}
+	return promql.InjectMatchers(query, slices.Concat(forced, matchers))
+}
+
+// restrictSelector adds the label matchers that label policies force on the
+// current user to a series selector, such as the match[] of a label values
+// request.
+func restrictSelector(c *gin.Context, registry *datasource.Registry, dsName, selector string) (string, error) {
+	forced := access.GetMatchers(c)
+	if len(forced) == 0 {
+		return selector, nil
+	}
+	if !registry.AcceptsPromQL(dsName) {
+		return "", fmt.Errorf("datasource %q: %w", dsName, errPolicyDatasource)
+	}
+	return promql.RestrictSelector(selector, forced)
+}
+
+// filterErrorStatus returns the status code for an error of applyFilters or
+// restrictSelector.
+func filterErrorStatus(err error) int {
+	if errors.Is(err, errPolicyDatasource) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+)
+
+func TestQueryHandlerAppliesFilters(t *testing.T) {
+	var gotQuery string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("query")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	}))
+	defer upstream.Close()
+
+	registry, err := datasource.NewRegistry([]config.DatasourceConfig{
+		{Name: "default", Type: "prometheus", URL: upstream.URL, Timeout: 5 * time.Second, Default: true},
+		{Name: "logs", Type: "loki", URL: upstream.URL, Timeout: 5 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	router := gin.New()
+	router.GET("/api/query", NewQueryHandler(registry).Handle)
+
+	tests := []struct {
+		name       string
+		params     url.Values
+		wantStatus int
+		wantQuery  string
+	}{
+		{
+			"filters injected into every selector",
+			url.Values{"query": {`sum(rate(a{job="api"}[5m])) / sum(b)`}, "filters": {`{instance="web-3"}`}},
+			http.StatusOK,
+			`sum(rate(a{instance="web-3",job="api"}[5m])) / sum(b{instance="web-3"})`,
+		},
+		{
+			"no filters keeps the query",
+			url.Values{"query": {`sum(rate(a[5m]))`}},
+			http.StatusOK,
+			`sum(rate(a[5m]))`,
+		},
+		{
+			"loki queries are not rewritten",
+			url.Values{"query": {`sum(rate({app="web"}[5m]))`}, "filters": {`{instance="web-3"}`}, "datasource": {"logs"}},
+			http.StatusOK,
+			`sum(rate({app="web"}[5m]))`,
+		},
+		{
+			"invalid filters",
+			url.Values{"query": {"up"}, "filters": {`{instance=web-3}`}},
+			http.StatusBadRequest,
+			"",
+		},
+		{
+			"invalid query",
+			url.Values{"query": {"rate(up[5m]"}, "filters": {`{instance="web-3"}`}},
+			http.StatusBadRequest,
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotQuery = ""
+			tt.params.Set("start", "1000")
+			tt.params.Set("end", "2000")
+			tt.params.Set("step", "15s")
+			req := httptest.NewRequest("GET", "/api/query?"+tt.params.Encode(), nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, resp.Code, resp.Body.String())
+			}
+			if gotQuery != tt.wantQuery {
+				t.Errorf("expected upstream query %q, got %q", tt.wantQuery, gotQuery)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+// newDummyGitLab starts a fake GitLab OAuth server and returns it. The user
+// "dummyuser" belongs to two groups, listed on two pages.
+func newDummyGitLab() *httptest.Server {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+
+	mux.HandleFunc("GET /oauth/authorize", func(w http.ResponseWriter, r *http.Request) {
+		redirectURI := r.URL.Query().Get("redirect_uri")
+		state := r.URL.Query().Get("state")
+		// Auto-approve: redirect back with a dummy code immediately.
+		http.Redirect(w, r, redirectURI+"?code=dummy-auth-code&state="+url.QueryEscape(state), http.StatusFound)
+	})
+
+	mux.HandleFunc("POST /oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "dummy-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   7200,
+		})
+	})
+
+	mux.HandleFunc("GET /api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_token") != "dummy-access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":       42,
+			"username": "dummyuser",
+			"name":     "Dummy User",
+			"email":    "dummy@example.com",
+		})
+	})
+
+	mux.HandleFunc("GET /api/v4/groups", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer dummy-access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "2" {
+			_ = json.NewEncoder(w).Encode([]map[string]interface{}{{"id": 2, "full_path": "infra/sre"}})
+			return
+		}
+		w.Header().Set("Link", `<`+srv.URL+`/api/v4/groups?min_access_level=10&page=2&per_page=100>; rel="next"`)
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{{"id": 1, "full_path": "infra"}})
+	})
+
+	return srv
+}
+
+func TestGitLabIntegrationBeginAuthRedirectsToBaseURL(t *testing.T) {
+	dummyGL := newDummyGitLab()
+	defer dummyGL.Close()
+	app := startOAuthApp(t, config.OAuthProviderConfig{
+		Provider:      "gitlab",
+		BaseURL:       dummyGL.URL,
+		AllowedGroups: []string{"infra/sre"},
+	})
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(app.URL + "/auth/gitlab")
+	if err != nil {
+		t.Fatalf("BeginAuth request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("failed to parse Location header: %v", err)
+	}
+	if got := loc.Scheme + "://" + loc.Host + loc.Path; got != dummyGL.URL+"/oauth/authorize" {
+		t.Errorf("expected redirect to %s/oauth/authorize, got %s", dummyGL.URL, got)
+	}
+	if scope := loc.Query().Get("scope"); scope != "read_user read_api" {
+		t.Errorf("expected scope 'read_user read_api' for allowed_groups, got %q", scope)
+	}
+}
+
+func TestGitLabIntegrationAllowedGroups(t *testing.T) {
+	dummyGL := newDummyGitLab()
+	defer dummyGL.Close()
+
+	tests := []struct {
+		name          string
+		allowedGroups []string
+		want          string
+	}{
+		{"group on the second page", []string{"infra/sre"}, "/"},
+		{"parent group", []string{"infra"}, "/"},
+		{"not a member", []string{"infra/dba"}, "/?error=access_denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := startOAuthApp(t, config.OAuthProviderConfig{
+				Provider:      "gitlab",
+				BaseURL:       dummyGL.URL,
+				AllowedGroups: tt.allowedGroups,
+			})
+			resp := runOAuthLogin(t, app.URL, "gitlab")
+			if resp.StatusCode != http.StatusTemporaryRedirect {
+				t.Fatalf("expected 307, got %d", resp.StatusCode)
+			}
+			if loc := resp.Header.Get("Location"); loc != tt.want {
+				t.Errorf("expected redirect to %q, got %q", tt.want, loc)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tokuhirom/dashyard/internal/auth"
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+// newDummyGoogle starts a fake Google OpenID Connect issuer that signs in
+// dummy@example.com, a user of the Google Workspace domain hd ("" for a
+// consumer account, which has no hd claim).
+func newDummyGoogle(t *testing.T, hd string) *httptest.Server {
+	t.Helper()
+	claims := map[string]interface{}{
+		"preferred_username": nil, // Google has no user name, only the email
+		"email":              "dummy@example.com",
+		"email_verified":     true,
+	}
+	if hd != "" {
+		claims["hd"] = hd
+	}
+	return newDummyOIDC(t, claims)
+}
+
+// googleProvider is the config of the google provider, pointed at a dummy
+// issuer.
+func googleProvider(issuerURL string) config.OAuthProviderConfig {
+	return config.OAuthProviderConfig{Provider: "google", DiscoveryURL: issuerURL + "/.well-known/openid-configuration"}
+}
+
+func TestGoogleIntegrationAllowedDomains(t *testing.T) {
+	tests := []struct {
+		name           string
+		hd             string
+		allowedDomains []string
+		allowedUsers   []string
+		want           string
+	}{
+		{"workspace domain", "example.com", []string{"example.com"}, nil, "/"},
+		{"other domain", "other.example", []string{"example.com"}, nil, "/?error=access_denied"},
+		{"consumer account", "", []string{"example.com"}, nil, "/?error=access_denied"},
+		{"allowed user by email", "", []string{"example.com"}, []string{"dummy@example.com"}, "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newDummyGoogle(t, tt.hd)
+			defer issuer.Close()
+			provider := googleProvider(issuer.URL)
+			provider.AllowedDomains = tt.allowedDomains
+			provider.AllowedUsers = tt.allowedUsers
+			app := startOAuthApp(t, provider)
+
+			resp := runOAuthLogin(t, app.URL, "google")
+			if resp.StatusCode != http.StatusTemporaryRedirect {
+				t.Fatalf("expected 307, got %d", resp.StatusCode)
+			}
+			if loc := resp.Header.Get("Location"); loc != tt.want {
+				t.Errorf("expected redirect to %q, got %q", tt.want, loc)
+			}
+		})
+	}
+}
+
+func TestGoogleIntegrationUserIDIsEmail(t *testing.T) {
+	issuer := newDummyGoogle(t, "example.com")
+	defer issuer.Close()
+	app := startOAuthApp(t, googleProvider(issuer.URL))
+
+	resp := runOAuthLogin(t, app.URL, "google")
+	var sessionCookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == "dashyard_session" {
+			sessionCookie = c
+		}
+	}
+	if sessionCookie == nil {
+		t.Fatal("expected dashyard_session cookie to be set after Google flow")
+	}
+
+	sm := auth.NewSessionManager("test-secret-that-is-at-least-32-bytes-long!", false)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(sessionCookie)
+	userID, err := sm.ValidateSession(req)
+	if err != nil {
+		t.Fatalf("ValidateSession failed: %v", err)
+	}
+	if userID != "dummy@example.com" {
+		t.Errorf("expected user ID 'dummy@example.com', got %q", userID)
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/dashboard"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+)
+
+// newHandlerRouter serves the handler built by newHandler at route, backed by
+// a default Prometheus datasource at promURL and any extra datasources.
+func newHandlerRouter(t *testing.T, route, promURL string, newHandler func(*datasource.Registry) gin.HandlerFunc, extra ...config.DatasourceConfig) *gin.Engine {
+	t.Helper()
+	registry, err := datasource.NewRegistry(append([]config.DatasourceConfig{
+		{Name: "default", Type: "prometheus", URL: promURL, Timeout: 5 * time.Second, Default: true},
+	}, extra...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	router := gin.New()
+	router.GET(route, newHandler(registry))
+	return router
+}
+
+// newTestStoreHolder loads a dashboard store holding the single dashboard
+// file name with the given YAML content.
+func newTestStoreHolder(t *testing.T, name, content string) *dashboard.StoreHolder {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := dashboard.LoadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to load dashboards: %v", err)
+	}
+	return dashboard.NewStoreHolder(store)
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+	"github.com/tokuhirom/dashyard/internal/series"
+)
+
+// HeatmapHandler handles GET /api/heatmap - turns a histogram query_range
+// into per-bucket counts server-side, for heatmap panels.
+type HeatmapHandler struct {
+	registry *datasource.Registry
+}
+
+// NewHeatmapHandler creates a new HeatmapHandler.
+func NewHeatmapHandler(registry *datasource.Registry) *HeatmapHandler {
+	return &HeatmapHandler{registry: registry}
+}
+
+// Handle processes a heatmap request. The query should return classic
+// histogram buckets (e.g. sum by (le) (rate(x_bucket[5m]))) or native
+// histograms. The response is a Prometheus-style matrix with one series per
+// bucket, labelled with its upper bound "le", holding non-cumulative counts.
+func (h *HeatmapHandler) Handle(c *gin.Context) {
+	h.serve(c, c.Query("query"), c.Query("datasource"))
+}
+
+// serve runs the histogram query on the datasource dsName.
+func (h *HeatmapHandler) serve(c *gin.Context, query, dsName string) {
+	start := c.Query("start")
+	end := c.Query("end")
+	step := c.Query("step")
+
+	if query == "" || start == "" || end == "" || step == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query, start, end, and step parameters are required"})
+		return
+	}
+
+	client, err := h.registry.Get(dsName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err = applyFilters(c, h.registry, dsName, query)
+	if err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	logPanelQuery(c, dsName, query)
+
+	body, statusCode, err := client.QueryRange(c.Request.Context(), query, start, end, step)
+	if err != nil {
+		slog.Error("datasource query failed", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "datasource query failed"})
+		return
+	}
+	defer func() { _ = body.Close() }()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		slog.Error("failed to read datasource response", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to read datasource response"})
+		return
+	}
+
+	if statusCode < 200 || statusCode >= 300 {
+		c.Data(statusCode, "application/json", data)
+		return
+	}
+
+	parsed, err := series.Parse(data)
+	if err != nil {
+		slog.Error("failed to parse datasource response", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to parse datasource response"})
+		return
+	}
+
+	buckets, err := series.BucketCounts(parsed)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	out, err := series.MatrixResponse(buckets)
+	if err != nil {
+		slog.Error("failed to encode heatmap response", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode response"})
+		return
+	}
+	c.Data(http.StatusOK, "application/json", out)
+}
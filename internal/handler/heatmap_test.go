@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+)
+
+func TestHeatmapHandler(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			t.Errorf("expected path '/api/v1/query_range', got %q", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"le":"+Inf"},"values":[[1000,"10"]]},
+			{"metric":{"le":"0.1"},"values":[[1000,"4"]]}
+		]}}`))
+	}))
+	defer promServer.Close()
+
+	router := newHandlerRouter(t, "/api/heatmap", promServer.URL, func(r *datasource.Registry) gin.HandlerFunc {
+		return NewHeatmapHandler(r).Handle
+	})
+	req := httptest.NewRequest("GET", "/api/heatmap?query=sum+by+(le)+(x_bucket)&start=1000&end=1000&step=15s", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	expected := `{"data":{"result":[{"metric":{"le":"0.1"},"values":[[1000,"4"]]},{"metric":{"le":"+Inf"},"values":[[1000,"6"]]}],"resultType":"matrix"},"status":"success"}`
+	if resp.Body.String() != expected {
+		t.Errorf("expected body %s, got %s", expected, resp.Body.String())
+	}
+}
+
+func TestHeatmapHandlerUpstreamError(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+	}))
+	defer promServer.Close()
+
+	router := newHandlerRouter(t, "/api/heatmap", promServer.URL, func(r *datasource.Registry) gin.HandlerFunc {
+		return NewHeatmapHandler(r).Handle
+	})
+	req := httptest.NewRequest("GET", "/api/heatmap?query=x&start=1000&end=2000&step=15s", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", resp.Code)
+	}
+	if resp.Body.String() != `{"status":"error","errorType":"bad_data","error":"parse error"}` {
+		t.Errorf("expected upstream body to be passed through, got %s", resp.Body.String())
+	}
+}
+
+func TestHeatmapHandlerInvalidLe(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"le":"fast"},"values":[[1000,"1"]]}]}}`))
+	}))
+	defer promServer.Close()
+
+	router := newHandlerRouter(t, "/api/heatmap", promServer.URL, func(r *datasource.Registry) gin.HandlerFunc {
+		return NewHeatmapHandler(r).Handle
+	})
+	req := httptest.NewRequest("GET", "/api/heatmap?query=x&start=1000&end=2000&step=15s", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d", resp.Code)
+	}
+}
+
+func TestHeatmapHandlerBadRequests(t *testing.T) {
+	router := newHandlerRouter(t, "/api/heatmap", "http://localhost:9090", func(r *datasource.Registry) gin.HandlerFunc {
+		return NewHeatmapHandler(r).Handle
+	})
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"missing query", "/api/heatmap?start=1000&end=2000&step=15s"},
+		{"missing step", "/api/heatmap?query=x&start=1000&end=2000"},
+		{"unknown datasource", "/api/heatmap?query=x&start=1000&end=2000&step=15s&datasource=nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", resp.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+)
+
+// InstantQueryHandler handles GET /api/instant-query - proxies instant queries to the datasource.
+type InstantQueryHandler struct {
+	registry *datasource.Registry
+}
+
+// NewInstantQueryHandler creates a new InstantQueryHandler.
+func NewInstantQueryHandler(registry *datasource.Registry) *InstantQueryHandler {
+	return &InstantQueryHandler{registry: registry}
+}
+
+// Handle processes a datasource instant query proxy request.
+// The optional time parameter is the evaluation timestamp; it defaults to now.
+func (h *InstantQueryHandler) Handle(c *gin.Context) {
+	h.serve(c, c.Query("query"), c.Query("datasource"))
+}
+
+// serve runs query as an instant query against the datasource dsName.
+func (h *InstantQueryHandler) serve(c *gin.Context, query, dsName string) {
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter is required"})
+		return
+	}
+
+	client, err := h.registry.Get(dsName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err = applyFilters(c, h.registry, dsName, query)
+	if err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	logPanelQuery(c, dsName, query)
+
+	body, statusCode, err := client.Query(c.Request.Context(), query, c.Query("time"))
+	if err != nil {
+		slog.Error("datasource instant query failed", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "datasource query failed"})
+		return
+	}
+	defer func() { _ = body.Close() }()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		slog.Error("failed to read datasource response", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to read datasource response"})
+		return
+	}
+
+	c.Data(statusCode, "application/json", data)
+}
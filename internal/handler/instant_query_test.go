@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+)
+
+func TestInstantQueryHandler(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			t.Errorf("expected path '/api/v1/query', got %q", r.URL.Path)
+		}
+		if r.URL.Query().Get("time") != "1500" {
+			t.Errorf("expected time '1500', got %q", r.URL.Query().Get("time"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer promServer.Close()
+
+	registry, _ := datasource.NewRegistry([]config.DatasourceConfig{
+		{Name: "default", Type: "prometheus", URL: promServer.URL, Timeout: 5 * time.Second, Default: true},
+	})
+	handler := NewInstantQueryHandler(registry)
+
+	router := gin.New()
+	router.GET("/api/instant-query", handler.Handle)
+
+	req := httptest.NewRequest("GET", "/api/instant-query?query=up&time=1500", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	expected := `{"status":"success","data":{"resultType":"vector","result":[]}}`
+	if resp.Body.String() != expected {
+		t.Errorf("expected body %q, got %q", expected, resp.Body.String())
+	}
+}
+
+func TestInstantQueryHandlerBadRequests(t *testing.T) {
+	registry, _ := datasource.NewRegistry([]config.DatasourceConfig{
+		{Name: "default", Type: "prometheus", URL: "http://localhost:9090", Timeout: 5 * time.Second, Default: true},
+	})
+	handler := NewInstantQueryHandler(registry)
+
+	router := gin.New()
+	router.GET("/api/instant-query", handler.Handle)
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"missing query", "/api/instant-query?time=1500"},
+		{"unknown datasource", "/api/instant-query?query=up&datasource=nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", resp.Code)
+			}
+		})
+	}
+}
@@ -27,13 +27,29 @@ func (h *LabelValuesHandler) Handle(c *gin.Context) {
 		return
 	}
 
-	client, err := h.registry.Get(c.Query("datasource"))
+	dsName := c.Query("datasource")
+	client, err := h.registry.Get(dsName)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// match[] carries the series selector, with any variables it references
+	// already resolved by the caller. The older "match" parameter is still accepted.
+	matches := c.QueryArray("match[]")
+	if len(matches) > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only one match[] selector is supported"})
+		return
+	}
 	match := c.Query("match")
+	if len(matches) == 1 {
+		match = matches[0]
+	}
+	match, err = restrictSelector(c, h.registry, dsName, match)
+	if err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
 
 	body, statusCode, err := client.LabelValues(c.Request.Context(), label, match)
 	if err != nil {
@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -107,3 +108,54 @@ func TestLabelValuesHandlerWithDatasourceParam(t *testing.T) {
 		t.Errorf("expected 200, got %d: %s", resp.Code, resp.Body.String())
 	}
 }
+
+func TestLabelValuesHandlerMatchSelector(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/label/pod/values" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if got := r.URL.Query()["match[]"]; len(got) != 1 || got[0] != `kube_pod_info{namespace="default"}` {
+			t.Errorf("unexpected match[] %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":["web-1"]}`))
+	}))
+	defer promServer.Close()
+
+	registry, _ := datasource.NewRegistry([]config.DatasourceConfig{
+		{Name: "default", Type: "prometheus", URL: promServer.URL, Timeout: 5 * time.Second, Default: true},
+	})
+	handler := NewLabelValuesHandler(registry)
+
+	router := gin.New()
+	router.GET("/api/label-values", handler.Handle)
+
+	params := url.Values{}
+	params.Set("label", "pod")
+	params.Set("match[]", `kube_pod_info{namespace="default"}`)
+	req := httptest.NewRequest("GET", "/api/label-values?"+params.Encode(), nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestLabelValuesHandlerMultipleMatchSelectors(t *testing.T) {
+	registry, _ := datasource.NewRegistry([]config.DatasourceConfig{
+		{Name: "default", Type: "prometheus", URL: "http://localhost:1", Timeout: 5 * time.Second, Default: true},
+	})
+	handler := NewLabelValuesHandler(registry)
+
+	router := gin.New()
+	router.GET("/api/label-values", handler.Handle)
+
+	req := httptest.NewRequest("GET", "/api/label-values?label=pod&match[]=a&match[]=b", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", resp.Code)
+	}
+}
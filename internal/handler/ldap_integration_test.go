@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/auth"
+	"github.com/tokuhirom/dashyard/internal/auth/ldaptest"
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+// newLDAPLoginRouter serves POST /api/login for the local user admin and the
+// users of an in-process LDAP directory, of whom only members of sre may sign
+// in. usesGroup is passed to auth.NewLDAPAuth.
+func newLDAPLoginRouter(t *testing.T, url string, usesGroup func(string) bool) (*gin.Engine, *auth.SessionManager) {
+	t.Helper()
+	ldap, err := auth.NewLDAPAuth(config.LDAPConfig{
+		URL:            url,
+		BindDN:         "cn=dashyard,dc=example,dc=com",
+		BindPassword:   "service-secret",
+		BaseDN:         "ou=people,dc=example,dc=com",
+		UserFilter:     "(uid={user})",
+		GroupBaseDN:    "ou=groups,dc=example,dc=com",
+		GroupFilter:    "(member={dn})",
+		GroupAttribute: "cn",
+		AllowedGroups:  []string{"sre"},
+		Timeout:        5 * time.Second,
+	}, usesGroup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := []config.User{{ID: "admin", PasswordHash: generateTestHash("password123")}}
+	sm := auth.NewSessionManager("test-secret", false)
+	router := gin.New()
+	router.POST("/api/login", NewLoginHandler(auth.NewUserStore(users), sm, ldap, nil).Handle)
+	return router, sm
+}
+
+func TestLDAPIntegrationLogin(t *testing.T) {
+	srv := ldaptest.NewServer([]ldaptest.Entry{
+		{DN: "cn=dashyard,dc=example,dc=com", Password: "service-secret"},
+		{DN: "uid=alice,ou=people,dc=example,dc=com", Attributes: map[string][]string{"uid": {"alice"}}, Password: "alice-secret"},
+		{DN: "uid=bob,ou=people,dc=example,dc=com", Attributes: map[string][]string{"uid": {"bob"}}, Password: "bob-secret"},
+		{DN: "uid=admin,ou=people,dc=example,dc=com", Attributes: map[string][]string{"uid": {"admin"}}, Password: "ldap-admin-secret"},
+		{DN: "cn=sre,ou=groups,dc=example,dc=com", Attributes: map[string][]string{"cn": {"sre"}, "member": {
+			"uid=alice,ou=people,dc=example,dc=com",
+			"uid=admin,ou=people,dc=example,dc=com",
+		}}},
+		{DN: "cn=sales,ou=groups,dc=example,dc=com", Attributes: map[string][]string{"cn": {"sales"}, "member": {"uid=bob,ou=people,dc=example,dc=com"}}},
+	})
+	defer srv.Close()
+	router, sm := newLDAPLoginRouter(t, srv.URL, nil)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantGroups []string
+	}{
+		{"directory user", `{"user_id":"alice","password":"alice-secret"}`, http.StatusOK, []string{"sre"}},
+		{"wrong password", `{"user_id":"alice","password":"wrong"}`, http.StatusUnauthorized, nil},
+		{"not in allowed groups", `{"user_id":"bob","password":"bob-secret"}`, http.StatusUnauthorized, nil},
+		{"local user", `{"user_id":"admin","password":"password123"}`, http.StatusOK, nil},
+		// Local users are not checked against the directory.
+		{"local user with directory password", `{"user_id":"admin","password":"ldap-admin-secret"}`, http.StatusUnauthorized, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/login", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, resp.Code, resp.Body.String())
+			}
+			if resp.Code != http.StatusOK {
+				return
+			}
+			check := httptest.NewRequest("GET", "/", nil)
+			for _, c := range resp.Result().Cookies() {
+				check.AddCookie(c)
+			}
+			if groups := sm.SessionGroups(check); !slices.Equal(groups, tt.wantGroups) {
+				t.Errorf("expected session groups %v, got %v", tt.wantGroups, groups)
+			}
+		})
+	}
+}
+
+func TestLDAPIntegrationDirectoryDown(t *testing.T) {
+	srv := ldaptest.NewServer(nil)
+	url := srv.URL
+	srv.Close()
+	router, _ := newLDAPLoginRouter(t, url, nil)
+
+	req := httptest.NewRequest("POST", "/api/login", strings.NewReader(`{"user_id":"alice","password":"alice-secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d: %s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestLDAPIntegrationManyGroups(t *testing.T) {
+	const alice = "uid=alice,ou=people,dc=example,dc=com"
+	entries := []ldaptest.Entry{
+		{DN: "cn=dashyard,dc=example,dc=com", Password: "service-secret"},
+		{DN: alice, Attributes: map[string][]string{"uid": {"alice"}}, Password: "alice-secret"},
+		{DN: "cn=sre,ou=groups,dc=example,dc=com", Attributes: map[string][]string{"cn": {"sre"}, "member": {alice}}},
+	}
+	// Far more groups than a session cookie can hold.
+	for i := range 500 {
+		name := fmt.Sprintf("team-%03d", i)
+		entries = append(entries, ldaptest.Entry{
+			DN:         "cn=" + name + ",ou=groups,dc=example,dc=com",
+			Attributes: map[string][]string{"cn": {name}, "member": {alice}},
+		})
+	}
+	srv := ldaptest.NewServer(entries)
+	defer srv.Close()
+	router, sm := newLDAPLoginRouter(t, srv.URL, func(name string) bool { return name == "team-042" })
+
+	req := httptest.NewRequest("POST", "/api/login", strings.NewReader(`{"user_id":"alice","password":"alice-secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	check := httptest.NewRequest("GET", "/", nil)
+	for _, c := range resp.Result().Cookies() {
+		check.AddCookie(c)
+	}
+	if groups, want := sm.SessionGroups(check), []string{"sre", "team-042"}; !slices.Equal(groups, want) {
+		t.Errorf("expected session groups %v, got %v", want, groups)
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/access"
+	"github.com/tokuhirom/dashyard/internal/auth"
+	"github.com/tokuhirom/dashyard/internal/dashboard"
+)
+
+// RequireDashboardQuery returns middleware for locked-down query mode: it
+// rejects with 403 requests whose query parameter no loaded dashboard that
+// the user sees sends to the datasource parameter, with its variables
+// substituted. Ad-hoc filters
+// only narrow a query and are not part of the check. defaultDatasource is the
+// datasource of requests and dashboard queries without one.
+func RequireDashboardQuery(holder *dashboard.StoreHolder, defaultDatasource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Query("query")
+		dsName := c.Query("datasource")
+		allowlist, userID, groups := holder.Allowlist(), auth.GetUserID(c), access.GetGroups(c)
+		allowed := slices.ContainsFunc(datasourceNames(dsName, defaultDatasource), func(name string) bool {
+			return allowlist.Allows(userID, groups, name, query)
+		})
+		if !allowed {
+			slog.Warn("rejected query not derived from a dashboard", "path", c.Request.URL.Path, "datasource", dsName, "query", query)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "query is not used by any dashboard"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireDashboardLabelValues is the RequireDashboardQuery of
+// /api/label-values: the datasource, label and series selector must be those
+// of a label_values variable query.
+func RequireDashboardLabelValues(holder *dashboard.StoreHolder, defaultDatasource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		label := c.Query("label")
+		dsName := c.Query("datasource")
+		match := c.Query("match")
+		matches := c.QueryArray("match[]")
+		if len(matches) > 0 {
+			match = matches[0]
+		}
+		allowlist, userID, groups := holder.Allowlist(), auth.GetUserID(c), access.GetGroups(c)
+		allowed := len(matches) <= 1 && slices.ContainsFunc(datasourceNames(dsName, defaultDatasource), func(name string) bool {
+			return allowlist.AllowsLabelValues(userID, groups, name, label, match)
+		})
+		if !allowed {
+			slog.Warn("rejected label values not derived from a dashboard", "datasource", dsName, "label", label, "match", match)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "label values are not used by any dashboard"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// datasourceNames returns the names a dashboard may give the datasource of a
+// request: the default datasource is also the one of queries without any.
+func datasourceNames(dsName, defaultDatasource string) []string {
+	if dsName == "" || dsName == defaultDatasource {
+		return []string{"", defaultDatasource}
+	}
+	return []string{dsName}
+}
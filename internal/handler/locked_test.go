@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/access"
+	"github.com/tokuhirom/dashyard/internal/auth"
+	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/dashboard"
+)
+
+const lockedDashboard = `
+title: "Service"
+variables:
+  - name: job
+    query: "label_values(up, job)"
+rows:
+  - title: "Row"
+    panels:
+      - title: "Up"
+        type: graph
+        query: 'up{job="$job"}'
+`
+
+func newLockedRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "service.yaml"), []byte(lockedDashboard), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := dashboard.LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	holder := dashboard.NewStoreHolder(store)
+
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "success"}) }
+	router := gin.New()
+	router.GET("/api/query", RequireDashboardQuery(holder, "default"), ok)
+	router.GET("/api/label-values", RequireDashboardLabelValues(holder, "default"), ok)
+	return router
+}
+
+func TestRequireDashboardQuery(t *testing.T) {
+	router := newLockedRouter(t)
+
+	tests := []struct {
+		name       string
+		params     url.Values
+		wantStatus int
+	}{
+		{"dashboard query", url.Values{"query": {`up{job="api"}`}}, http.StatusOK},
+		{"dashboard query with filters", url.Values{"query": {`up{job="api"}`}, "filters": {`{instance="a"}`}}, http.StatusOK},
+		{"variable query", url.Values{"query": {`label_values(up, job)`}}, http.StatusOK},
+		{"default datasource by name", url.Values{"query": {`up{job="api"}`}, "datasource": {"default"}}, http.StatusOK},
+		{"dashboard query against another datasource", url.Values{"query": {`up{job="api"}`}, "datasource": {"secret"}}, http.StatusForbidden},
+		{"arbitrary query", url.Values{"query": {`secret_metric`}}, http.StatusForbidden},
+		{"escaping the variable string", url.Values{"query": {`up{job="api"} or secret_metric{job=""}`}}, http.StatusForbidden},
+		{"missing query", url.Values{}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/query?"+tt.params.Encode(), nil)
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireDashboardLabelValues(t *testing.T) {
+	router := newLockedRouter(t)
+
+	tests := []struct {
+		name       string
+		params     url.Values
+		wantStatus int
+	}{
+		{"variable label and selector", url.Values{"label": {"job"}, "match[]": {"up"}}, http.StatusOK},
+		{"legacy match parameter", url.Values{"label": {"job"}, "match": {"up"}}, http.StatusOK},
+		{"other datasource", url.Values{"label": {"job"}, "match[]": {"up"}, "datasource": {"secret"}}, http.StatusForbidden},
+		{"other selector", url.Values{"label": {"job"}, "match[]": {"secret_metric"}}, http.StatusForbidden},
+		{"no selector", url.Values{"label": {"job"}}, http.StatusForbidden},
+		{"other label", url.Values{"label": {"password"}, "match[]": {"up"}}, http.StatusForbidden},
+		{"several selectors", url.Values{"label": {"job"}, "match[]": {"up", "secret_metric"}}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/label-values?"+tt.params.Encode(), nil)
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireDashboardQueryHiddenDashboard(t *testing.T) {
+	dir := t.TempDir()
+	hidden := "title: Payments\naccess:\n  groups: [payments]\nrows:\n  - title: R\n    panels:\n      - title: P\n        type: graph\n        query: payments_total\n"
+	if err := os.WriteFile(filepath.Join(dir, "payments.yaml"), []byte(hidden), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := dashboard.LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	policies, err := access.New(&config.Config{
+		Groups: []config.Group{{Name: "payments", Members: []string{"alice"}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sm := auth.NewSessionManager("test-secret-that-is-32bytes!!", false)
+	router := gin.New()
+	router.Use(auth.AuthMiddleware(sm, nil, nil), access.Middleware(policies))
+	router.GET("/api/query", RequireDashboardQuery(dashboard.NewStoreHolder(store), "default"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "success"})
+	})
+
+	target := "/api/query?" + url.Values{"query": {"payments_total"}}.Encode()
+	if w := serveAs(t, router, sm, "alice", target); w.Code != http.StatusOK {
+		t.Errorf("member: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := serveAs(t, router, sm, "bob", target); w.Code != http.StatusForbidden {
+		t.Errorf("non-member: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
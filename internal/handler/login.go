@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/tokuhirom/dashyard/internal/auth"
-	"github.com/tokuhirom/dashyard/internal/config"
 )
 
 type loginRequest struct {
@@ -15,15 +18,21 @@ type loginRequest struct {
 
 // LoginHandler handles POST /api/login requests.
 type LoginHandler struct {
-	users   []config.User
-	session *auth.SessionManager
+	users    *auth.UserStore
+	session  *auth.SessionManager
+	ldap     *auth.LDAPAuth
+	throttle *auth.LoginThrottle
 }
 
-// NewLoginHandler creates a new LoginHandler.
-func NewLoginHandler(users []config.User, session *auth.SessionManager) *LoginHandler {
+// NewLoginHandler creates a new LoginHandler. If ldap is not nil, users who
+// are not in users are checked against the LDAP directory. If throttle is not
+// nil, it limits the failed logins of each client IP and user ID.
+func NewLoginHandler(users *auth.UserStore, session *auth.SessionManager, ldap *auth.LDAPAuth, throttle *auth.LoginThrottle) *LoginHandler {
 	return &LoginHandler{
-		users:   users,
-		session: session,
+		users:    users,
+		session:  session,
+		ldap:     ldap,
+		throttle: throttle,
 	}
 }
 
@@ -35,24 +44,61 @@ func (h *LoginHandler) Handle(c *gin.Context) {
 		return
 	}
 
-	// Find user
-	var user *config.User
-	for i := range h.users {
-		if h.users[i].ID == req.UserID {
-			user = &h.users[i]
-			break
-		}
+	// ClientIP honors X-Forwarded-For only from server.trusted_proxies.
+	ip := c.ClientIP()
+	if wait, ok := h.throttle.Allow(ip, req.UserID); !ok {
+		auditLogin(c, slog.LevelWarn, "login throttled", req.UserID, "retry_after", wait)
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed logins, try again later"})
+		return
 	}
 
-	if user == nil || !auth.VerifyPassword(req.Password, user.PasswordHash) {
+	groups, backend, err := h.authenticate(req.UserID, req.Password)
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		auditLogin(c, slog.LevelWarn, "login failed", req.UserID, "reason", "invalid_credentials")
+		for _, scope := range h.throttle.Fail(ip, req.UserID) {
+			auditLogin(c, slog.LevelWarn, "login locked out", req.UserID, "scope", scope)
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
+	if err != nil {
+		h.throttle.Release(ip, req.UserID)
+		slog.Error("LDAP login failed", "user", req.UserID, "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "directory unavailable"})
+		return
+	}
+	h.throttle.Succeed(ip, req.UserID)
 
-	if err := h.session.CreateSession(c.Request, c.Writer, user.ID); err != nil {
+	if err := h.session.CreateSessionWithGroups(c.Request, c.Writer, req.UserID, groups); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "session creation failed"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user_id": user.ID})
+	auditLogin(c, slog.LevelInfo, "login succeeded", req.UserID, "backend", backend)
+	c.JSON(http.StatusOK, gin.H{"user_id": req.UserID})
+}
+
+// authenticate checks the password of a user, returning the user's groups
+// and the backend that knows the user: "local" or "ldap". It returns
+// auth.ErrInvalidCredentials for a wrong password or an unknown user.
+func (h *LoginHandler) authenticate(userID, password string) ([]string, string, error) {
+	if user, found := h.users.Find(userID); found {
+		if !auth.VerifyPassword(password, user.PasswordHash) {
+			return nil, "local", auth.ErrInvalidCredentials
+		}
+		return nil, "local", nil
+	}
+	if h.ldap != nil {
+		groups, err := h.ldap.Authenticate(userID, password)
+		return groups, "ldap", err
+	}
+	return nil, "", auth.ErrInvalidCredentials
+}
+
+// auditLogin logs a login attempt as a structured audit entry, marked with
+// audit=login so that log pipelines can pick out the audit trail.
+func auditLogin(c *gin.Context, level slog.Level, msg, userID string, args ...any) {
+	attrs := append([]any{"audit", "login", "user", userID, "ip", c.ClientIP()}, args...)
+	slog.Log(c.Request.Context(), level, msg, attrs...)
 }
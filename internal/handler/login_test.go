@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/GehirnInc/crypt"
 	_ "github.com/GehirnInc/crypt/sha512_crypt"
@@ -32,7 +33,7 @@ func TestLoginSuccess(t *testing.T) {
 		{ID: "admin", PasswordHash: generateTestHash("password123")},
 	}
 	sm := auth.NewSessionManager("test-secret", false)
-	handler := NewLoginHandler(users, sm)
+	handler := NewLoginHandler(auth.NewUserStore(users), sm, nil, nil)
 
 	router := gin.New()
 	router.POST("/api/login", handler.Handle)
@@ -74,7 +75,7 @@ func TestLoginWrongPassword(t *testing.T) {
 		{ID: "admin", PasswordHash: generateTestHash("password123")},
 	}
 	sm := auth.NewSessionManager("test-secret", false)
-	handler := NewLoginHandler(users, sm)
+	handler := NewLoginHandler(auth.NewUserStore(users), sm, nil, nil)
 
 	router := gin.New()
 	router.POST("/api/login", handler.Handle)
@@ -95,7 +96,7 @@ func TestLoginUnknownUser(t *testing.T) {
 		{ID: "admin", PasswordHash: generateTestHash("password123")},
 	}
 	sm := auth.NewSessionManager("test-secret", false)
-	handler := NewLoginHandler(users, sm)
+	handler := NewLoginHandler(auth.NewUserStore(users), sm, nil, nil)
 
 	router := gin.New()
 	router.POST("/api/login", handler.Handle)
@@ -113,7 +114,7 @@ func TestLoginUnknownUser(t *testing.T) {
 
 func TestLoginBadRequest(t *testing.T) {
 	sm := auth.NewSessionManager("test-secret", false)
-	handler := NewLoginHandler(nil, sm)
+	handler := NewLoginHandler(auth.NewUserStore(nil), sm, nil, nil)
 
 	router := gin.New()
 	router.POST("/api/login", handler.Handle)
@@ -128,3 +129,52 @@ func TestLoginBadRequest(t *testing.T) {
 		t.Errorf("expected 400, got %d", resp.Code)
 	}
 }
+
+func TestLoginThrottled(t *testing.T) {
+	users := []config.User{
+		{ID: "admin", PasswordHash: generateTestHash("password123")},
+		{ID: "viewer", PasswordHash: generateTestHash("viewer-secret")},
+	}
+	sm := auth.NewSessionManager("test-secret", false)
+	throttle := auth.NewLoginThrottle(config.LoginRateLimitConfig{
+		MaxFailuresPerIP:   20,
+		MaxFailuresPerUser: 5,
+		Backoff:            time.Hour,
+		MaxBackoff:         time.Hour,
+		Lockout:            2 * time.Hour,
+	})
+	router := gin.New()
+	router.POST("/api/login", NewLoginHandler(auth.NewUserStore(users), sm, nil, throttle).Handle)
+
+	login := func(remoteAddr, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	if resp := login("192.0.2.1:1234", `{"user_id":"admin","password":"wrong"}`); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d: %s", resp.Code, resp.Body.String())
+	}
+
+	// Even the right password must wait for the backoff, from any client.
+	resp := login("198.51.100.1:1234", `{"user_id":"admin","password":"password123"}`)
+	if resp.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if got := resp.Header().Get("Retry-After"); got != "3600" {
+		t.Errorf("expected Retry-After 3600, got %q", got)
+	}
+
+	// The client that failed waits as any user.
+	if resp := login("192.0.2.1:1234", `{"user_id":"viewer","password":"viewer-secret"}`); resp.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d: %s", resp.Code, resp.Body.String())
+	}
+
+	// Other users of other clients are not held back.
+	if resp := login("198.51.100.1:1234", `{"user_id":"viewer","password":"viewer-secret"}`); resp.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+}
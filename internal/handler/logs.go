@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/access"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+)
+
+const (
+	defaultLogsLimit = 100
+	maxLogsLimit     = 5000
+)
+
+// LogsHandler handles GET /api/logs - proxies log line queries to a logs datasource.
+type LogsHandler struct {
+	registry *datasource.Registry
+}
+
+// NewLogsHandler creates a new LogsHandler.
+func NewLogsHandler(registry *datasource.Registry) *LogsHandler {
+	return &LogsHandler{registry: registry}
+}
+
+// Handle processes a log query proxy request.
+func (h *LogsHandler) Handle(c *gin.Context) {
+	h.serve(c, c.Query("query"), c.Query("datasource"))
+}
+
+// serve runs the log query on the datasource dsName.
+func (h *LogsHandler) serve(c *gin.Context, query, dsName string) {
+	start := c.Query("start")
+	end := c.Query("end")
+
+	if query == "" || start == "" || end == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query, start, and end parameters are required"})
+		return
+	}
+
+	limit := defaultLogsLimit
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxLogsLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 5000"})
+			return
+		}
+		limit = n
+	}
+
+	direction := c.DefaultQuery("direction", "backward")
+	if direction != "backward" && direction != "forward" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "direction must be 'backward' or 'forward'"})
+		return
+	}
+
+	client, err := h.registry.GetLogs(dsName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Log queries cannot be restricted by label policies.
+	if len(access.GetMatchers(c)) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": errPolicyDatasource.Error()})
+		return
+	}
+	logPanelQuery(c, dsName, query)
+
+	body, statusCode, err := client.QueryLogs(c.Request.Context(), query, start, end, limit, direction)
+	if err != nil {
+		slog.Error("datasource logs query failed", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "datasource logs query failed"})
+		return
+	}
+	defer func() { _ = body.Close() }()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		slog.Error("failed to read datasource response", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to read datasource response"})
+		return
+	}
+
+	c.Data(statusCode, "application/json", data)
+}
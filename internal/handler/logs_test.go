@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+)
+
+func TestLogsHandler(t *testing.T) {
+	lokiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("limit") != "20" {
+			t.Errorf("expected limit '20', got %q", r.URL.Query().Get("limit"))
+		}
+		if r.URL.Query().Get("direction") != "backward" {
+			t.Errorf("expected default direction 'backward', got %q", r.URL.Query().Get("direction"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[]}}`))
+	}))
+	defer lokiServer.Close()
+
+	registry, _ := datasource.NewRegistry([]config.DatasourceConfig{
+		{Name: "prom", Type: "prometheus", URL: "http://localhost:9090", Timeout: 5 * time.Second, Default: true},
+		{Name: "logs", Type: "loki", URL: lokiServer.URL, Timeout: 5 * time.Second},
+	})
+	handler := NewLogsHandler(registry)
+
+	router := gin.New()
+	router.GET("/api/logs", handler.Handle)
+
+	req := httptest.NewRequest("GET", `/api/logs?query={app="web"}&start=1000&end=2000&limit=20&datasource=logs`, nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	expected := `{"status":"success","data":{"resultType":"streams","result":[]}}`
+	if resp.Body.String() != expected {
+		t.Errorf("expected body %q, got %q", expected, resp.Body.String())
+	}
+}
+
+func TestLogsHandlerBadRequests(t *testing.T) {
+	registry, _ := datasource.NewRegistry([]config.DatasourceConfig{
+		{Name: "prom", Type: "prometheus", URL: "http://localhost:9090", Timeout: 5 * time.Second, Default: true},
+		{Name: "logs", Type: "loki", URL: "http://localhost:3100", Timeout: 5 * time.Second},
+	})
+	handler := NewLogsHandler(registry)
+
+	router := gin.New()
+	router.GET("/api/logs", handler.Handle)
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"missing query", "/api/logs?start=1000&end=2000&datasource=logs"},
+		{"missing start", "/api/logs?query=x&end=2000&datasource=logs"},
+		{"missing end", "/api/logs?query=x&start=1000&datasource=logs"},
+		{"invalid limit", "/api/logs?query=x&start=1000&end=2000&limit=0&datasource=logs"},
+		{"limit too large", "/api/logs?query=x&start=1000&end=2000&limit=5001&datasource=logs"},
+		{"invalid direction", "/api/logs?query=x&start=1000&end=2000&direction=up&datasource=logs"},
+		{"prometheus datasource", "/api/logs?query=x&start=1000&end=2000&datasource=prom"},
+		{"default prometheus datasource", "/api/logs?query=x&start=1000&end=2000"},
+		{"unknown datasource", "/api/logs?query=x&start=1000&end=2000&datasource=nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", resp.Code)
+			}
+		})
+	}
+}
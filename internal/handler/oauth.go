@@ -70,13 +70,7 @@ func (h *OAuthHandler) Callback(c *gin.Context) {
 		return
 	}
 
-	// Use NickName as the user ID (GitHub username)
-	userID := gothUser.NickName
-	if userID == "" {
-		userID = gothUser.Email
-	}
-
-	if err := h.session.CreateSession(c.Request, c.Writer, userID); err != nil {
+	if err := h.session.CreateSession(c.Request, c.Writer, auth.OAuthUserID(gothUser)); err != nil {
 		slog.Error("OAuth session creation failed", "error", err)
 		c.Redirect(http.StatusTemporaryRedirect, "/?error=session_failed")
 		return
@@ -68,19 +68,33 @@ func newDummyGitHub() *httptest.Server {
 		})
 	})
 
+	mux.HandleFunc("GET /api/v3/user/teams", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+			{
+				"slug":         "sre",
+				"name":         "SRE",
+				"organization": map[string]interface{}{"login": "dummy-org"},
+			},
+		})
+	})
+
 	return httptest.NewServer(mux)
 }
 
 // setupOAuthTestServer creates a Gin router with OAuth endpoints backed by a dummygithub server.
-func setupOAuthTestServer(dummyGitHubURL string, providers []config.OAuthProviderConfig) *gin.Engine {
+func setupOAuthTestServer(t *testing.T, dummyGitHubURL string, providers []config.OAuthProviderConfig) *gin.Engine {
+	t.Helper()
 	gin.SetMode(gin.TestMode)
 
 	sm := auth.NewSessionManager("test-secret-that-is-at-least-32-bytes-long!", false)
-	auth.InitGothProviders(providers)
+	if err := auth.InitGothProviders(providers); err != nil {
+		t.Fatalf("InitGothProviders failed: %v", err)
+	}
 	gothic.Store = sm.Store()
 
 	oauthHandler := NewOAuthHandler(providers, sm)
-	authInfoHandler := NewAuthInfoHandler(nil, providers)
+	authInfoHandler := NewAuthInfoHandler(nil, providers, false)
 
 	router := gin.New()
 	router.GET("/api/auth-info", authInfoHandler.Handle)
@@ -91,6 +105,78 @@ func setupOAuthTestServer(dummyGitHubURL string, providers []config.OAuthProvide
 	return router
 }
 
+// startOAuthApp serves the OAuth endpoints with a single provider, whose
+// client credentials and redirect URL it fills in.
+func startOAuthApp(t *testing.T, provider config.OAuthProviderConfig) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	sm := auth.NewSessionManager("test-secret-that-is-at-least-32-bytes-long!", false)
+
+	provider.ClientID = "dummy-client-id"
+	provider.ClientSecret = "dummy-client-secret"
+	providers := []config.OAuthProviderConfig{provider}
+
+	router := gin.New()
+	oauthHandler := NewOAuthHandler(providers, sm)
+	router.GET("/auth/:provider", oauthHandler.BeginAuth)
+	router.GET("/auth/:provider/callback", oauthHandler.Callback)
+
+	appServer := httptest.NewServer(router)
+	t.Cleanup(appServer.Close)
+
+	providers[0].RedirectURL = appServer.URL + "/auth/" + provider.Provider + "/callback"
+	if err := auth.InitGothProviders(providers); err != nil {
+		t.Fatalf("InitGothProviders failed: %v", err)
+	}
+	gothic.Store = sm.Store()
+	return appServer
+}
+
+// runOAuthLogin goes through the login flow of the app with a provider whose
+// authorize endpoint approves right away, and returns the response of the
+// app's callback.
+func runOAuthLogin(t *testing.T, appURL, provider string) *http.Response {
+	t.Helper()
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// Step 1: Start the flow; the gothic session cookie carries its state.
+	resp, err := client.Get(appURL + "/auth/" + provider)
+	if err != nil {
+		t.Fatalf("BeginAuth request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusTemporaryRedirect && resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected redirect from BeginAuth, got %d", resp.StatusCode)
+	}
+	cookies := resp.Cookies()
+
+	// Step 2: The issuer approves and redirects back to the callback.
+	resp2, err := client.Get(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize request failed: %v", err)
+	}
+	_ = resp2.Body.Close()
+	if resp2.StatusCode != http.StatusFound {
+		t.Fatalf("expected redirect from the provider, got %d", resp2.StatusCode)
+	}
+
+	// Step 3: The callback exchanges the code.
+	req, _ := http.NewRequest("GET", resp2.Header.Get("Location"), nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	resp3, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("callback request failed: %v", err)
+	}
+	_ = resp3.Body.Close()
+	return resp3
+}
+
 func TestOAuthIntegrationBeginAuthRedirectsToDummyGitHub(t *testing.T) {
 	dummyGH := newDummyGitHub()
 	defer dummyGH.Close()
@@ -104,7 +190,7 @@ func TestOAuthIntegrationBeginAuthRedirectsToDummyGitHub(t *testing.T) {
 			RedirectURL:  "http://localhost:8080/auth/github/callback",
 		},
 	}
-	router := setupOAuthTestServer(dummyGH.URL, providers)
+	router := setupOAuthTestServer(t, dummyGH.URL, providers)
 
 	req := httptest.NewRequest("GET", "/auth/github", nil)
 	resp := httptest.NewRecorder()
@@ -159,7 +245,9 @@ func TestOAuthIntegrationFullFlow(t *testing.T) {
 
 	// Now update the redirect URL and re-initialize providers
 	providers[0].RedirectURL = appServer.URL + "/auth/github/callback"
-	auth.InitGothProviders(providers)
+	if err := auth.InitGothProviders(providers); err != nil {
+		t.Fatalf("InitGothProviders failed: %v", err)
+	}
 	gothic.Store = sm.Store()
 
 	// Use an HTTP client that does NOT follow redirects automatically
@@ -269,7 +357,9 @@ func TestOAuthIntegrationWithAllowedUsers(t *testing.T) {
 	defer appServer.Close()
 
 	providers[0].RedirectURL = appServer.URL + "/auth/github/callback"
-	auth.InitGothProviders(providers)
+	if err := auth.InitGothProviders(providers); err != nil {
+		t.Fatalf("InitGothProviders failed: %v", err)
+	}
 	gothic.Store = sm.Store()
 
 	client := &http.Client{
@@ -344,7 +434,9 @@ func TestOAuthIntegrationWithAllowedOrgs(t *testing.T) {
 	defer appServer.Close()
 
 	providers[0].RedirectURL = appServer.URL + "/auth/github/callback"
-	auth.InitGothProviders(providers)
+	if err := auth.InitGothProviders(providers); err != nil {
+		t.Fatalf("InitGothProviders failed: %v", err)
+	}
 	gothic.Store = sm.Store()
 
 	client := &http.Client{
@@ -403,6 +495,37 @@ func TestOAuthIntegrationWithAllowedOrgs(t *testing.T) {
 	}
 }
 
+func TestOAuthIntegrationWithAllowedTeams(t *testing.T) {
+	dummyGH := newDummyGitHub()
+	defer dummyGH.Close()
+
+	// dummygithub returns team "dummy-org/sre"
+	tests := []struct {
+		name  string
+		teams []string
+		want  string
+	}{
+		{"member", []string{"dummy-org/sre"}, "/"},
+		{"not a member", []string{"dummy-org/security"}, "/?error=access_denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := startOAuthApp(t, config.OAuthProviderConfig{
+				Provider:     "github",
+				BaseURL:      dummyGH.URL,
+				AllowedTeams: tt.teams,
+			})
+			resp := runOAuthLogin(t, app.URL, "github")
+			if resp.StatusCode != http.StatusTemporaryRedirect {
+				t.Fatalf("expected 307, got %d", resp.StatusCode)
+			}
+			if loc := resp.Header.Get("Location"); loc != tt.want {
+				t.Errorf("expected redirect to %q, got %q", tt.want, loc)
+			}
+		})
+	}
+}
+
 func TestOAuthIntegrationAuthInfoEndpoint(t *testing.T) {
 	dummyGH := newDummyGitHub()
 	defer dummyGH.Close()
@@ -417,7 +540,7 @@ func TestOAuthIntegrationAuthInfoEndpoint(t *testing.T) {
 		},
 	}
 
-	router := setupOAuthTestServer(dummyGH.URL, providers)
+	router := setupOAuthTestServer(t, dummyGH.URL, providers)
 
 	req := httptest.NewRequest("GET", "/api/auth-info", nil)
 	resp := httptest.NewRecorder()
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tokuhirom/dashyard/internal/auth"
+	"github.com/tokuhirom/dashyard/internal/config"
+)
+
+// oidcProvider is the config of the oidc provider of a dummy issuer.
+func oidcProvider(issuerURL string) config.OAuthProviderConfig {
+	return config.OAuthProviderConfig{Provider: "oidc", DiscoveryURL: issuerURL + "/.well-known/openid-configuration"}
+}
+
+// newDummyOIDC starts a fake OpenID Connect issuer, like cmd/dummyoidc, that
+// signs in "dummyuser" with the given claims; a nil claim removes a default
+// one. It requires PKCE: the token endpoint rejects a code whose verifier does
+// not match its S256 challenge.
+func newDummyOIDC(t *testing.T, claims map[string]interface{}) *httptest.Server {
+	t.Helper()
+	var (
+		mu         sync.Mutex
+		challenges = map[string]string{} // code -> code_challenge
+	)
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 srv.URL,
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"userinfo_endpoint":      srv.URL + "/userinfo",
+		})
+	})
+
+	mux.HandleFunc("GET /authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+			http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+			return
+		}
+		// Auto-approve: redirect back with a code immediately.
+		code := "code-" + q.Get("state")
+		mu.Lock()
+		challenges[code] = q.Get("code_challenge")
+		mu.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code="+url.QueryEscape(code)+"&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		challenge, ok := challenges[r.FormValue("code")]
+		mu.Unlock()
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		idClaims := map[string]interface{}{
+			"iss":                srv.URL,
+			"sub":                "1001",
+			"aud":                "dummy-client-id",
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"preferred_username": "dummyuser",
+		}
+		for k, v := range claims {
+			if v == nil {
+				delete(idClaims, k)
+				continue
+			}
+			idClaims[k] = v
+		}
+		header, _ := json.Marshal(map[string]string{"alg": "none"})
+		payload, _ := json.Marshal(idClaims)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "dummy-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + ".",
+		})
+	})
+
+	mux.HandleFunc("GET /userinfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"sub": "1001", "email": "dummy@example.com"})
+	})
+
+	return srv
+}
+
+func TestOIDCIntegrationBeginAuthSendsPKCEChallenge(t *testing.T) {
+	issuer := newDummyOIDC(t, nil)
+	defer issuer.Close()
+	app := startOAuthApp(t, oidcProvider(issuer.URL))
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(app.URL + "/auth/oidc")
+	if err != nil {
+		t.Fatalf("BeginAuth request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("failed to parse Location header: %v", err)
+	}
+	if got := loc.Scheme + "://" + loc.Host + loc.Path; got != issuer.URL+"/authorize" {
+		t.Errorf("expected redirect to %s/authorize, got %s", issuer.URL, got)
+	}
+	q := loc.Query()
+	if q.Get("client_id") != "dummy-client-id" {
+		t.Errorf("expected client_id 'dummy-client-id', got %q", q.Get("client_id"))
+	}
+	if q.Get("scope") != "openid profile email" {
+		t.Errorf("expected default scopes 'openid profile email', got %q", q.Get("scope"))
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		t.Errorf("expected an S256 code challenge, got %q (%q)", q.Get("code_challenge"), q.Get("code_challenge_method"))
+	}
+}
+
+func TestOIDCIntegrationFullFlow(t *testing.T) {
+	issuer := newDummyOIDC(t, map[string]interface{}{"groups": []string{"developers", "sre"}})
+	defer issuer.Close()
+	provider := oidcProvider(issuer.URL)
+	provider.AllowedGroups = []string{"sre"}
+	app := startOAuthApp(t, provider)
+
+	resp := runOAuthLogin(t, app.URL, "oidc")
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("expected 307 redirect from callback, got %d", resp.StatusCode)
+	}
+	if loc := resp.Header.Get("Location"); loc != "/" {
+		t.Errorf("expected redirect to '/', got %q", loc)
+	}
+
+	var sessionCookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == "dashyard_session" {
+			sessionCookie = c
+		}
+	}
+	if sessionCookie == nil {
+		t.Fatal("expected dashyard_session cookie to be set after OIDC flow")
+	}
+
+	sm := auth.NewSessionManager("test-secret-that-is-at-least-32-bytes-long!", false)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(sessionCookie)
+	userID, err := sm.ValidateSession(req)
+	if err != nil {
+		t.Fatalf("ValidateSession failed: %v", err)
+	}
+	if userID != "dummyuser" {
+		t.Errorf("expected user ID from preferred_username 'dummyuser', got %q", userID)
+	}
+}
+
+func TestOIDCIntegrationGroupsClaim(t *testing.T) {
+	issuer := newDummyOIDC(t, map[string]interface{}{"groups": []string{"sre"}, "roles": "viewer"})
+	defer issuer.Close()
+
+	tests := []struct {
+		name     string
+		provider config.OAuthProviderConfig
+		want     string
+	}{
+		{"not in allowed groups", config.OAuthProviderConfig{AllowedGroups: []string{"admins"}}, "/?error=access_denied"},
+		{"custom claim", config.OAuthProviderConfig{GroupsClaim: "roles", AllowedGroups: []string{"viewer"}}, "/"},
+		{"custom claim without group", config.OAuthProviderConfig{GroupsClaim: "roles", AllowedGroups: []string{"sre"}}, "/?error=access_denied"},
+		{"allowed user", config.OAuthProviderConfig{AllowedUsers: []string{"dummyuser"}, AllowedGroups: []string{"admins"}}, "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := tt.provider
+			provider.Provider = "oidc"
+			provider.DiscoveryURL = issuer.URL + "/.well-known/openid-configuration"
+			app := startOAuthApp(t, provider)
+			resp := runOAuthLogin(t, app.URL, "oidc")
+			if loc := resp.Header.Get("Location"); loc != tt.want {
+				t.Errorf("expected redirect to %q, got %q", tt.want, loc)
+			}
+		})
+	}
+}
+
+func TestOIDCIntegrationDiscoveryFailure(t *testing.T) {
+	issuer := httptest.NewServer(http.NotFoundHandler())
+	defer issuer.Close()
+
+	err := auth.InitGothProviders([]config.OAuthProviderConfig{{
+		Provider:     "oidc",
+		ClientID:     "dummy-client-id",
+		ClientSecret: "dummy-client-secret",
+		DiscoveryURL: issuer.URL + "/.well-known/openid-configuration",
+	}})
+	if err == nil {
+		t.Error("expected an error when the discovery document cannot be fetched")
+	}
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/dashboard"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+	"github.com/tokuhirom/dashyard/internal/model"
+	"github.com/tokuhirom/dashyard/internal/variable"
+)
+
+// allSelection is the value the frontend sends for an "All" selection.
+const allSelection = "$__all"
+
+// PanelQueryHandler handles GET /api/panel-query - runs a query of a panel
+// of a loaded dashboard, with its variables substituted server-side.
+type PanelQueryHandler struct {
+	holder *dashboard.StoreHolder
+	// modes run a resolved query like the endpoint of the same name.
+	modes map[string]func(c *gin.Context, query, dsName string)
+}
+
+// panelQueryKey is the gin context key of the panel a query runs for. It is
+// set by PanelQueryHandler so that the executed query is logged.
+const panelQueryKey = "panel_query"
+
+// panelRef identifies the panel a query runs for, for the log.
+type panelRef struct {
+	dashboard string
+	panel     string
+}
+
+// NewPanelQueryHandler creates a new PanelQueryHandler.
+func NewPanelQueryHandler(holder *dashboard.StoreHolder, registry *datasource.Registry) *PanelQueryHandler {
+	return &PanelQueryHandler{
+		holder: holder,
+		modes: map[string]func(c *gin.Context, query, dsName string){
+			"range":   NewQueryHandler(registry).serve,
+			"instant": NewInstantQueryHandler(registry).serve,
+			"reduce":  NewReduceHandler(registry).serve,
+			"heatmap": NewHeatmapHandler(registry).serve,
+			"logs":    NewLogsHandler(registry).serve,
+		},
+	}
+}
+
+// Handle processes a panel query request. path is the dashboard, panel the
+// panel id "<row>-<index>" counting from 0, index the query of a table panel
+// with several queries (default 0), and var-<name> the selected values of
+// each variable, as in the dashboard URL. mode selects how the substituted
+// query runs: range (the default), instant, reduce, heatmap or logs, taking
+// the remaining parameters and responding like /api/query,
+// /api/instant-query, /api/reduce, /api/heatmap and /api/logs. The executed
+// query is logged.
+func (h *PanelQueryHandler) Handle(c *gin.Context) {
+	path := c.Query("path")
+	panelID := c.Query("panel")
+	if path == "" || panelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path and panel parameters are required"})
+		return
+	}
+	run, ok := h.modes[c.DefaultQuery("mode", "range")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be one of range, instant, reduce, heatmap, logs"})
+		return
+	}
+
+	d := visibleDashboard(c, h.holder.Store(), path)
+	if d == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "dashboard not found"})
+		return
+	}
+	panel := findPanel(d, panelID)
+	if panel == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "panel not found"})
+		return
+	}
+	template, err := panelQuery(panel, c.DefaultQuery("index", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	selections, err := variableSelections(c, d)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query, err := variable.Substitute(template, selections)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dsName, err := variable.Substitute(panel.Datasource, selections)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Set(panelQueryKey, panelRef{dashboard: d.Path, panel: panelID})
+	run(c, query, dsName)
+}
+
+// logPanelQuery logs the query about to run, with the label matchers of
+// filters and policies added, when it is the query of a panel.
+func logPanelQuery(c *gin.Context, dsName, query string) {
+	v, ok := c.Get(panelQueryKey)
+	if !ok {
+		return
+	}
+	ref := v.(panelRef)
+	slog.Info("panel query", "dashboard", ref.dashboard, "panel", ref.panel, "datasource", dsName, "query", query)
+}
+
+// panelQuery returns the query template of a panel: its query, or the one at
+// index of the queries of a table panel.
+func panelQuery(panel *model.Panel, index string) (string, error) {
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 {
+		return "", errors.New("index must be a non-negative integer")
+	}
+	if len(panel.Queries) > 0 {
+		if i >= len(panel.Queries) {
+			return "", fmt.Errorf("panel %q has %d queries", panel.Title, len(panel.Queries))
+		}
+		return panel.Queries[i].Query, nil
+	}
+	if panel.Query == "" {
+		return "", fmt.Errorf("panel %q has no query", panel.Title)
+	}
+	if i > 0 {
+		return "", fmt.Errorf("panel %q has a single query", panel.Title)
+	}
+	return panel.Query, nil
+}
+
+// findPanel returns the panel with the id "<row>-<index>", or nil.
+func findPanel(d *model.Dashboard, id string) *model.Panel {
+	rowStr, panelStr, ok := strings.Cut(id, "-")
+	if !ok {
+		return nil
+	}
+	row, err1 := strconv.Atoi(rowStr)
+	idx, err2 := strconv.Atoi(panelStr)
+	if err1 != nil || err2 != nil || row < 0 || row >= len(d.Rows) || idx < 0 || idx >= len(d.Rows[row].Panels) {
+		return nil
+	}
+	return &d.Rows[row].Panels[idx]
+}
+
+// variableSelections builds the selection of every dashboard variable from
+// its var-<name> parameters. Constants always stand for their value; static
+// variables without a selection fall back to their default.
+func variableSelections(c *gin.Context, d *model.Dashboard) (map[string]variable.Selection, error) {
+	out := make(map[string]variable.Selection, len(d.Variables))
+	for _, v := range d.Variables {
+		values := c.QueryArray("var-" + v.Name)
+		switch {
+		case v.Type == "adhoc_filters":
+			continue
+		case v.Type == "constant":
+			values = []string{v.Query}
+		case len(values) == 0 && v.Type == "textbox":
+			values = []string{v.Query}
+		case len(values) == 0 && (v.Type == "custom" || v.Type == "interval"):
+			if options := v.Options(); len(options) > 0 {
+				values = options[:1]
+			}
+		}
+
+		if !slices.Contains(values, allSelection) {
+			if len(values) > 1 && !v.Multi && !v.IncludeAll {
+				return nil, fmt.Errorf("variable %q takes a single value", v.Name)
+			}
+			// As in the browser, the value of a variable that takes a single
+			// value is a regex of its own in =~ matchers.
+			out[v.Name] = variable.Selection{Values: values, Verbatim: !v.Multi && !v.IncludeAll}
+			continue
+		}
+		switch {
+		case !v.IncludeAll:
+			return nil, fmt.Errorf("variable %q has no All option", v.Name)
+		case v.AllValue != "":
+			out[v.Name] = variable.Selection{Regex: v.AllValue}
+		case v.Type == "custom":
+			out[v.Name] = variable.Selection{Values: v.Options()}
+		default:
+			return nil, fmt.Errorf("variable %q has no all_value; pass the values All stands for", v.Name)
+		}
+	}
+	return out, nil
+}
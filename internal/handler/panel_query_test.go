@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+)
+
+const panelQueryDashboard = `
+title: "Hosts"
+variables:
+  - name: host
+    query: "label_values(up, instance)"
+    multi: true
+    include_all: true
+  - name: env
+    type: custom
+    query: "prod,staging"
+    include_all: true
+  - name: job
+    type: constant
+    query: "node"
+  - name: window
+    type: interval
+    query: "5m,1h"
+  - name: device
+    type: textbox
+    query: "eth.*"
+  - name: filters
+    type: adhoc_filters
+rows:
+  - title: "Row"
+    panels:
+      - title: "Notes"
+        type: markdown
+        content: "hello"
+      - title: "CPU"
+        type: graph
+        query: 'rate(cpu{instance=~"$host", env=~"$env", job="$job"}[$window])'
+      - title: "Hosts"
+        type: table
+        queries:
+          - query: 'up{instance=~"$host"}'
+            name: Up
+          - query: 'node_load1{instance=~"$host"}'
+            name: Load
+      - title: "Network"
+        type: graph
+        query: 'rate(net{device=~"$device"}[5m])'
+`
+
+func TestPanelQueryHandler(t *testing.T) {
+	var gotQuery string
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			t.Errorf("expected path '/api/v1/query_range', got %q", r.URL.Path)
+		}
+		gotQuery = r.URL.Query().Get("query")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	}))
+	defer promServer.Close()
+
+	holder := newTestStoreHolder(t, "hosts.yaml", panelQueryDashboard)
+	router := newHandlerRouter(t, "/api/panel-query", promServer.URL, func(r *datasource.Registry) gin.HandlerFunc {
+		return NewPanelQueryHandler(holder, r).Handle
+	})
+	tests := []struct {
+		name      string
+		vars      url.Values
+		wantQuery string
+	}{
+		{
+			"multi values are escaped regex alternations",
+			url.Values{"var-host": {"web-1.example.com:9100", "web-2.example.com:9100"}, "var-env": {"prod"}, "var-window": {"1h"}},
+			`rate(cpu{instance=~"web-1\\.example\\.com:9100|web-2\\.example\\.com:9100", env=~"prod", job="node"}[1h])`,
+		},
+		{
+			"All of a custom variable expands to its options, defaults apply",
+			url.Values{"var-host": {"a"}, "var-env": {"$__all"}, "var-job": {"ignored"}},
+			`rate(cpu{instance=~"a", env=~"prod|staging", job="node"}[5m])`,
+		},
+		{
+			"ad-hoc filters are added",
+			url.Values{"var-host": {"a"}, "filters": {`{region="eu"}`}},
+			`rate(cpu{env=~"prod",instance=~"a",job="node",region="eu"}[5m])`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := url.Values{"path": {"hosts"}, "panel": {"0-1"}, "start": {"1000"}, "end": {"2000"}, "step": {"15s"}}
+			for k, v := range tt.vars {
+				params[k] = v
+			}
+			req := httptest.NewRequest("GET", "/api/panel-query?"+params.Encode(), nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+			}
+			if gotQuery != tt.wantQuery {
+				t.Errorf("expected query %s, got %s", tt.wantQuery, gotQuery)
+			}
+		})
+	}
+}
+
+func TestPanelQueryHandlerModes(t *testing.T) {
+	var gotPath, gotQuery string
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotQuery = r.URL.Query().Get("query")
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/query" {
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"instance":"a"},"value":[2000,"1"]}]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"instance":"a"},"values":[[1000,"1"],[2000,"3"]]}]}}`))
+	}))
+	defer promServer.Close()
+
+	holder := newTestStoreHolder(t, "hosts.yaml", panelQueryDashboard)
+	router := newHandlerRouter(t, "/api/panel-query", promServer.URL, func(r *datasource.Registry) gin.HandlerFunc {
+		return NewPanelQueryHandler(holder, r).Handle
+	})
+	tests := []struct {
+		name      string
+		params    url.Values
+		wantPath  string
+		wantQuery string
+		wantBody  string
+	}{
+		{
+			"instant query of a table column",
+			url.Values{"panel": {"0-2"}, "index": {"1"}, "mode": {"instant"}, "time": {"2000"}},
+			"/api/v1/query", `node_load1{instance=~"a"}`, "",
+		},
+		{
+			"the value of a single-value variable is a regex",
+			url.Values{"panel": {"0-3"}, "start": {"1000"}, "end": {"2000"}, "step": {"15s"}},
+			"/api/v1/query_range", `rate(net{device=~"eth.*"}[5m])`, "",
+		},
+		{
+			"first table query by default",
+			url.Values{"panel": {"0-2"}, "mode": {"instant"}},
+			"/api/v1/query", `up{instance=~"a"}`, "",
+		},
+		{
+			"reduced server-side",
+			url.Values{"panel": {"0-1"}, "mode": {"reduce"}, "reduce": {"max"}, "start": {"1000"}, "end": {"2000"}, "step": {"15s"}},
+			"/api/v1/query_range", `rate(cpu{instance=~"a", env=~"prod", job="node"}[5m])`,
+			`{"data":{"result":[{"metric":{"instance":"a"},"value":[2000,"3"]}],"resultType":"vector"},"status":"success"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := url.Values{"path": {"hosts"}, "var-host": {"a"}}
+			for k, v := range tt.params {
+				params[k] = v
+			}
+			req := httptest.NewRequest("GET", "/api/panel-query?"+params.Encode(), nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+			}
+			if gotPath != tt.wantPath || gotQuery != tt.wantQuery {
+				t.Errorf("expected %s %s, got %s %s", tt.wantPath, tt.wantQuery, gotPath, gotQuery)
+			}
+			if tt.wantBody != "" && resp.Body.String() != tt.wantBody {
+				t.Errorf("expected body %s, got %s", tt.wantBody, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestPanelQueryHandlerErrors(t *testing.T) {
+	holder := newTestStoreHolder(t, "hosts.yaml", panelQueryDashboard)
+	router := newHandlerRouter(t, "/api/panel-query", "http://localhost:9090", func(r *datasource.Registry) gin.HandlerFunc {
+		return NewPanelQueryHandler(holder, r).Handle
+	})
+	tests := []struct {
+		name       string
+		params     url.Values
+		wantStatus int
+	}{
+		{"missing params", url.Values{"path": {"hosts"}}, http.StatusBadRequest},
+		{"unknown dashboard", url.Values{"path": {"nope"}, "panel": {"0-1"}}, http.StatusNotFound},
+		{"unknown panel", url.Values{"path": {"hosts"}, "panel": {"0-5"}}, http.StatusNotFound},
+		{"malformed panel id", url.Values{"path": {"hosts"}, "panel": {"1"}}, http.StatusNotFound},
+		{"panel without query", url.Values{"path": {"hosts"}, "panel": {"0-0"}}, http.StatusBadRequest},
+		{"missing query variable value", url.Values{"path": {"hosts"}, "panel": {"0-1"}}, http.StatusBadRequest},
+		{"All without all_value", url.Values{"path": {"hosts"}, "panel": {"0-1"}, "var-host": {"$__all"}}, http.StatusBadRequest},
+		{"several values of a single-value variable", url.Values{"path": {"hosts"}, "panel": {"0-1"}, "var-host": {"a"}, "var-window": {"5m", "1h"}}, http.StatusBadRequest},
+		{"unknown mode", url.Values{"path": {"hosts"}, "panel": {"0-1"}, "var-host": {"a"}, "mode": {"stream"}}, http.StatusBadRequest},
+		{"table query out of range", url.Values{"path": {"hosts"}, "panel": {"0-2"}, "var-host": {"a"}, "index": {"2"}}, http.StatusBadRequest},
+		{"index of a single-query panel", url.Values{"path": {"hosts"}, "panel": {"0-1"}, "var-host": {"a"}, "index": {"1"}}, http.StatusBadRequest},
+		{"unsafe bare value", url.Values{"path": {"hosts"}, "panel": {"0-1"}, "var-host": {"a"}, "var-window": {"5m]) or vector(1"}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := url.Values{"start": {"1000"}, "end": {"2000"}, "step": {"15s"}}
+			for k, v := range tt.params {
+				params[k] = v
+			}
+			req := httptest.NewRequest("GET", "/api/panel-query?"+params.Encode(), nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, resp.Code, resp.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/access"
+	"github.com/tokuhirom/dashyard/internal/auth"
+	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+)
+
+// newPolicyRouter serves the query handlers behind the auth and access
+// middleware, with a label policy restricting the user "alice" to
+// team="payments". It returns a function that sends a request as a given user.
+func newPolicyRouter(t *testing.T, upstreamURL string) func(user, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	registry, err := datasource.NewRegistry([]config.DatasourceConfig{
+		{Name: "default", Type: "prometheus", URL: upstreamURL, Timeout: 5 * time.Second, Default: true},
+		{Name: "logs", Type: "loki", URL: upstreamURL, Timeout: 5 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	policies, err := access.New(&config.Config{
+		Groups:        []config.Group{{Name: "payments", Members: []string{"alice"}}},
+		LabelPolicies: []config.LabelPolicy{{Groups: []string{"payments"}, Matchers: `team="payments"`}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sm := auth.NewSessionManager("test-secret-that-is-32bytes!!", false)
+	router := gin.New()
+	router.Use(auth.AuthMiddleware(sm, nil, nil), access.Middleware(policies))
+	router.GET("/api/query", NewQueryHandler(registry).Handle)
+	router.GET("/api/instant-query", NewInstantQueryHandler(registry).Handle)
+	router.GET("/api/label-values", NewLabelValuesHandler(registry).Handle)
+	router.GET("/api/variable-values", NewVariableValuesHandler(registry).Handle)
+	router.GET("/api/logs", NewLogsHandler(registry).Handle)
+
+	return func(user, target string) *httptest.ResponseRecorder {
+		return serveAs(t, router, sm, user, target)
+	}
+}
+
+// serveAs sends a GET request for target to router with a session of user.
+func serveAs(t *testing.T, router *gin.Engine, sm *auth.SessionManager, user, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	login := httptest.NewRecorder()
+	if err := sm.CreateSession(httptest.NewRequest(http.MethodGet, "/", nil), login, user); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for _, cookie := range login.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestLabelPoliciesRewriteRequests(t *testing.T) {
+	var mu sync.Mutex
+	var got url.Values
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		got = r.URL.Query()
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/query_range":
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+		case "/api/v1/query":
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+		case "/api/v1/series":
+			_, _ = w.Write([]byte(`{"status":"success","data":[]}`))
+		default:
+			_, _ = w.Write([]byte(`{"status":"success","data":[]}`))
+		}
+	}))
+	defer upstream.Close()
+	send := newPolicyRouter(t, upstream.URL)
+
+	rangeParams := "&start=1700000000&end=1700003600&step=60"
+	tests := []struct {
+		name   string
+		user   string
+		target string
+		param  string
+		want   string
+	}{
+		{
+			"query gets the policy matcher",
+			"alice", "/api/query?" + url.Values{"query": {`sum(rate(http_requests_total[5m]))`}}.Encode() + rangeParams,
+			"query", `sum(rate(http_requests_total{team="payments"}[5m]))`,
+		},
+		{
+			"every selector of a crafted query is restricted",
+			"alice", "/api/query?" + url.Values{"query": {`up or {__name__=~".+", team!="payments"}`}}.Encode() + rangeParams,
+			"query", `up{team="payments"} or {__name__=~".+",team!="payments",team="payments"}`,
+		},
+		{
+			"an overriding matcher is kept alongside the policy",
+			"alice", "/api/instant-query?" + url.Values{"query": {`max_over_time(up{team=~".*"}[1h:5m])`}}.Encode(),
+			"query", `max_over_time(up{team="payments",team=~".*"}[1h:5m])`,
+		},
+		{
+			"ad-hoc filters add to the policy",
+			"alice", "/api/query?" + url.Values{"query": {`up`}, "filters": {`{team="search"}`}}.Encode() + rangeParams,
+			"query", `up{team="payments",team="search"}`,
+		},
+		{
+			"label values without a selector",
+			"alice", "/api/label-values?" + url.Values{"label": {"instance"}}.Encode(),
+			"match[]", `{team="payments"}`,
+		},
+		{
+			"label values with a selector",
+			"alice", "/api/label-values?" + url.Values{"label": {"instance"}, "match[]": {`up{job="api"}`}}.Encode(),
+			"match[]", `up{job="api",team="payments"}`,
+		},
+		{
+			"label_values variable",
+			"alice", "/api/variable-values?" + url.Values{"query": {`label_values(up, instance)`}}.Encode(),
+			"match[]", `up{team="payments"}`,
+		},
+		{
+			"label_names variable",
+			"alice", "/api/variable-values?" + url.Values{"query": {`label_names()`}}.Encode(),
+			"match[]", `{team="payments"}`,
+		},
+		{
+			"metrics variable",
+			"alice", "/api/variable-values?" + url.Values{"query": {`metrics(node_.*)`}}.Encode(),
+			"match[]", `{__name__=~".*(?:node_.*).*",team="payments"}`,
+		},
+		{
+			"query_result variable",
+			"alice", "/api/variable-values?" + url.Values{"query": {`query_result(topk(2, up))`}}.Encode(),
+			"query", `topk(2, up{team="payments"})`,
+		},
+		{
+			"users without a policy are not restricted",
+			"admin", "/api/query?" + url.Values{"query": {`sum(rate(http_requests_total[5m]))`}}.Encode() + rangeParams,
+			"query", `sum(rate(http_requests_total[5m]))`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := send(tt.user, tt.target)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if got.Get(tt.param) != tt.want {
+				t.Errorf("expected upstream %s %s, got %s", tt.param, tt.want, got.Get(tt.param))
+			}
+		})
+	}
+}
+
+func TestLabelPoliciesRefuseUnrestrictableRequests(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected upstream request %s", r.URL)
+	}))
+	defer upstream.Close()
+	send := newPolicyRouter(t, upstream.URL)
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{
+			"loki metric query",
+			"/api/query?" + url.Values{"query": {`sum(rate({app="web"}[5m]))`}, "datasource": {"logs"}, "start": {"1"}, "end": {"2"}, "step": {"1"}}.Encode(),
+			http.StatusForbidden,
+		},
+		{
+			"loki logs",
+			"/api/logs?" + url.Values{"query": {`{app="web"}`}, "datasource": {"logs"}, "start": {"1"}, "end": {"2"}}.Encode(),
+			http.StatusForbidden,
+		},
+		{
+			"loki label values",
+			"/api/label-values?" + url.Values{"label": {"app"}, "datasource": {"logs"}}.Encode(),
+			http.StatusForbidden,
+		},
+		{
+			"unparsable query",
+			"/api/instant-query?" + url.Values{"query": {`up{`}}.Encode(),
+			http.StatusBadRequest,
+		},
+		{
+			"selector that is an expression",
+			"/api/label-values?" + url.Values{"label": {"instance"}, "match[]": {`up or secret`}}.Encode(),
+			http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := send("alice", tt.target)
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -21,7 +21,11 @@ func NewQueryHandler(registry *datasource.Registry) *QueryHandler {
 
 // Handle processes a datasource query_range proxy request.
 func (h *QueryHandler) Handle(c *gin.Context) {
-	query := c.Query("query")
+	h.serve(c, c.Query("query"), c.Query("datasource"))
+}
+
+// serve runs query as a query_range against the datasource dsName.
+func (h *QueryHandler) serve(c *gin.Context, query, dsName string) {
 	start := c.Query("start")
 	end := c.Query("end")
 	step := c.Query("step")
@@ -31,12 +35,19 @@ func (h *QueryHandler) Handle(c *gin.Context) {
 		return
 	}
 
-	client, err := h.registry.Get(c.Query("datasource"))
+	client, err := h.registry.Get(dsName)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	query, err = applyFilters(c, h.registry, dsName, query)
+	if err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	logPanelQuery(c, dsName, query)
+
 	body, statusCode, err := client.QueryRange(c.Request.Context(), query, start, end, step)
 	if err != nil {
 		slog.Error("datasource query failed", "error", err)
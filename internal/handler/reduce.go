@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+	"github.com/tokuhirom/dashyard/internal/model"
+	"github.com/tokuhirom/dashyard/internal/series"
+)
+
+// ReduceHandler handles GET /api/reduce - collapses each series of a query to
+// a single value server-side, for gauge panels.
+type ReduceHandler struct {
+	registry *datasource.Registry
+}
+
+// NewReduceHandler creates a new ReduceHandler.
+func NewReduceHandler(registry *datasource.Registry) *ReduceHandler {
+	return &ReduceHandler{registry: registry}
+}
+
+// Handle processes a reduce request. reduce=last (the default) evaluates an
+// instant query at end; avg, max and min reduce a query_range over
+// start..end. The response is a Prometheus-style vector with one sample per
+// series, timestamped at the series' last sample.
+func (h *ReduceHandler) Handle(c *gin.Context) {
+	h.serve(c, c.Query("query"), c.Query("datasource"))
+}
+
+// serve reduces the series of query on the datasource dsName.
+func (h *ReduceHandler) serve(c *gin.Context, query, dsName string) {
+	start := c.Query("start")
+	end := c.Query("end")
+	step := c.Query("step")
+
+	if query == "" || start == "" || end == "" || step == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query, start, end, and step parameters are required"})
+		return
+	}
+
+	reducer := c.DefaultQuery("reduce", "last")
+	if !model.ValidReducer(reducer) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reduce must be one of last, avg, max, min"})
+		return
+	}
+
+	client, err := h.registry.Get(dsName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err = applyFilters(c, h.registry, dsName, query)
+	if err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	logPanelQuery(c, dsName, query)
+
+	var body io.ReadCloser
+	var statusCode int
+	if reducer == "last" {
+		body, statusCode, err = client.Query(c.Request.Context(), query, end)
+	} else {
+		body, statusCode, err = client.QueryRange(c.Request.Context(), query, start, end, step)
+	}
+	if err != nil {
+		slog.Error("datasource query failed", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "datasource query failed"})
+		return
+	}
+	defer func() { _ = body.Close() }()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		slog.Error("failed to read datasource response", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to read datasource response"})
+		return
+	}
+
+	// Upstream errors keep their status and body so the frontend shows the
+	// datasource's message, as with /api/query.
+	if statusCode < 200 || statusCode >= 300 {
+		c.Data(statusCode, "application/json", data)
+		return
+	}
+
+	parsed, err := series.Parse(data)
+	if err != nil {
+		slog.Error("failed to parse datasource response", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to parse datasource response"})
+		return
+	}
+
+	reduced := make([]series.Series, 0, len(parsed))
+	for _, s := range parsed {
+		v, ok := series.Reduce(s.Samples, reducer)
+		if !ok {
+			continue
+		}
+		ts := s.Samples[len(s.Samples)-1].T
+		reduced = append(reduced, series.Series{Metric: s.Metric, Samples: []series.Sample{{T: ts, V: v}}})
+	}
+
+	out, err := series.VectorResponse(reduced)
+	if err != nil {
+		slog.Error("failed to encode reduced response", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode response"})
+		return
+	}
+	c.Data(http.StatusOK, "application/json", out)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+)
+
+func TestReduceHandlerRange(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			t.Errorf("expected path '/api/v1/query_range', got %q", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"cpu":"cpu0"},"values":[[1000,"1"],[1015,"5"],[1030,"3"]]},
+			{"metric":{"cpu":"cpu1"},"values":[[1000,"NaN"]]}
+		]}}`))
+	}))
+	defer promServer.Close()
+
+	router := newHandlerRouter(t, "/api/reduce", promServer.URL, func(r *datasource.Registry) gin.HandlerFunc {
+		return NewReduceHandler(r).Handle
+	})
+	tests := []struct {
+		reduce   string
+		expected string
+	}{
+		{"max", `{"data":{"result":[{"metric":{"cpu":"cpu0"},"value":[1030,"5"]}],"resultType":"vector"},"status":"success"}`},
+		{"min", `{"data":{"result":[{"metric":{"cpu":"cpu0"},"value":[1030,"1"]}],"resultType":"vector"},"status":"success"}`},
+		{"avg", `{"data":{"result":[{"metric":{"cpu":"cpu0"},"value":[1030,"3"]}],"resultType":"vector"},"status":"success"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reduce, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/reduce?query=cpu&start=1000&end=1030&step=15s&reduce="+tt.reduce, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+			}
+			if resp.Body.String() != tt.expected {
+				t.Errorf("expected body %s, got %s", tt.expected, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestReduceHandlerLastUsesInstantQuery(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			t.Errorf("expected path '/api/v1/query', got %q", r.URL.Path)
+		}
+		if r.URL.Query().Get("time") != "2000" {
+			t.Errorf("expected time '2000', got %q", r.URL.Query().Get("time"))
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[2000,"7"]}]}}`))
+	}))
+	defer promServer.Close()
+
+	router := newHandlerRouter(t, "/api/reduce", promServer.URL, func(r *datasource.Registry) gin.HandlerFunc {
+		return NewReduceHandler(r).Handle
+	})
+	req := httptest.NewRequest("GET", "/api/reduce?query=up&start=1000&end=2000&step=15s", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	expected := `{"data":{"result":[{"metric":{},"value":[2000,"7"]}],"resultType":"vector"},"status":"success"}`
+	if resp.Code != http.StatusOK || resp.Body.String() != expected {
+		t.Errorf("expected 200 %s, got %d %s", expected, resp.Code, resp.Body.String())
+	}
+}
+
+func TestReduceHandlerUpstreamError(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+	}))
+	defer promServer.Close()
+
+	router := newHandlerRouter(t, "/api/reduce", promServer.URL, func(r *datasource.Registry) gin.HandlerFunc {
+		return NewReduceHandler(r).Handle
+	})
+	req := httptest.NewRequest("GET", "/api/reduce?query=(&start=1000&end=2000&step=15s&reduce=max", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("expected upstream status 400, got %d", resp.Code)
+	}
+	if resp.Body.String() != `{"status":"error","errorType":"bad_data","error":"parse error"}` {
+		t.Errorf("expected upstream body, got %s", resp.Body.String())
+	}
+}
+
+func TestReduceHandlerBadRequests(t *testing.T) {
+	router := newHandlerRouter(t, "/api/reduce", "http://localhost:9090", func(r *datasource.Registry) gin.HandlerFunc {
+		return NewReduceHandler(r).Handle
+	})
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"missing query", "/api/reduce?start=1000&end=2000&step=15s"},
+		{"missing step", "/api/reduce?query=up&start=1000&end=2000"},
+		{"invalid reduce", "/api/reduce?query=up&start=1000&end=2000&step=15s&reduce=median"},
+		{"unknown datasource", "/api/reduce?query=up&start=1000&end=2000&step=15s&datasource=nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", resp.Code)
+			}
+		})
+	}
+}
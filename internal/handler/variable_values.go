@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+	"github.com/tokuhirom/dashyard/internal/series"
+	"github.com/tokuhirom/dashyard/internal/variable"
+)
+
+// VariableValuesHandler handles GET /api/variable-values - resolves the values
+// of a query variable server-side, applying its regex and sort.
+type VariableValuesHandler struct {
+	registry *datasource.Registry
+}
+
+// NewVariableValuesHandler creates a new VariableValuesHandler.
+func NewVariableValuesHandler(registry *datasource.Registry) *VariableValuesHandler {
+	return &VariableValuesHandler{registry: registry}
+}
+
+// Handle processes a variable values request. query is the variable query with
+// any variables it references already resolved by the caller; regex, sort and
+// sort_desc are the variable's options. start and end, the dashboard time
+// range, optionally bound the series label_names and metrics consider. The
+// response has the shape of the Prometheus label values API:
+// {"status":"success","data":[...]}.
+func (h *VariableValuesHandler) Handle(c *gin.Context) {
+	query, err := variable.ParseQuery(c.Query("query"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var re *regexp.Regexp
+	if pattern := c.Query("regex"); pattern != "" {
+		re, err = regexp.Compile(pattern)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid regex: %s", err)})
+			return
+		}
+	}
+
+	order := c.Query("sort")
+	if order != "" && !variable.ValidSort(order) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of alpha, numeric, natural"})
+		return
+	}
+	desc, _ := strconv.ParseBool(c.Query("sort_desc"))
+
+	dsName := c.Query("datasource")
+	client, err := h.registry.Get(dsName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The label policies of the user restrict the series selector or query.
+	// Prometheus anchors =~ matchers, while metrics(regex) matches anywhere
+	// in the name as in Grafana, so the regex is grouped and wrapped in .* on
+	// both sides.
+	match := query.Match
+	if query.Kind == variable.Metrics {
+		match = fmt.Sprintf("{__name__=~%q}", ".*(?:"+query.Regex+").*")
+	}
+	if query.Kind == variable.QueryResult {
+		query.PromQL, err = applyFilters(c, h.registry, dsName, query.PromQL)
+	} else {
+		match, err = restrictSelector(c, h.registry, dsName, match)
+	}
+	if err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	start, end := c.Query("start"), c.Query("end")
+	var body io.ReadCloser
+	var statusCode int
+	switch query.Kind {
+	case variable.LabelValues:
+		body, statusCode, err = client.LabelValues(ctx, query.Label, match)
+	case variable.QueryResult:
+		body, statusCode, err = client.Query(ctx, query.PromQL, "")
+	default:
+		meta, metaErr := h.registry.GetMetadata(dsName)
+		if metaErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": metaErr.Error()})
+			return
+		}
+		if query.Kind == variable.LabelNames {
+			body, statusCode, err = meta.LabelNames(ctx, match, start, end)
+		} else {
+			body, statusCode, err = meta.LabelValuesInRange(ctx, "__name__", match, start, end)
+		}
+	}
+	if err != nil {
+		slog.Error("datasource variable query failed", "kind", query.Kind, "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "datasource variable query failed"})
+		return
+	}
+	defer func() { _ = body.Close() }()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		slog.Error("failed to read datasource response", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to read datasource response"})
+		return
+	}
+
+	// Upstream errors keep their status and body so the frontend shows the
+	// datasource's message, as with /api/label-values.
+	if statusCode < 200 || statusCode >= 300 {
+		c.Data(statusCode, "application/json", data)
+		return
+	}
+
+	values, err := decodeVariableValues(query.Kind, data)
+	if err != nil {
+		slog.Error("failed to parse datasource response", "kind", query.Kind, "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to parse datasource response"})
+		return
+	}
+
+	values = variable.Filter(values, re)
+	if err := variable.Sort(values, order, desc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": values})
+}
+
+// decodeVariableValues extracts the candidate values from the datasource
+// response for a query of the given kind.
+func decodeVariableValues(kind string, data []byte) ([]string, error) {
+	switch kind {
+	case variable.QueryResult:
+		parsed, err := series.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		return variable.ResultLines(parsed), nil
+	default:
+		var resp struct {
+			Data []string `json:"data"`
+		}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("decoding values: %w", err)
+		}
+		return resp.Data, nil
+	}
+}
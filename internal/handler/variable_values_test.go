@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tokuhirom/dashyard/internal/config"
+	"github.com/tokuhirom/dashyard/internal/datasource"
+)
+
+func TestVariableValuesHandler(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/label/instance/values":
+			if got := r.URL.Query().Get("match[]"); got != `up{job="node"}` {
+				t.Errorf("expected match[] 'up{job=\"node\"}', got %q", got)
+			}
+			_, _ = w.Write([]byte(`{"status":"success","data":["web-10:9100","web-2:9100","db-1:9100"]}`))
+		case "/api/v1/labels":
+			_, _ = w.Write([]byte(`{"status":"success","data":["__name__","job","instance"]}`))
+		case "/api/v1/label/__name__/values":
+			if got := r.URL.Query().Get("match[]"); got != `{__name__=~".*(?:node_.*).*"}` {
+				t.Errorf("expected match[] '{__name__=~\".*(?:node_.*).*\"}', got %q", got)
+			}
+			_, _ = w.Write([]byte(`{"status":"success","data":["node_load1","node_cpu_seconds_total"]}`))
+		case "/api/v1/query":
+			if got := r.URL.Query().Get("query"); got != "topk(2, up)" {
+				t.Errorf("expected query 'topk(2, up)', got %q", got)
+			}
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"__name__":"up","job":"node"},"value":[1700000000,"1"]},
+				{"metric":{"__name__":"up","job":"api"},"value":[1700000000,"1"]}
+			]}}`))
+		default:
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+	}))
+	defer promServer.Close()
+
+	router := newHandlerRouter(t, "/api/variable-values", promServer.URL, func(r *datasource.Registry) gin.HandlerFunc {
+		return NewVariableValuesHandler(r).Handle
+	})
+	tests := []struct {
+		name     string
+		params   url.Values
+		expected string
+	}{
+		{
+			"label_values with regex and natural sort",
+			url.Values{"query": {`label_values(up{job="node"}, instance)`}, "regex": {`^web-(\d+)`}, "sort": {"natural"}},
+			`{"data":["2","10"],"status":"success"}`,
+		},
+		{
+			"label_names sorted descending",
+			url.Values{"query": {"label_names()"}, "regex": {"^[a-z]"}, "sort": {"alpha"}, "sort_desc": {"true"}},
+			`{"data":["job","instance"],"status":"success"}`,
+		},
+		{
+			"metrics",
+			url.Values{"query": {"metrics(node_.*)"}, "sort": {"alpha"}},
+			`{"data":["node_cpu_seconds_total","node_load1"],"status":"success"}`,
+		},
+		{
+			"query_result",
+			url.Values{"query": {"query_result(topk(2, up))"}, "regex": {`job="(?P<value>[^"]+)"`}},
+			`{"data":["node","api"],"status":"success"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/variable-values?"+tt.params.Encode(), nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+			}
+			if resp.Body.String() != tt.expected {
+				t.Errorf("expected body %s, got %s", tt.expected, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestVariableValuesHandlerMetrics(t *testing.T) {
+	names := []string{"node_load1", "node_cpu_seconds_total", "node_memory_MemFree_bytes", "go_gc_duration_seconds", "process_cpu_seconds_total"}
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/label/__name__/values" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("start") != "1700000000" || q.Get("end") != "1700003600" {
+			t.Errorf("expected the dashboard time range, got %q-%q", q.Get("start"), q.Get("end"))
+		}
+		// Prometheus anchors =~ matchers.
+		pattern := strings.TrimSuffix(strings.TrimPrefix(q.Get("match[]"), `{__name__=~"`), `"}`)
+		re := regexp.MustCompile("^(?:" + pattern + ")$")
+		var matched []string
+		for _, name := range names {
+			if re.MatchString(name) {
+				matched = append(matched, name)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": "success", "data": matched})
+	}))
+	defer promServer.Close()
+
+	router := newHandlerRouter(t, "/api/variable-values", promServer.URL, func(r *datasource.Registry) gin.HandlerFunc {
+		return NewVariableValuesHandler(r).Handle
+	})
+	tests := []struct {
+		name     string
+		regex    string
+		expected string
+	}{
+		{"substring match", "cpu", `{"data":["node_cpu_seconds_total","process_cpu_seconds_total"],"status":"success"}`},
+		{"anchored regex", "^node_.*$", `{"data":["node_cpu_seconds_total","node_load1","node_memory_MemFree_bytes"],"status":"success"}`},
+		{"alternation", "cpu|memory", `{"data":["node_cpu_seconds_total","node_memory_MemFree_bytes","process_cpu_seconds_total"],"status":"success"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := url.Values{
+				"query": {"metrics(" + tt.regex + ")"},
+				"sort":  {"alpha"},
+				"start": {"1700000000"},
+				"end":   {"1700003600"},
+			}
+			req := httptest.NewRequest("GET", "/api/variable-values?"+params.Encode(), nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+			}
+			if resp.Body.String() != tt.expected {
+				t.Errorf("expected body %s, got %s", tt.expected, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestVariableValuesHandlerBadRequest(t *testing.T) {
+	router := newHandlerRouter(t, "/api/variable-values", "http://localhost:9090", func(r *datasource.Registry) gin.HandlerFunc {
+		return NewVariableValuesHandler(r).Handle
+	}, config.DatasourceConfig{Name: "logs", Type: "loki", URL: "http://localhost:3100", Timeout: 5 * time.Second})
+	tests := []struct {
+		name   string
+		params url.Values
+	}{
+		{"missing query", url.Values{}},
+		{"unknown function", url.Values{"query": {"series(up)"}}},
+		{"invalid regex", url.Values{"query": {"label_values(job)"}, "regex": {"(web"}}},
+		{"unknown sort", url.Values{"query": {"label_values(job)"}, "sort": {"random"}}},
+		{"unknown datasource", url.Values{"query": {"label_values(job)"}, "datasource": {"nope"}}},
+		{"label_names on loki", url.Values{"query": {"label_names()"}, "datasource": {"logs"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/variable-values?"+tt.params.Encode(), nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", resp.Code, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestVariableValuesHandlerUpstreamError(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+	}))
+	defer promServer.Close()
+
+	router := newHandlerRouter(t, "/api/variable-values", promServer.URL, func(r *datasource.Registry) gin.HandlerFunc {
+		return NewVariableValuesHandler(r).Handle
+	})
+	req := httptest.NewRequest("GET", "/api/variable-values?query=query_result(up%5B)", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", resp.Code)
+	}
+	expected := `{"status":"error","errorType":"bad_data","error":"parse error"}`
+	if resp.Body.String() != expected {
+		t.Errorf("expected body %s, got %s", expected, resp.Body.String())
+	}
+}
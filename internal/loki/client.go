@@ -0,0 +1,187 @@
+package loki
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/tokuhirom/dashyard/internal/metrics"
+)
+
+// ClientOption configures optional Client settings.
+type ClientOption func(*Client)
+
+// Header represents a single HTTP header as a name/value pair.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// WithHeaders sets custom HTTP headers to include in every request.
+func WithHeaders(headers []Header) ClientOption {
+	return func(c *Client) {
+		c.headers = append(c.headers, headers...)
+	}
+}
+
+// Client is an HTTP client for the Loki query API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	headers    []Header
+}
+
+// NewClient creates a new Loki client.
+func NewClient(baseURL string, timeout time.Duration, opts ...ClientOption) *Client {
+	c := &Client{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Client) applyAuth(req *http.Request) {
+	for _, h := range c.headers {
+		req.Header.Add(h.Name, h.Value)
+	}
+}
+
+func (c *Client) get(ctx context.Context, path []string, params url.Values) (*http.Response, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing base URL: %w", err)
+	}
+	u = u.JoinPath(path...)
+	if len(params) > 0 {
+		u.RawQuery = params.Encode()
+	}
+
+	slog.Debug("loki request", "url", u.String())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	c.applyAuth(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	return resp, nil
+}
+
+// QueryRange performs a Loki query_range request for a metric LogQL query
+// (e.g. rate({app="web"}[5m])) and returns the raw response body, which uses
+// the same matrix format as Prometheus.
+// The caller is responsible for closing the returned ReadCloser.
+func (c *Client) QueryRange(ctx context.Context, query, start, end, step string) (io.ReadCloser, int, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", start)
+	params.Set("end", end)
+	params.Set("step", step)
+
+	reqStart := time.Now()
+	resp, err := c.get(ctx, []string{"loki/api/v1/query_range"}, params)
+	metrics.DatasourceQueryDuration.Observe(time.Since(reqStart).Seconds())
+	if err != nil {
+		metrics.DatasourceQueryTotal.WithLabelValues("error").Inc()
+		return nil, 0, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		metrics.DatasourceQueryTotal.WithLabelValues("success").Inc()
+	} else {
+		metrics.DatasourceQueryTotal.WithLabelValues("error").Inc()
+	}
+
+	return resp.Body, resp.StatusCode, nil
+}
+
+// Query performs a Loki instant query for a metric LogQL query and returns the
+// raw response body, which uses the same vector format as Prometheus.
+// ts is the evaluation timestamp; when empty, Loki evaluates at the current time.
+// The caller is responsible for closing the returned ReadCloser.
+func (c *Client) Query(ctx context.Context, query, ts string) (io.ReadCloser, int, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	if ts != "" {
+		params.Set("time", ts)
+	}
+
+	reqStart := time.Now()
+	resp, err := c.get(ctx, []string{"loki/api/v1/query"}, params)
+	metrics.DatasourceQueryDuration.Observe(time.Since(reqStart).Seconds())
+	if err != nil {
+		metrics.DatasourceQueryTotal.WithLabelValues("error").Inc()
+		return nil, 0, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		metrics.DatasourceQueryTotal.WithLabelValues("success").Inc()
+	} else {
+		metrics.DatasourceQueryTotal.WithLabelValues("error").Inc()
+	}
+
+	return resp.Body, resp.StatusCode, nil
+}
+
+// QueryLogs performs a Loki query_range request for a log LogQL query
+// (e.g. {app="web"} |= "error") and returns the raw streams response body.
+// direction is "backward" (newest first) or "forward".
+// The caller is responsible for closing the returned ReadCloser.
+func (c *Client) QueryLogs(ctx context.Context, query, start, end string, limit int, direction string) (io.ReadCloser, int, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", start)
+	params.Set("end", end)
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("direction", direction)
+
+	resp, err := c.get(ctx, []string{"loki/api/v1/query_range"}, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Body, resp.StatusCode, nil
+}
+
+// Ping checks whether the Loki server is reachable by hitting the /ready endpoint.
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.get(ctx, []string{"ready"}, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// LabelValues queries the Loki label values API and returns the raw response body.
+// match is an optional stream selector (e.g. {app="web"}) restricting the values.
+// The caller is responsible for closing the returned ReadCloser.
+func (c *Client) LabelValues(ctx context.Context, label, match string) (io.ReadCloser, int, error) {
+	params := url.Values{}
+	if match != "" {
+		params.Set("query", match)
+	}
+
+	resp, err := c.get(ctx, []string{"loki/api/v1/label", label, "values"}, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Body, resp.StatusCode, nil
+}
@@ -0,0 +1,189 @@
+package loki
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQueryRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/query_range" {
+			t.Errorf("expected path '/loki/api/v1/query_range', got %q", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("query") != `rate({app="web"}[5m])` {
+			t.Errorf("unexpected query %q", q.Get("query"))
+		}
+		if q.Get("start") != "1000" || q.Get("end") != "2000" || q.Get("step") != "15s" {
+			t.Errorf("unexpected range params: %v", q)
+		}
+		if q.Get("limit") != "" {
+			t.Errorf("expected no limit for metric query, got %q", q.Get("limit"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+
+	body, statusCode, err := client.QueryRange(context.Background(), `rate({app="web"}[5m])`, "1000", "2000", "15s")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	if statusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", statusCode)
+	}
+	data, _ := io.ReadAll(body)
+	expected := `{"status":"success","data":{"resultType":"matrix","result":[]}}`
+	if string(data) != expected {
+		t.Errorf("expected body %q, got %q", expected, string(data))
+	}
+}
+
+func TestQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/query" {
+			t.Errorf("expected path '/loki/api/v1/query', got %q", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("query") != `count_over_time({app="web"}[5m])` {
+			t.Errorf("unexpected query %q", q.Get("query"))
+		}
+		if q.Get("time") != "1500" {
+			t.Errorf("expected time '1500', got %q", q.Get("time"))
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+
+	body, statusCode, err := client.Query(context.Background(), `count_over_time({app="web"}[5m])`, "1500")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	if statusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", statusCode)
+	}
+}
+
+func TestQueryLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/query_range" {
+			t.Errorf("expected path '/loki/api/v1/query_range', got %q", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("query") != `{app="web"} |= "error"` {
+			t.Errorf("unexpected query %q", q.Get("query"))
+		}
+		if q.Get("limit") != "50" {
+			t.Errorf("expected limit '50', got %q", q.Get("limit"))
+		}
+		if q.Get("direction") != "backward" {
+			t.Errorf("expected direction 'backward', got %q", q.Get("direction"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[]}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+
+	body, statusCode, err := client.QueryLogs(context.Background(), `{app="web"} |= "error"`, "1000", "2000", 50, "backward")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	if statusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", statusCode)
+	}
+}
+
+func TestLabelValues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/label/app/values" {
+			t.Errorf("expected path '/loki/api/v1/label/app/values', got %q", r.URL.Path)
+		}
+		if r.URL.Query().Get("query") != `{env="prod"}` {
+			t.Errorf("expected query '{env=\"prod\"}', got %q", r.URL.Query().Get("query"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":["api","web"]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+
+	body, statusCode, err := client.LabelValues(context.Background(), "app", `{env="prod"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	if statusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", statusCode)
+	}
+	data, _ := io.ReadAll(body)
+	if string(data) != `{"status":"success","data":["api","web"]}` {
+		t.Errorf("unexpected body %q", string(data))
+	}
+}
+
+func TestPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ready" {
+			t.Errorf("expected path '/ready', got %q", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestPingNotReady(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("expected error for non-ready server")
+	}
+}
+
+func TestClientHeadersAndBasePath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tenant/loki/api/v1/label/app/values" {
+			t.Errorf("expected base path to be preserved, got %q", r.URL.Path)
+		}
+		if r.Header.Get("X-Scope-OrgID") != "tenant-1" {
+			t.Errorf("expected X-Scope-OrgID header, got %q", r.Header.Get("X-Scope-OrgID"))
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/tenant", 5*time.Second, WithHeaders([]Header{{Name: "X-Scope-OrgID", Value: "tenant-1"}}))
+	body, _, err := client.LabelValues(context.Background(), "app", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = body.Close()
+}
@@ -31,6 +31,24 @@ var (
 		Help:    "Upstream datasource query latency in seconds.",
 		Buckets: prometheus.DefBuckets,
 	})
+
+	DatasourceDeduplicatedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dashyard_datasource_deduplicated_total",
+		Help: "Total number of datasource calls served by sharing an identical in-flight upstream request.",
+	}, []string{"datasource", "endpoint"})
+)
+
+// Query cache metrics.
+var (
+	QueryCacheHitsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dashyard_query_cache_hits_total",
+		Help: "Total number of query_range requests served from the result cache.",
+	}, []string{"datasource"})
+
+	QueryCacheMissesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dashyard_query_cache_misses_total",
+		Help: "Total number of query_range requests not found in the result cache.",
+	}, []string{"datasource"})
 )
 
 // Dashboard metrics.
@@ -45,3 +63,29 @@ var (
 		Help: "Total number of dashboard hot-reloads.",
 	})
 )
+
+// API token metrics.
+var (
+	APITokenRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dashyard_api_token_requests_total",
+		Help: "Total number of API requests with a bearer token, by token name and result (ok, expired, invalid, forbidden). Invalid tokens have an empty name.",
+	}, []string{"token", "result"})
+
+	APITokenLastUsed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dashyard_api_token_last_used_timestamp_seconds",
+		Help: "Unix time of the last accepted request of each API token.",
+	}, []string{"token"})
+)
+
+// Login metrics.
+var (
+	LoginFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dashyard_login_failures_total",
+		Help: "Total number of failed password logins, by reason (invalid_credentials, throttled).",
+	}, []string{"reason"})
+
+	LoginLockoutsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dashyard_login_lockouts_total",
+		Help: "Total number of temporary lockouts after repeated failed logins, by scope (ip, user).",
+	}, []string{"scope"})
+)
@@ -1,6 +1,16 @@
 package model
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	"github.com/tokuhirom/dashyard/internal/variable"
+)
 
 // Threshold represents a horizontal reference line on a graph panel.
 type Threshold struct {
@@ -9,10 +19,18 @@ type Threshold struct {
 	Label string  `yaml:"label,omitempty" json:"label,omitempty"`
 }
 
+// TableQuery is one value column of a table panel. Series returned by the
+// queries of a table panel are joined into rows by their shared labels.
+type TableQuery struct {
+	Query string `yaml:"query" json:"query"`
+	Name  string `yaml:"name,omitempty" json:"name,omitempty"` // column header, defaults to "Value"
+	Unit  string `yaml:"unit,omitempty" json:"unit,omitempty"`
+}
+
 // Panel represents a single visualization panel within a dashboard row.
 type Panel struct {
 	Title      string      `yaml:"title" json:"title"`
-	Type       string      `yaml:"type" json:"type"`       // "graph" or "markdown"
+	Type       string      `yaml:"type" json:"type"`       // "graph", "markdown", "logs", "stat", "table", "gauge", "bargauge" or "heatmap"
 	ChartType  string      `yaml:"chart_type,omitempty" json:"chart_type,omitempty"`
 	Query      string      `yaml:"query,omitempty" json:"query,omitempty"`
 	Datasource string      `yaml:"datasource,omitempty" json:"datasource,omitempty"`
@@ -30,6 +48,27 @@ type Panel struct {
 	YScale     string      `yaml:"y_scale,omitempty" json:"y_scale,omitempty"` // "linear" or "log"
 	Content    string      `yaml:"content,omitempty" json:"content,omitempty"`
 	Span       int         `yaml:"span,omitempty" json:"span,omitempty"`
+	Limit      int         `yaml:"limit,omitempty" json:"limit,omitempty"` // max log lines for "logs" panels
+	Reduce     string      `yaml:"reduce,omitempty" json:"reduce,omitempty"` // "last" (default), "avg", "max", "min"
+	Sparkline  bool        `yaml:"sparkline,omitempty" json:"sparkline,omitempty"`
+	Queries      []TableQuery `yaml:"queries,omitempty" json:"queries,omitempty"`
+	Columns      []string     `yaml:"columns,omitempty" json:"columns,omitempty"` // column order for "table" panels
+	HiddenLabels []string     `yaml:"hidden_labels,omitempty" json:"hidden_labels,omitempty"`
+	SortBy       string       `yaml:"sort_by,omitempty" json:"sort_by,omitempty"`
+	SortDesc     bool         `yaml:"sort_desc,omitempty" json:"sort_desc,omitempty"`
+}
+
+// GaugeRange returns the value range of a gauge or bargauge panel: YMin and
+// YMax, defaulting to 0 and 100.
+func (p *Panel) GaugeRange() (lo, hi float64) {
+	lo, hi = 0, 100
+	if p.YMin != nil {
+		lo = *p.YMin
+	}
+	if p.YMax != nil {
+		hi = *p.YMax
+	}
+	return lo, hi
 }
 
 // Row represents a horizontal row of panels in a dashboard.
@@ -39,23 +78,189 @@ type Row struct {
 	Panels []Panel `yaml:"panels" json:"panels"`
 }
 
-// Variable represents a dashboard-level template variable populated from Prometheus label values
-// or from the configured datasource list.
+// Variable represents a dashboard-level template variable populated from Prometheus label values,
+// from the configured datasource list, or from the definition itself. Query holds
+// label_values(...), label_names(...), metrics(...) or query_result(...) for query
+// variables, the comma-separated options of custom and interval variables, the value
+// of a constant and the default text of a textbox. An adhoc_filters variable
+// holds label matchers that are added to every panel query rather than
+// substituted.
 type Variable struct {
 	Name       string `yaml:"name" json:"name"`
-	Type       string `yaml:"type,omitempty" json:"type,omitempty"` // "query" (default) or "datasource"
+	Type       string `yaml:"type,omitempty" json:"type,omitempty"` // "query" (default), "datasource", "custom", "constant", "interval", "textbox" or "adhoc_filters"
 	Label      string `yaml:"label,omitempty" json:"label,omitempty"`
 	Query      string `yaml:"query,omitempty" json:"query,omitempty"`
 	Datasource string `yaml:"datasource,omitempty" json:"datasource,omitempty"`
 	Hide       bool   `yaml:"hide,omitempty" json:"hide,omitempty"`
+	Multi      bool   `yaml:"multi,omitempty" json:"multi,omitempty"`             // allow selecting several values, substituted as a regex "a|b|c"
+	IncludeAll bool   `yaml:"include_all,omitempty" json:"include_all,omitempty"` // offer an "All" option
+	AllValue   string `yaml:"all_value,omitempty" json:"all_value,omitempty"`     // substituted for "All" instead of every value joined, e.g. ".*"
+	Auto       bool   `yaml:"auto,omitempty" json:"auto,omitempty"`               // interval variables: offer "auto", derived from the time range
+	AutoCount  int    `yaml:"auto_count,omitempty" json:"auto_count,omitempty"`   // number of intervals "auto" aims for across the range, default 30
+	AutoMin    string `yaml:"auto_min,omitempty" json:"auto_min,omitempty"`       // lower bound of "auto", default 10s
+	Regex      string `yaml:"regex,omitempty" json:"regex,omitempty"`             // query variables: keep matching values, or the text of the "value" or first capture group
+	Sort       string `yaml:"sort,omitempty" json:"sort,omitempty"`               // query variables: "alpha", "numeric" or "natural"; default keeps the datasource order
+	SortDesc   bool   `yaml:"sort_desc,omitempty" json:"sort_desc,omitempty"`
+}
+
+// Options returns the comma-separated options of a custom or interval
+// variable, trimmed and without empty entries.
+func (v *Variable) Options() []string {
+	var options []string
+	for _, o := range strings.Split(v.Query, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			options = append(options, o)
+		}
+	}
+	return options
+}
+
+var variableRefRe = regexp.MustCompile(`\$(?:\{(\w+)\}|(\w+))`)
+
+// References returns the variables, among names, that the query of a query
+// variable refers to as $name or ${name}, in order of first appearance. Such a
+// variable is resolved after the ones it references.
+func (v *Variable) References(names map[string]bool) []string {
+	if v.Type != "" && v.Type != "query" {
+		return nil
+	}
+	var refs []string
+	seen := map[string]bool{}
+	for _, m := range variableRefRe.FindAllStringSubmatch(v.Query, -1) {
+		name := m[1] + m[2]
+		if names[name] && !seen[name] {
+			seen[name] = true
+			refs = append(refs, name)
+		}
+	}
+	return refs
+}
+
+// VariableOrder returns the dashboard variables ordered so that every
+// variable comes after the variables its query references, keeping the
+// definition order otherwise. It fails if the references form a cycle.
+func (d *Dashboard) VariableOrder() ([]Variable, error) {
+	byName := make(map[string]Variable, len(d.Variables))
+	names := make(map[string]bool, len(d.Variables))
+	for _, v := range d.Variables {
+		byName[v.Name] = v
+		names[v.Name] = true
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	order := make([]Variable, 0, len(d.Variables))
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			start := slices.Index(path, name)
+			cycle := append(slices.Clone(path[start:]), name)
+			return fmt.Errorf("variables have a reference cycle %s", strings.Join(cycle, " -> "))
+		}
+		state[name] = visiting
+		path = append(path, name)
+		v := byName[name]
+		for _, ref := range v.References(names) {
+			if err := visit(ref); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		order = append(order, v)
+		return nil
+	}
+	for _, v := range d.Variables {
+		if err := visit(v.Name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// Annotation is a dashboard-level source of events drawn as vertical markers
+// on graph panels: either a PromQL query, whose non-zero samples become
+// events, or a static list of events.
+type Annotation struct {
+	Name       string            `yaml:"name" json:"name"`
+	Query      string            `yaml:"query,omitempty" json:"query,omitempty"`
+	Datasource string            `yaml:"datasource,omitempty" json:"datasource,omitempty"`
+	Text       string            `yaml:"text,omitempty" json:"text,omitempty"` // label template for query events, e.g. "deploy {version}"
+	Color      string            `yaml:"color,omitempty" json:"color,omitempty"`
+	Events     []AnnotationEvent `yaml:"events,omitempty" json:"events,omitempty"`
+}
+
+// AnnotationEvent is a single static annotation. Times are RFC 3339
+// timestamps or Unix seconds.
+type AnnotationEvent struct {
+	Time    string   `yaml:"time" json:"time"`
+	TimeEnd string   `yaml:"time_end,omitempty" json:"time_end,omitempty"`
+	Text    string   `yaml:"text,omitempty" json:"text,omitempty"`
+	Tags    []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// ParseAnnotationTime parses an annotation event time given as an RFC 3339
+// timestamp or as Unix seconds.
+func ParseAnnotationTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	sec, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (expected RFC 3339 or Unix seconds)", s)
+	}
+	return time.Unix(0, int64(sec*float64(time.Second))), nil
+}
+
+// Access restricts who can see a dashboard, or the dashboards of a folder, to
+// the listed users and the members of the listed groups.
+type Access struct {
+	Users  []string `yaml:"users,omitempty" json:"users,omitempty"`
+	Groups []string `yaml:"groups,omitempty" json:"groups,omitempty"`
+}
+
+// Allows reports whether the user with userID, a member of groups, is listed.
+func (a *Access) Allows(userID string, groups []string) bool {
+	if slices.Contains(a.Users, userID) {
+		return true
+	}
+	for _, g := range groups {
+		if slices.Contains(a.Groups, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the access rule lists someone.
+func (a *Access) Validate() error {
+	if len(a.Users) == 0 && len(a.Groups) == 0 {
+		return fmt.Errorf("access must list users or groups")
+	}
+	return nil
+}
+
+// Folder holds the settings of a dashboards directory, loaded from its
+// _folder.yaml. They apply to every dashboard below the directory.
+type Folder struct {
+	Access *Access `yaml:"access,omitempty"`
 }
 
 // Dashboard represents a single dashboard definition loaded from YAML.
 type Dashboard struct {
-	Title     string     `yaml:"title" json:"title"`
-	Variables []Variable `yaml:"variables,omitempty" json:"variables,omitempty"`
-	Rows      []Row      `yaml:"rows" json:"rows"`
-	Path      string     `yaml:"-" json:"path"` // Set by loader, not from YAML
+	Title       string       `yaml:"title" json:"title"`
+	Access      *Access      `yaml:"access,omitempty" json:"-"`
+	Variables   []Variable   `yaml:"variables,omitempty" json:"variables,omitempty"`
+	Annotations []Annotation `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+	Rows        []Row        `yaml:"rows" json:"rows"`
+	Path        string       `yaml:"-" json:"path"` // Set by loader, not from YAML
 }
 
 var validChartTypes = map[string]bool{
@@ -71,6 +276,15 @@ var validUnits = map[string]bool{
 	"bytes": true, "percent": true, "count": true, "seconds": true,
 }
 
+var validReducers = map[string]bool{
+	"last": true, "avg": true, "max": true, "min": true,
+}
+
+// ValidReducer reports whether reduce is a supported panel reducer.
+func ValidReducer(reduce string) bool {
+	return validReducers[reduce]
+}
+
 var validLegendAligns = map[string]bool{
 	"start": true, "center": true, "end": true,
 }
@@ -87,18 +301,30 @@ func (d *Dashboard) Validate() error {
 	if len(d.Rows) == 0 {
 		return fmt.Errorf("dashboard %q must have at least one row", d.Title)
 	}
+	if d.Access != nil {
+		if err := d.Access.Validate(); err != nil {
+			return fmt.Errorf("%s in dashboard %q", err, d.Title)
+		}
+	}
 
 	// Build variable name set for repeat validation
 	varNames := make(map[string]bool, len(d.Variables))
+	adhocName := ""
 	for i, v := range d.Variables {
 		if v.Name == "" {
 			return fmt.Errorf("variable[%d] name must not be empty in dashboard %q", i, d.Title)
 		}
+		if varNames[v.Name] {
+			return fmt.Errorf("variable %q is defined more than once in dashboard %q", v.Name, d.Title)
+		}
 		switch v.Type {
 		case "", "query":
 			if v.Query == "" {
 				return fmt.Errorf("variable %q query must not be empty in dashboard %q", v.Name, d.Title)
 			}
+			if err := validateQueryVariable(v); err != nil {
+				return fmt.Errorf("variable %q %s in dashboard %q", v.Name, err, d.Title)
+			}
 		case "datasource":
 			if v.Query != "" {
 				return fmt.Errorf("datasource variable %q must not have a query in dashboard %q", v.Name, d.Title)
@@ -106,11 +332,54 @@ func (d *Dashboard) Validate() error {
 			if v.Datasource != "" {
 				return fmt.Errorf("datasource variable %q must not have a datasource field in dashboard %q", v.Name, d.Title)
 			}
+			// A panel queries exactly one datasource.
+			if v.Multi || v.IncludeAll {
+				return fmt.Errorf("datasource variable %q must not have multi or include_all in dashboard %q", v.Name, d.Title)
+			}
+		case "adhoc_filters":
+			if adhocName != "" {
+				return fmt.Errorf("adhoc_filters variable %q duplicates %q; only one is allowed in dashboard %q", v.Name, adhocName, d.Title)
+			}
+			adhocName = v.Name
+			// The filters are label matchers, chosen in the UI.
+			if v.Query != "" || v.Multi || v.IncludeAll {
+				return fmt.Errorf("adhoc_filters variable %q must not have a query, multi or include_all in dashboard %q", v.Name, d.Title)
+			}
+		case "custom", "constant", "interval", "textbox":
+			if err := validateStaticVariable(v); err != nil {
+				return fmt.Errorf("%s variable %q %s in dashboard %q", v.Type, v.Name, err, d.Title)
+			}
 		default:
 			return fmt.Errorf("variable %q has unknown type %q in dashboard %q", v.Name, v.Type, d.Title)
 		}
+		if v.AllValue != "" && !v.IncludeAll {
+			return fmt.Errorf("variable %q has all_value without include_all in dashboard %q", v.Name, d.Title)
+		}
+		if v.Type != "interval" && (v.Auto || v.AutoCount != 0 || v.AutoMin != "") {
+			return fmt.Errorf("variable %q has auto settings but is not an interval variable in dashboard %q", v.Name, d.Title)
+		}
+		if v.Type != "" && v.Type != "query" && (v.Regex != "" || v.Sort != "" || v.SortDesc) {
+			return fmt.Errorf("variable %q has regex or sort but is not a query variable in dashboard %q", v.Name, d.Title)
+		}
 		varNames[v.Name] = true
 	}
+	if _, err := d.VariableOrder(); err != nil {
+		return fmt.Errorf("%s in dashboard %q", err, d.Title)
+	}
+
+	annotationNames := make(map[string]bool, len(d.Annotations))
+	for i, a := range d.Annotations {
+		if a.Name == "" {
+			return fmt.Errorf("annotation[%d] name must not be empty in dashboard %q", i, d.Title)
+		}
+		if annotationNames[a.Name] {
+			return fmt.Errorf("annotation %q is defined more than once in dashboard %q", a.Name, d.Title)
+		}
+		annotationNames[a.Name] = true
+		if err := validateAnnotation(a); err != nil {
+			return fmt.Errorf("annotation %q %s in dashboard %q", a.Name, err, d.Title)
+		}
+	}
 
 	for i, row := range d.Rows {
 		if row.Title == "" {
@@ -122,6 +391,9 @@ func (d *Dashboard) Validate() error {
 		if row.Repeat != "" && !varNames[row.Repeat] {
 			return fmt.Errorf("row %q repeat variable %q is not defined in dashboard %q", row.Title, row.Repeat, d.Title)
 		}
+		if row.Repeat != "" && row.Repeat == adhocName {
+			return fmt.Errorf("row %q cannot repeat over adhoc_filters variable %q in dashboard %q", row.Title, row.Repeat, d.Title)
+		}
 
 		for j, panel := range row.Panels {
 			if panel.Span != 0 && (panel.Span < 1 || panel.Span > 12) {
@@ -151,6 +423,47 @@ func (d *Dashboard) Validate() error {
 				if panel.Content == "" {
 					return fmt.Errorf("markdown panel[%d] %q in row %q must have content in dashboard %q", j, panel.Title, row.Title, d.Title)
 				}
+			case "logs":
+				if panel.Query == "" {
+					return fmt.Errorf("logs panel[%d] %q in row %q must have a query in dashboard %q", j, panel.Title, row.Title, d.Title)
+				}
+				if panel.Limit < 0 || panel.Limit > 5000 {
+					return fmt.Errorf("logs panel[%d] %q in row %q has invalid limit %d in dashboard %q (must be 1-5000)", j, panel.Title, row.Title, panel.Limit, d.Title)
+				}
+			case "stat":
+				if panel.Query == "" {
+					return fmt.Errorf("stat panel[%d] %q in row %q must have a query in dashboard %q", j, panel.Title, row.Title, d.Title)
+				}
+				if panel.Unit != "" && !validUnits[panel.Unit] {
+					return fmt.Errorf("stat panel[%d] %q in row %q has invalid unit %q in dashboard %q", j, panel.Title, row.Title, panel.Unit, d.Title)
+				}
+				if panel.Reduce != "" && !ValidReducer(panel.Reduce) {
+					return fmt.Errorf("stat panel[%d] %q in row %q has invalid reduce %q in dashboard %q (valid: last, avg, max, min)", j, panel.Title, row.Title, panel.Reduce, d.Title)
+				}
+			case "gauge", "bargauge":
+				if panel.Query == "" {
+					return fmt.Errorf("%s panel[%d] %q in row %q must have a query in dashboard %q", panel.Type, j, panel.Title, row.Title, d.Title)
+				}
+				if panel.Unit != "" && !validUnits[panel.Unit] {
+					return fmt.Errorf("%s panel[%d] %q in row %q has invalid unit %q in dashboard %q", panel.Type, j, panel.Title, row.Title, panel.Unit, d.Title)
+				}
+				if panel.Reduce != "" && !ValidReducer(panel.Reduce) {
+					return fmt.Errorf("%s panel[%d] %q in row %q has invalid reduce %q in dashboard %q (valid: last, avg, max, min)", panel.Type, j, panel.Title, row.Title, panel.Reduce, d.Title)
+				}
+				if lo, hi := panel.GaugeRange(); lo >= hi {
+					return fmt.Errorf("%s panel[%d] %q in row %q has range min %v not below max %v in dashboard %q (set y_min/y_max)", panel.Type, j, panel.Title, row.Title, lo, hi, d.Title)
+				}
+			case "heatmap":
+				if panel.Query == "" {
+					return fmt.Errorf("heatmap panel[%d] %q in row %q must have a query in dashboard %q", j, panel.Title, row.Title, d.Title)
+				}
+				if panel.Unit != "" && !validUnits[panel.Unit] {
+					return fmt.Errorf("heatmap panel[%d] %q in row %q has invalid unit %q in dashboard %q", j, panel.Title, row.Title, panel.Unit, d.Title)
+				}
+			case "table":
+				if err := validateTablePanel(panel); err != nil {
+					return fmt.Errorf("table panel[%d] %q in row %q %s in dashboard %q", j, panel.Title, row.Title, err, d.Title)
+				}
 			default:
 				return fmt.Errorf("panel[%d] %q in row %q has invalid type %q in dashboard %q", j, panel.Title, row.Title, panel.Type, d.Title)
 			}
@@ -160,6 +473,172 @@ func (d *Dashboard) Validate() error {
 	return nil
 }
 
+// validateQueryVariable checks the query, regex and sort of a query variable.
+func validateQueryVariable(v Variable) error {
+	if _, err := variable.ParseQuery(v.Query); err != nil {
+		return fmt.Errorf("has %s", err)
+	}
+	if v.Regex != "" {
+		if _, err := regexp.Compile(v.Regex); err != nil {
+			return fmt.Errorf("has invalid regex: %s", err)
+		}
+	}
+	if v.Sort != "" && !variable.ValidSort(v.Sort) {
+		return fmt.Errorf("has unknown sort %q (expected alpha, numeric or natural)", v.Sort)
+	}
+	if v.SortDesc && v.Sort == "" {
+		return fmt.Errorf("has sort_desc without sort")
+	}
+	return nil
+}
+
+// validateStaticVariable checks a custom, constant, interval or textbox variable.
+func validateStaticVariable(v Variable) error {
+	if v.Datasource != "" {
+		return fmt.Errorf("must not have a datasource field")
+	}
+	// Only custom variables offer a list to pick several values from.
+	if v.Type != "custom" && (v.Multi || v.IncludeAll) {
+		return fmt.Errorf("must not have multi or include_all")
+	}
+	switch v.Type {
+	case "custom":
+		options := v.Options()
+		if len(options) == 0 {
+			return fmt.Errorf("must list its options in query")
+		}
+		seen := make(map[string]bool, len(options))
+		for _, o := range options {
+			if seen[o] {
+				return fmt.Errorf("has duplicate option %q", o)
+			}
+			seen[o] = true
+		}
+	case "constant":
+		if v.Query == "" {
+			return fmt.Errorf("must have its value in query")
+		}
+	case "interval":
+		options := v.Options()
+		if len(options) == 0 {
+			return fmt.Errorf("must list its intervals in query")
+		}
+		for _, o := range options {
+			if _, err := prommodel.ParseDuration(o); err != nil {
+				return fmt.Errorf("has invalid interval %q", o)
+			}
+		}
+		if !v.Auto && (v.AutoCount != 0 || v.AutoMin != "") {
+			return fmt.Errorf("has auto_count or auto_min without auto")
+		}
+		if v.AutoCount < 0 {
+			return fmt.Errorf("has invalid auto_count %d (must be positive)", v.AutoCount)
+		}
+		if v.AutoMin != "" {
+			if _, err := prommodel.ParseDuration(v.AutoMin); err != nil {
+				return fmt.Errorf("has invalid auto_min %q", v.AutoMin)
+			}
+		}
+	}
+	return nil
+}
+
+// validateAnnotation checks a single annotation source.
+func validateAnnotation(a Annotation) error {
+	if (a.Query == "") == (len(a.Events) == 0) {
+		return fmt.Errorf("must have exactly one of query or events")
+	}
+	if a.Query == "" && (a.Datasource != "" || a.Text != "") {
+		return fmt.Errorf("must not have datasource or text without a query")
+	}
+	for i, e := range a.Events {
+		start, err := ParseAnnotationTime(e.Time)
+		if err != nil {
+			return fmt.Errorf("event[%d] has %v", i, err)
+		}
+		if e.TimeEnd == "" {
+			continue
+		}
+		end, err := ParseAnnotationTime(e.TimeEnd)
+		if err != nil {
+			return fmt.Errorf("event[%d] time_end has %v", i, err)
+		}
+		if end.Before(start) {
+			return fmt.Errorf("event[%d] has time_end before time", i)
+		}
+	}
+	return nil
+}
+
+// validateTablePanel checks the table-specific fields of a panel.
+func validateTablePanel(panel Panel) error {
+	if panel.Query == "" && len(panel.Queries) == 0 {
+		return fmt.Errorf("must have a query or queries")
+	}
+	if panel.Query != "" && len(panel.Queries) > 0 {
+		return fmt.Errorf("must not have both query and queries")
+	}
+	if panel.Unit != "" && !validUnits[panel.Unit] {
+		return fmt.Errorf("has invalid unit %q", panel.Unit)
+	}
+
+	valueColumns := map[string]bool{}
+	if panel.Query != "" {
+		valueColumns["Value"] = true
+	}
+	for i, q := range panel.Queries {
+		if q.Query == "" {
+			return fmt.Errorf("queries[%d] must have a query", i)
+		}
+		if q.Unit != "" && !validUnits[q.Unit] {
+			return fmt.Errorf("queries[%d] has invalid unit %q", i, q.Unit)
+		}
+		name := q.Name
+		if name == "" {
+			if len(panel.Queries) > 1 {
+				return fmt.Errorf("queries[%d] must have a name when there are several queries", i)
+			}
+			name = "Value"
+		}
+		if valueColumns[name] {
+			return fmt.Errorf("has duplicate column name %q", name)
+		}
+		valueColumns[name] = true
+	}
+
+	hidden := map[string]bool{}
+	for _, l := range panel.HiddenLabels {
+		if l == "" {
+			return fmt.Errorf("hidden_labels must not contain empty names")
+		}
+		hidden[l] = true
+	}
+
+	columns := map[string]bool{}
+	for _, c := range panel.Columns {
+		if c == "" {
+			return fmt.Errorf("columns must not contain empty names")
+		}
+		if columns[c] {
+			return fmt.Errorf("has duplicate entry %q in columns", c)
+		}
+		if hidden[c] {
+			return fmt.Errorf("lists hidden label %q in columns", c)
+		}
+		columns[c] = true
+	}
+
+	if panel.SortBy != "" {
+		if hidden[panel.SortBy] {
+			return fmt.Errorf("sorts by hidden label %q", panel.SortBy)
+		}
+		if len(panel.Columns) > 0 && !columns[panel.SortBy] && !valueColumns[panel.SortBy] {
+			return fmt.Errorf("sorts by %q which is not in columns", panel.SortBy)
+		}
+	}
+	return nil
+}
+
 // DashboardTreeNode represents a node in the hierarchical dashboard navigation tree.
 type DashboardTreeNode struct {
 	Name      string               `json:"name"`
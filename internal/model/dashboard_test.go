@@ -2,6 +2,8 @@ package model
 
 import (
 	"encoding/json"
+	"slices"
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v3"
@@ -519,6 +521,39 @@ func TestValidateNoRows(t *testing.T) {
 	}
 }
 
+func TestValidateAccess(t *testing.T) {
+	rows := []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "graph", Query: "up"}}}}
+	d := Dashboard{Title: "Test", Access: &Access{Groups: []string{"payments"}}, Rows: rows}
+	if err := d.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	d.Access = &Access{}
+	err := d.Validate()
+	if err == nil || !strings.Contains(err.Error(), `access must list users or groups in dashboard "Test"`) {
+		t.Errorf("expected access error, got %v", err)
+	}
+}
+
+func TestAccessAllows(t *testing.T) {
+	a := &Access{Users: []string{"alice"}, Groups: []string{"payments"}}
+	tests := []struct {
+		user   string
+		groups []string
+		want   bool
+	}{
+		{"alice", nil, true},
+		{"bob", []string{"search", "payments"}, true},
+		{"bob", []string{"search"}, false},
+		{"", nil, false},
+	}
+	for _, tt := range tests {
+		if got := a.Allows(tt.user, tt.groups); got != tt.want {
+			t.Errorf("Allows(%q, %v) = %v, want %v", tt.user, tt.groups, got, tt.want)
+		}
+	}
+}
+
 func TestValidateRowEmptyTitle(t *testing.T) {
 	d := Dashboard{
 		Title: "Test",
@@ -749,6 +784,298 @@ func TestValidateQueryVariableWithExplicitType(t *testing.T) {
 	}
 }
 
+func TestMultiVariableYAMLUnmarshal(t *testing.T) {
+	input := `
+name: cluster
+query: "label_values(up, cluster)"
+multi: true
+include_all: true
+all_value: ".*"
+`
+	var v Variable
+	if err := yaml.Unmarshal([]byte(input), &v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !v.Multi || !v.IncludeAll || v.AllValue != ".*" {
+		t.Errorf("unexpected variable %+v", v)
+	}
+}
+
+func TestValidateMultiVariable(t *testing.T) {
+	d := Dashboard{
+		Title: "Test",
+		Variables: []Variable{
+			{Name: "cluster", Query: "label_values(up, cluster)", Multi: true, IncludeAll: true, AllValue: ".*"},
+			{Name: "job", Query: "label_values(up, job)", IncludeAll: true},
+		},
+		Rows: []Row{{Title: "Row1", Repeat: "cluster", Panels: []Panel{{Title: "P1", Type: "graph", Query: "up"}}}},
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidateMultiVariableErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		variable Variable
+		wantErr  string
+	}{
+		{"multi datasource", Variable{Name: "ds", Type: "datasource", Multi: true}, "must not have multi or include_all"},
+		{"include_all datasource", Variable{Name: "ds", Type: "datasource", IncludeAll: true}, "must not have multi or include_all"},
+		{"all_value without include_all", Variable{Name: "job", Query: "label_values(up, job)", Multi: true, AllValue: ".*"}, "all_value without include_all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dashboard{
+				Title:     "Test",
+				Variables: []Variable{tt.variable},
+				Rows:      []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "graph", Query: "up"}}}},
+			}
+			err := d.Validate()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVariableOptions(t *testing.T) {
+	v := Variable{Type: "custom", Query: " prod, staging ,,dev "}
+	got := v.Options()
+	want := []string{"prod", "staging", "dev"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("option[%d]: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestValidateStaticVariables(t *testing.T) {
+	d := Dashboard{
+		Title: "Test",
+		Variables: []Variable{
+			{Name: "env", Type: "custom", Query: "prod,staging", Multi: true, IncludeAll: true},
+			{Name: "job", Type: "constant", Query: "node"},
+			{Name: "window", Type: "interval", Query: "1m,5m,1h,1d", Auto: true, AutoCount: 50, AutoMin: "30s"},
+			{Name: "pod", Type: "textbox"},
+		},
+		Rows: []Row{{Title: "Row1", Repeat: "env", Panels: []Panel{{Title: "P1", Type: "graph", Query: "up"}}}},
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidateStaticVariableErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		variable Variable
+		wantErr  string
+	}{
+		{"custom without options", Variable{Name: "env", Type: "custom", Query: " , "}, "must list its options in query"},
+		{"custom duplicate option", Variable{Name: "env", Type: "custom", Query: "a,b,a"}, `duplicate option "a"`},
+		{"custom datasource", Variable{Name: "env", Type: "custom", Query: "a", Datasource: "prom"}, "must not have a datasource field"},
+		{"constant without value", Variable{Name: "job", Type: "constant"}, "must have its value in query"},
+		{"constant multi", Variable{Name: "job", Type: "constant", Query: "node", Multi: true}, "must not have multi or include_all"},
+		{"textbox include_all", Variable{Name: "pod", Type: "textbox", IncludeAll: true}, "must not have multi or include_all"},
+		{"interval without options", Variable{Name: "w", Type: "interval"}, "must list its intervals in query"},
+		{"interval invalid", Variable{Name: "w", Type: "interval", Query: "1m,5 minutes"}, `invalid interval "5 minutes"`},
+		{"auto_min without auto", Variable{Name: "w", Type: "interval", Query: "1m", AutoMin: "10s"}, "auto_count or auto_min without auto"},
+		{"negative auto_count", Variable{Name: "w", Type: "interval", Query: "1m", Auto: true, AutoCount: -1}, "invalid auto_count -1"},
+		{"invalid auto_min", Variable{Name: "w", Type: "interval", Query: "1m", Auto: true, AutoMin: "soon"}, `invalid auto_min "soon"`},
+		{"auto on query variable", Variable{Name: "x", Query: "label_values(up, x)", Auto: true}, "not an interval variable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dashboard{
+				Title:     "Test",
+				Variables: []Variable{tt.variable},
+				Rows:      []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "graph", Query: "up"}}}},
+			}
+			err := d.Validate()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVariableReferences(t *testing.T) {
+	names := map[string]bool{"namespace": true, "pod": true, "name": true}
+	v := Variable{Name: "container", Query: `label_values(kube_pod_container_info{namespace="$namespace", pod=~"${pod}", x="$namespaced", y="$namespace"}, container)`}
+	got := v.References(names)
+	want := []string{"namespace", "pod"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	custom := Variable{Name: "c", Type: "custom", Query: "$namespace,b"}
+	if refs := custom.References(names); refs != nil {
+		t.Errorf("expected no references for custom variable, got %v", refs)
+	}
+}
+
+func TestVariableOrder(t *testing.T) {
+	d := Dashboard{
+		Variables: []Variable{
+			{Name: "pod", Query: `label_values(kube_pod_info{namespace="$namespace", node="$node"}, pod)`},
+			{Name: "node", Query: `label_values(kube_pod_info{cluster="$cluster"}, node)`},
+			{Name: "namespace", Query: `label_values(kube_pod_info{cluster="$cluster"}, namespace)`},
+			{Name: "cluster", Query: "label_values(kube_pod_info, cluster)"},
+		},
+	}
+	order, err := d.VariableOrder()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, v := range order {
+		names = append(names, v.Name)
+	}
+	want := []string{"cluster", "namespace", "node", "pod"}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+}
+
+func TestValidateChainedVariables(t *testing.T) {
+	d := Dashboard{
+		Title: "Test",
+		Variables: []Variable{
+			{Name: "namespace", Query: "label_values(kube_pod_info, namespace)"},
+			{Name: "pod", Query: `label_values(kube_pod_info{namespace="$namespace"}, pod)`, Multi: true},
+		},
+		Rows: []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "graph", Query: "up"}}}},
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidateQueryVariableForms(t *testing.T) {
+	d := Dashboard{
+		Title: "Test",
+		Variables: []Variable{
+			{Name: "label", Query: "label_names()", Regex: "^node_", Sort: "alpha"},
+			{Name: "metric", Query: "metrics(node_.*)", Sort: "natural", SortDesc: true},
+			{Name: "top", Query: "query_result(topk(3, up))", Regex: `job="(?P<value>[^"]+)"`},
+		},
+		Rows: []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "graph", Query: "up"}}}},
+	}
+	if err := d.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateAdhocFilters(t *testing.T) {
+	d := Dashboard{
+		Title:     "Test",
+		Variables: []Variable{{Name: "filters", Type: "adhoc_filters", Datasource: "prom"}},
+		Rows:      []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "graph", Query: "up"}}}},
+	}
+	if err := d.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d.Rows[0].Repeat = "filters"
+	err := d.Validate()
+	if err == nil || !strings.Contains(err.Error(), `cannot repeat over adhoc_filters variable "filters"`) {
+		t.Errorf("expected repeat error, got %v", err)
+	}
+}
+
+func TestValidateVariableErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables []Variable
+		wantErr   string
+	}{
+		{
+			"self reference",
+			[]Variable{{Name: "pod", Query: `label_values(kube_pod_info{pod="$pod"}, pod)`}},
+			"reference cycle pod -> pod",
+		},
+		{
+			"cycle",
+			[]Variable{
+				{Name: "a", Query: `label_values(m{b="$b"}, a)`},
+				{Name: "b", Query: `label_values(m{c="${c}"}, b)`},
+				{Name: "c", Query: `label_values(m{a="$a"}, c)`},
+			},
+			"reference cycle a -> b -> c -> a",
+		},
+		{
+			"duplicate name",
+			[]Variable{
+				{Name: "a", Query: "label_values(m, a)"},
+				{Name: "a", Type: "custom", Query: "x,y"},
+			},
+			`variable "a" is defined more than once`,
+		},
+		{
+			"unknown query function",
+			[]Variable{{Name: "a", Query: "series(up)"}},
+			`unknown function "series"`,
+		},
+		{
+			"invalid regex",
+			[]Variable{{Name: "a", Query: "label_values(a)", Regex: "(web"}},
+			`variable "a" has invalid regex`,
+		},
+		{
+			"unknown sort",
+			[]Variable{{Name: "a", Query: "label_values(a)", Sort: "random"}},
+			`variable "a" has unknown sort "random"`,
+		},
+		{
+			"sort_desc without sort",
+			[]Variable{{Name: "a", Query: "label_values(a)", SortDesc: true}},
+			"has sort_desc without sort",
+		},
+		{
+			"adhoc_filters with query",
+			[]Variable{{Name: "f", Type: "adhoc_filters", Query: "label_names()"}},
+			`adhoc_filters variable "f" must not have a query`,
+		},
+		{
+			"two adhoc_filters variables",
+			[]Variable{{Name: "f", Type: "adhoc_filters"}, {Name: "g", Type: "adhoc_filters"}},
+			`adhoc_filters variable "g" duplicates "f"`,
+		},
+		{
+			"sort on custom variable",
+			[]Variable{{Name: "a", Type: "custom", Query: "x,y", Sort: "alpha"}},
+			"has regex or sort but is not a query variable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dashboard{
+				Title:     "Test",
+				Variables: tt.variables,
+				Rows:      []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "graph", Query: "up"}}}},
+			}
+			err := d.Validate()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestValidateRepeatUndefinedVariable(t *testing.T) {
 	d := Dashboard{
 		Title: "Test",
@@ -1034,3 +1361,413 @@ func TestDashboardTreeNodeJSON(t *testing.T) {
 		t.Fatalf("expected 1 child, got %d", len(children))
 	}
 }
+
+func TestPanelLogsYAML(t *testing.T) {
+	input := `
+title: "App Logs"
+type: "logs"
+query: '{app="web"} |= "error"'
+datasource: "loki"
+limit: 200
+`
+	var p Panel
+	if err := yaml.Unmarshal([]byte(input), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Type != "logs" {
+		t.Errorf("expected type 'logs', got %q", p.Type)
+	}
+	if p.Query != `{app="web"} |= "error"` {
+		t.Errorf("unexpected query %q", p.Query)
+	}
+	if p.Datasource != "loki" {
+		t.Errorf("expected datasource 'loki', got %q", p.Datasource)
+	}
+	if p.Limit != 200 {
+		t.Errorf("expected limit 200, got %d", p.Limit)
+	}
+}
+
+func TestValidateLogsPanel(t *testing.T) {
+	d := Dashboard{
+		Title: "Test",
+		Rows:  []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "logs", Query: `{app="web"}`}}}},
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidateLogsPanelNoQuery(t *testing.T) {
+	d := Dashboard{
+		Title: "Test",
+		Rows:  []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "logs"}}}},
+	}
+	if err := d.Validate(); err == nil {
+		t.Error("expected error for logs panel without query")
+	}
+}
+
+func TestValidateLogsPanelInvalidLimit(t *testing.T) {
+	for _, limit := range []int{-1, 5001} {
+		d := Dashboard{
+			Title: "Test",
+			Rows:  []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "logs", Query: `{app="web"}`, Limit: limit}}}},
+		}
+		if err := d.Validate(); err == nil {
+			t.Errorf("expected error for limit %d", limit)
+		}
+	}
+}
+
+func TestPanelStatYAML(t *testing.T) {
+	input := `
+title: "Error Rate"
+type: "stat"
+query: 'sum(rate(http_errors_total[5m]))'
+unit: "percent"
+reduce: "max"
+sparkline: true
+thresholds:
+  - value: 5
+    color: "#ef4444"
+`
+	var p Panel
+	if err := yaml.Unmarshal([]byte(input), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Type != "stat" {
+		t.Errorf("expected type 'stat', got %q", p.Type)
+	}
+	if p.Reduce != "max" {
+		t.Errorf("expected reduce 'max', got %q", p.Reduce)
+	}
+	if !p.Sparkline {
+		t.Error("expected sparkline to be true")
+	}
+	if len(p.Thresholds) != 1 {
+		t.Errorf("expected 1 threshold, got %d", len(p.Thresholds))
+	}
+}
+
+func TestValidateStatPanel(t *testing.T) {
+	for _, reduce := range []string{"", "last", "avg", "max", "min"} {
+		d := Dashboard{
+			Title: "Test",
+			Rows:  []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "stat", Query: "up", Reduce: reduce}}}},
+		}
+		if err := d.Validate(); err != nil {
+			t.Errorf("reduce %q: expected no error, got %v", reduce, err)
+		}
+	}
+}
+
+func TestValidateStatPanelNoQuery(t *testing.T) {
+	d := Dashboard{
+		Title: "Test",
+		Rows:  []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "stat"}}}},
+	}
+	if err := d.Validate(); err == nil {
+		t.Error("expected error for stat panel without query")
+	}
+}
+
+func TestValidateStatPanelInvalidReduce(t *testing.T) {
+	d := Dashboard{
+		Title: "Test",
+		Rows:  []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "stat", Query: "up", Reduce: "median"}}}},
+	}
+	if err := d.Validate(); err == nil {
+		t.Error("expected error for invalid reduce")
+	}
+}
+
+func TestValidateStatPanelInvalidUnit(t *testing.T) {
+	d := Dashboard{
+		Title: "Test",
+		Rows:  []Row{{Title: "Row1", Panels: []Panel{{Title: "P1", Type: "stat", Query: "up", Unit: "furlongs"}}}},
+	}
+	if err := d.Validate(); err == nil {
+		t.Error("expected error for invalid unit")
+	}
+}
+
+func TestPanelTableYAML(t *testing.T) {
+	input := `
+title: "Pods"
+type: "table"
+queries:
+  - query: 'sum by (pod) (rate(cpu_seconds_total[5m]))'
+    name: "CPU"
+    unit: "percent"
+  - query: 'sum by (pod) (memory_bytes)'
+    name: "Memory"
+    unit: "bytes"
+columns: ["pod", "Memory", "CPU"]
+hidden_labels: ["instance"]
+sort_by: "CPU"
+sort_desc: true
+`
+	var p Panel
+	if err := yaml.Unmarshal([]byte(input), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(p.Queries) != 2 {
+		t.Fatalf("expected 2 queries, got %d", len(p.Queries))
+	}
+	if p.Queries[1].Name != "Memory" || p.Queries[1].Unit != "bytes" {
+		t.Errorf("unexpected second query %+v", p.Queries[1])
+	}
+	if len(p.Columns) != 3 || p.Columns[1] != "Memory" {
+		t.Errorf("unexpected columns %v", p.Columns)
+	}
+	if len(p.HiddenLabels) != 1 || p.HiddenLabels[0] != "instance" {
+		t.Errorf("unexpected hidden_labels %v", p.HiddenLabels)
+	}
+	if p.SortBy != "CPU" || !p.SortDesc {
+		t.Errorf("unexpected sort %q desc=%v", p.SortBy, p.SortDesc)
+	}
+}
+
+func TestValidateTablePanel(t *testing.T) {
+	tests := []struct {
+		name  string
+		panel Panel
+	}{
+		{"single query", Panel{Title: "P", Type: "table", Query: "up", SortBy: "Value"}},
+		{"multiple queries", Panel{Title: "P", Type: "table", Queries: []TableQuery{
+			{Query: "a", Name: "A", Unit: "bytes"},
+			{Query: "b", Name: "B"},
+		}, Columns: []string{"pod", "B", "A"}, SortBy: "A"}},
+		{"single unnamed query in queries", Panel{Title: "P", Type: "table", Queries: []TableQuery{{Query: "a"}}}},
+		{"sort by label without columns", Panel{Title: "P", Type: "table", Query: "up", SortBy: "pod"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dashboard{Title: "Test", Rows: []Row{{Title: "Row1", Panels: []Panel{tt.panel}}}}
+			if err := d.Validate(); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateTablePanelErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		panel   Panel
+		wantErr string
+	}{
+		{"no query", Panel{Title: "P", Type: "table"}, "must have a query or queries"},
+		{"query and queries", Panel{Title: "P", Type: "table", Query: "up", Queries: []TableQuery{{Query: "a"}}}, "must not have both"},
+		{"empty query in queries", Panel{Title: "P", Type: "table", Queries: []TableQuery{{Name: "A"}}}, "queries[0] must have a query"},
+		{"invalid panel unit", Panel{Title: "P", Type: "table", Query: "up", Unit: "furlongs"}, "invalid unit"},
+		{"invalid column unit", Panel{Title: "P", Type: "table", Queries: []TableQuery{{Query: "a", Unit: "furlongs"}}}, "queries[0] has invalid unit"},
+		{"unnamed query among several", Panel{Title: "P", Type: "table", Queries: []TableQuery{{Query: "a", Name: "A"}, {Query: "b"}}}, "queries[1] must have a name"},
+		{"duplicate names", Panel{Title: "P", Type: "table", Queries: []TableQuery{{Query: "a", Name: "A"}, {Query: "b", Name: "A"}}}, "duplicate column name"},
+		{"duplicate column", Panel{Title: "P", Type: "table", Query: "up", Columns: []string{"pod", "pod"}}, "duplicate entry"},
+		{"hidden column", Panel{Title: "P", Type: "table", Query: "up", Columns: []string{"pod"}, HiddenLabels: []string{"pod"}}, "hidden label"},
+		{"sort by hidden", Panel{Title: "P", Type: "table", Query: "up", HiddenLabels: []string{"pod"}, SortBy: "pod"}, "sorts by hidden label"},
+		{"sort by missing column", Panel{Title: "P", Type: "table", Query: "up", Columns: []string{"pod"}, SortBy: "node"}, "not in columns"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dashboard{Title: "Test", Rows: []Row{{Title: "Row1", Panels: []Panel{tt.panel}}}}
+			err := d.Validate()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if !strings.Contains(err.Error(), `table panel[0] "P" in row "Row1"`) {
+				t.Errorf("expected error to name the panel, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateGaugePanels(t *testing.T) {
+	lo, hi := 10.0, 20.0
+	for _, typ := range []string{"gauge", "bargauge"} {
+		d := Dashboard{
+			Title: "Test",
+			Rows: []Row{{Title: "Row1", Panels: []Panel{{
+				Title: "P1", Type: typ, Query: "up", Unit: "percent", Reduce: "avg", YMin: &lo, YMax: &hi,
+				Thresholds: []Threshold{{Value: 15, Color: "#f59e0b"}},
+			}}}},
+		}
+		if err := d.Validate(); err != nil {
+			t.Errorf("%s: expected no error, got %v", typ, err)
+		}
+	}
+}
+
+func TestValidateGaugePanelErrors(t *testing.T) {
+	over := 200.0
+	neg := -5.0
+	tests := []struct {
+		name    string
+		panel   Panel
+		wantErr string
+	}{
+		{"no query", Panel{Title: "P", Type: "gauge"}, "must have a query"},
+		{"invalid unit", Panel{Title: "P", Type: "bargauge", Query: "up", Unit: "furlongs"}, "invalid unit"},
+		{"invalid reduce", Panel{Title: "P", Type: "gauge", Query: "up", Reduce: "median"}, "invalid reduce"},
+		{"min above default max", Panel{Title: "P", Type: "gauge", Query: "up", YMin: &over}, "not below max"},
+		{"max below default min", Panel{Title: "P", Type: "bargauge", Query: "up", YMax: &neg}, "not below max"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dashboard{Title: "Test", Rows: []Row{{Title: "Row1", Panels: []Panel{tt.panel}}}}
+			err := d.Validate()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateHeatmapPanel(t *testing.T) {
+	d := Dashboard{Title: "Test", Rows: []Row{{Title: "Row1", Panels: []Panel{
+		{Title: "Latency", Type: "heatmap", Query: "sum by (le) (rate(x_bucket[5m]))", Unit: "seconds"},
+	}}}}
+	if err := d.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		panel   Panel
+		wantErr string
+	}{
+		{"no query", Panel{Title: "P", Type: "heatmap"}, "must have a query"},
+		{"invalid unit", Panel{Title: "P", Type: "heatmap", Query: "x", Unit: "furlongs"}, "invalid unit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dashboard{Title: "Test", Rows: []Row{{Title: "Row1", Panels: []Panel{tt.panel}}}}
+			err := d.Validate()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGaugeRange(t *testing.T) {
+	p := Panel{}
+	if lo, hi := p.GaugeRange(); lo != 0 || hi != 100 {
+		t.Errorf("expected default range 0-100, got %v-%v", lo, hi)
+	}
+	max := 1e9
+	p.YMax = &max
+	if lo, hi := p.GaugeRange(); lo != 0 || hi != 1e9 {
+		t.Errorf("expected range 0-1e9, got %v-%v", lo, hi)
+	}
+}
+
+func TestDashboardAnnotationsYAML(t *testing.T) {
+	input := `
+title: "Service"
+annotations:
+  - name: "Deploys"
+    query: 'changes(app_build_info[5m]) > 0'
+    text: "deploy {version}"
+    color: "#8b5cf6"
+  - name: "Incidents"
+    events:
+      - time: 2026-01-15T10:00:00Z
+        time_end: 2026-01-15T10:30:00Z
+        text: "Database failover"
+        tags: ["db"]
+      - time: 1768471200
+rows:
+  - title: "Row"
+    panels:
+      - title: "P"
+        type: "graph"
+        query: "up"
+`
+	var d Dashboard
+	if err := yaml.Unmarshal([]byte(input), &d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.Annotations) != 2 {
+		t.Fatalf("expected 2 annotations, got %d", len(d.Annotations))
+	}
+	if d.Annotations[0].Text != "deploy {version}" {
+		t.Errorf("expected text template, got %q", d.Annotations[0].Text)
+	}
+	events := d.Annotations[1].Events
+	if len(events) != 2 || events[0].Time != "2026-01-15T10:00:00Z" || events[1].Time != "1768471200" {
+		t.Errorf("unexpected events %+v", events)
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestParseAnnotationTime(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"2026-01-15T10:00:00Z", 1768471200},
+		{"2026-01-15T19:00:00+09:00", 1768471200},
+		{"1768471200", 1768471200},
+		{"1768471200.5", 1768471200},
+	}
+	for _, tt := range tests {
+		got, err := ParseAnnotationTime(tt.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if got.Unix() != tt.want {
+			t.Errorf("%q: expected %d, got %d", tt.input, tt.want, got.Unix())
+		}
+	}
+	if _, err := ParseAnnotationTime("yesterday"); err == nil {
+		t.Error("expected error for invalid time")
+	}
+}
+
+func TestValidateAnnotationErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations []Annotation
+		wantErr     string
+	}{
+		{"no name", []Annotation{{Query: "up"}}, "name must not be empty"},
+		{"duplicate name", []Annotation{{Name: "A", Query: "up"}, {Name: "A", Query: "up"}}, "more than once"},
+		{"neither source", []Annotation{{Name: "A"}}, "exactly one of query or events"},
+		{"both sources", []Annotation{{Name: "A", Query: "up", Events: []AnnotationEvent{{Time: "1"}}}}, "exactly one of query or events"},
+		{"static with datasource", []Annotation{{Name: "A", Datasource: "prom", Events: []AnnotationEvent{{Time: "1"}}}}, "without a query"},
+		{"invalid time", []Annotation{{Name: "A", Events: []AnnotationEvent{{Time: "soon"}}}}, "invalid time"},
+		{"invalid time_end", []Annotation{{Name: "A", Events: []AnnotationEvent{{Time: "1", TimeEnd: "later"}}}}, "time_end has invalid time"},
+		{"end before start", []Annotation{{Name: "A", Events: []AnnotationEvent{{Time: "10", TimeEnd: "5"}}}}, "time_end before time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dashboard{
+				Title:       "Test",
+				Annotations: tt.annotations,
+				Rows:        []Row{{Title: "Row1", Panels: []Panel{{Title: "P", Type: "graph", Query: "up"}}}},
+			}
+			err := d.Validate()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
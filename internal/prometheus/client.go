@@ -97,6 +97,110 @@ func (c *Client) QueryRange(ctx context.Context, query, start, end, step string)
 	return resp.Body, resp.StatusCode, nil
 }
 
+// Query performs a Prometheus instant query and returns the raw response body.
+// ts is the evaluation timestamp; when empty, Prometheus evaluates at the current time.
+// The caller is responsible for closing the returned ReadCloser.
+func (c *Client) Query(ctx context.Context, query, ts string) (io.ReadCloser, int, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("parsing base URL: %w", err)
+	}
+	u = u.JoinPath("api/v1/query")
+
+	params := url.Values{}
+	params.Set("query", query)
+	if ts != "" {
+		params.Set("time", ts)
+	}
+	u.RawQuery = params.Encode()
+
+	slog.Debug("prometheus query request", "url", u.String())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("creating request: %w", err)
+	}
+	c.applyAuth(req)
+
+	reqStart := time.Now()
+	resp, err := c.httpClient.Do(req)
+	duration := time.Since(reqStart).Seconds()
+	metrics.DatasourceQueryDuration.Observe(duration)
+	if err != nil {
+		metrics.DatasourceQueryTotal.WithLabelValues("error").Inc()
+		return nil, 0, fmt.Errorf("executing request: %w", err)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		metrics.DatasourceQueryTotal.WithLabelValues("success").Inc()
+	} else {
+		metrics.DatasourceQueryTotal.WithLabelValues("error").Inc()
+	}
+
+	return resp.Body, resp.StatusCode, nil
+}
+
+// LabelNames queries the Prometheus label names API (/api/v1/labels) and returns the raw
+// response body. match optionally restricts the names to series matching the selector;
+// start and end are optional and bound the series considered.
+// The caller is responsible for closing the returned ReadCloser.
+func (c *Client) LabelNames(ctx context.Context, match, start, end string) (io.ReadCloser, int, error) {
+	params := url.Values{}
+	if match != "" {
+		params.Set("match[]", match)
+	}
+	setRange(params, start, end)
+	return c.get(ctx, "api/v1/labels", params)
+}
+
+// LabelValuesInRange queries the Prometheus label values API
+// (/api/v1/label/{label}/values) like LabelValues, but only for series present
+// between start and end, which are optional.
+// The caller is responsible for closing the returned ReadCloser.
+func (c *Client) LabelValuesInRange(ctx context.Context, label, match, start, end string) (io.ReadCloser, int, error) {
+	params := url.Values{}
+	if match != "" {
+		params.Set("match[]", match)
+	}
+	setRange(params, start, end)
+	return c.get(ctx, "api/v1/label/"+label+"/values", params)
+}
+
+// setRange sets the optional start and end parameters of a metadata request.
+func setRange(params url.Values, start, end string) {
+	if start != "" {
+		params.Set("start", start)
+	}
+	if end != "" {
+		params.Set("end", end)
+	}
+}
+
+// get performs a GET request to a Prometheus API path and returns the raw response body.
+func (c *Client) get(ctx context.Context, path string, params url.Values) (io.ReadCloser, int, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("parsing base URL: %w", err)
+	}
+	u = u.JoinPath(path)
+	u.RawQuery = params.Encode()
+
+	slog.Debug("prometheus request", "url", u.String())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("creating request: %w", err)
+	}
+	c.applyAuth(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("executing request: %w", err)
+	}
+
+	return resp.Body, resp.StatusCode, nil
+}
+
 // Ping checks whether the Prometheus server is reachable by hitting the /-/ready endpoint.
 func (c *Client) Ping(ctx context.Context) error {
 	u, err := url.Parse(c.baseURL)
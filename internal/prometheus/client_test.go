@@ -55,6 +55,58 @@ func TestQueryRange(t *testing.T) {
 	}
 }
 
+func TestQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			t.Errorf("expected path '/api/v1/query', got %q", r.URL.Path)
+		}
+		if r.URL.Query().Get("query") != "up" {
+			t.Errorf("expected query 'up', got %q", r.URL.Query().Get("query"))
+		}
+		if r.URL.Query().Get("time") != "1500" {
+			t.Errorf("expected time '1500', got %q", r.URL.Query().Get("time"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+
+	body, statusCode, err := client.Query(context.Background(), "up", "1500")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	if statusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", statusCode)
+	}
+	data, _ := io.ReadAll(body)
+	expected := `{"status":"success","data":{"resultType":"vector","result":[]}}`
+	if string(data) != expected {
+		t.Errorf("expected body %q, got %q", expected, string(data))
+	}
+}
+
+func TestQueryWithoutTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["time"]; ok {
+			t.Errorf("expected no time parameter, got %q", r.URL.Query().Get("time"))
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+	body, _, err := client.Query(context.Background(), "up", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = body.Close()
+}
+
 func TestQueryRangeServerError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -249,3 +301,78 @@ func TestQueryRangeConnectionError(t *testing.T) {
 		t.Error("expected error for connection failure")
 	}
 }
+
+func TestLabelNames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/labels" {
+			t.Errorf("expected path '/api/v1/labels', got %q", r.URL.Path)
+		}
+		if r.URL.Query().Get("match[]") != `up{job="node"}` {
+			t.Errorf("unexpected match[] %q", r.URL.Query().Get("match[]"))
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":["__name__","instance","job"]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+
+	body, statusCode, err := client.LabelNames(context.Background(), `up{job="node"}`, "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	if statusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", statusCode)
+	}
+	data, _ := io.ReadAll(body)
+	if string(data) != `{"status":"success","data":["__name__","instance","job"]}` {
+		t.Errorf("unexpected body %q", string(data))
+	}
+}
+
+func TestLabelNamesNoMatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "" {
+			t.Errorf("expected no query string, got %q", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+	body, _, err := client.LabelNames(context.Background(), "", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = body.Close()
+}
+
+func TestLabelValuesInRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/prefix/api/v1/label/__name__/values" {
+			t.Errorf("expected path '/prefix/api/v1/label/__name__/values', got %q", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("match[]") != `{__name__=~".*node_.*"}` {
+			t.Errorf("unexpected match[] %q", q.Get("match[]"))
+		}
+		if q.Get("start") != "1000" || q.Get("end") != "2000" {
+			t.Errorf("unexpected range %q-%q", q.Get("start"), q.Get("end"))
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":["node_load1"]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/prefix", 5*time.Second)
+
+	body, statusCode, err := client.LabelValuesInRange(context.Background(), "__name__", `{__name__=~".*node_.*"}`, "1000", "2000")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	if statusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", statusCode)
+	}
+}
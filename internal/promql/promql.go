@@ -0,0 +1,87 @@
+// Package promql rewrites PromQL expressions with the Prometheus parser, so
+// that label matchers are added to selectors rather than concatenated into
+// the query text.
+package promql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// ParseMatchers parses a list of label matchers such as
+// `{instance="web-3", job=~"api|web"}`; the braces are optional. An empty
+// string yields no matchers.
+func ParseMatchers(s string) ([]*labels.Matcher, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "{}" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "{") {
+		s = "{" + s + "}"
+	}
+	matchers, err := parser.ParseMetricSelector(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label matchers %q: %w", s, err)
+	}
+	return matchers, nil
+}
+
+// InjectMatchers adds matchers to every vector and matrix selector of query
+// and returns the rewritten expression. A selector that already has an
+// identical matcher is left as is. Without matchers, query is returned
+// unchanged.
+func InjectMatchers(query string, matchers []*labels.Matcher) (string, error) {
+	if len(matchers) == 0 {
+		return query, nil
+	}
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return "", fmt.Errorf("parsing query: %w", err)
+	}
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if vs, ok := node.(*parser.VectorSelector); ok {
+			vs.LabelMatchers = appendMissing(vs.LabelMatchers, matchers)
+		}
+		return nil
+	})
+	return expr.String(), nil
+}
+
+// RestrictSelector adds matchers to a series selector, such as the match[]
+// of a label values request, and returns the rewritten selector. An empty
+// selector yields one of just the matchers.
+func RestrictSelector(selector string, matchers []*labels.Matcher) (string, error) {
+	if strings.TrimSpace(selector) == "" {
+		return (&parser.VectorSelector{LabelMatchers: matchers}).String(), nil
+	}
+	expr, err := parser.ParseExpr(selector)
+	if err != nil {
+		return "", fmt.Errorf("invalid series selector %q: %w", selector, err)
+	}
+	vs, ok := expr.(*parser.VectorSelector)
+	if !ok {
+		return "", fmt.Errorf("invalid series selector %q", selector)
+	}
+	vs.LabelMatchers = appendMissing(vs.LabelMatchers, matchers)
+	return vs.String(), nil
+}
+
+func appendMissing(existing, add []*labels.Matcher) []*labels.Matcher {
+	out := existing
+	for _, m := range add {
+		found := false
+		for _, e := range existing {
+			if e.Name == m.Name && e.Type == m.Type && e.Value == m.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, m)
+		}
+	}
+	return out
+}
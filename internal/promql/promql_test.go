@@ -0,0 +1,138 @@
+package promql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMatchers(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"{}", nil},
+		{`instance="web-3"`, []string{`instance="web-3"`}},
+		{`{instance="web-3", job=~"api|web"}`, []string{`instance="web-3"`, `job=~"api|web"`}},
+		{`env!="dev",path!~"/health.*"`, []string{`env!="dev"`, `path!~"/health.*"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMatchers(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d matchers, got %v", len(tt.want), got)
+			}
+			for i, m := range got {
+				if m.String() != tt.want[i] {
+					t.Errorf("matcher %d: expected %s, got %s", i, tt.want[i], m.String())
+				}
+			}
+		})
+	}
+}
+
+func TestParseMatchersErrors(t *testing.T) {
+	for _, in := range []string{`instance`, `instance=web`, `{job="a"`, `job=~"("`} {
+		if _, err := ParseMatchers(in); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+}
+
+func TestInjectMatchers(t *testing.T) {
+	matchers, err := ParseMatchers(`instance="web-3"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`up`, `up{instance="web-3"}`},
+		{`rate(http_requests_total{job="api"}[5m])`, `rate(http_requests_total{instance="web-3",job="api"}[5m])`},
+		{
+			`sum by (job) (rate(a[5m])) / on(job) group_left() sum by (job) (b offset 1h)`,
+			`sum by (job) (rate(a{instance="web-3"}[5m])) / on (job) group_left () sum by (job) (b{instance="web-3"} offset 1h)`,
+		},
+		{`max_over_time(up[1h:5m])`, `max_over_time(up{instance="web-3"}[1h:5m])`},
+		{`up{instance="web-3"}`, `up{instance="web-3"}`},
+		{`1 + 2`, `1 + 2`},
+		{`{__name__=~"node_.*"}`, `{__name__=~"node_.*",instance="web-3"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := InjectMatchers(tt.query, matchers)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestInjectMatchersNoMatchers(t *testing.T) {
+	// The query is not reformatted when there is nothing to add.
+	query := `sum(rate(up[5m]))  by (job)`
+	got, err := InjectMatchers(query, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != query {
+		t.Errorf("expected %s, got %s", query, got)
+	}
+}
+
+func TestInjectMatchersInvalidQuery(t *testing.T) {
+	matchers, _ := ParseMatchers(`instance="web-3"`)
+	_, err := InjectMatchers(`rate(up[5m]`, matchers)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "parsing query") {
+		t.Errorf("expected parsing error, got %v", err)
+	}
+}
+
+func TestRestrictSelector(t *testing.T) {
+	matchers, err := ParseMatchers(`team="payments"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		selector string
+		want     string
+	}{
+		{``, `{team="payments"}`},
+		{`up`, `up{team="payments"}`},
+		{`up{job="api"}`, `up{job="api",team="payments"}`},
+		{`{__name__=~"node_.*"}`, `{__name__=~"node_.*",team="payments"}`},
+		{`up{team=~".*"}`, `up{team="payments",team=~".*"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := RestrictSelector(tt.selector, matchers)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRestrictSelectorInvalid(t *testing.T) {
+	matchers, _ := ParseMatchers(`team="payments"`)
+	for _, selector := range []string{`up or secret`, `rate(up[5m])`, `up{`} {
+		if _, err := RestrictSelector(selector, matchers); err == nil {
+			t.Errorf("expected error for %q", selector)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package series
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// BucketCounts converts histogram series into one series per bucket holding
+// the number of observations that fell into that bucket, as needed for
+// heatmaps.
+//
+// Classic histograms are recognised by their "le" label; their cumulative
+// bucket values are differenced per timestamp, and series sharing the same
+// "le" are summed first. Native histogram samples are split into their
+// buckets, keyed by upper bound. The result has one series per upper bound,
+// labelled only with "le" and sorted by it. Series that are neither are
+// ignored.
+func BucketCounts(in []Series) ([]Series, error) {
+	type bucket struct {
+		le     string
+		counts map[float64]float64
+	}
+	buckets := map[float64]*bucket{}
+	add := func(upper float64, le string, t, v float64) {
+		b, ok := buckets[upper]
+		if !ok {
+			b = &bucket{le: le, counts: map[float64]float64{}}
+			buckets[upper] = b
+		}
+		b.counts[t] += v
+	}
+
+	// cumulative[t][upper] is the classic histogram's cumulative count.
+	cumulative := map[float64]map[float64]float64{}
+	leStrings := map[float64]string{}
+	for _, s := range in {
+		if le, ok := s.Metric["le"]; ok {
+			upper, err := strconv.ParseFloat(le, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid le label %q: %w", le, err)
+			}
+			if _, ok := leStrings[upper]; !ok {
+				leStrings[upper] = le
+			}
+			for _, sample := range s.Samples {
+				if math.IsNaN(sample.V) {
+					continue
+				}
+				if cumulative[sample.T] == nil {
+					cumulative[sample.T] = map[float64]float64{}
+				}
+				cumulative[sample.T][upper] += sample.V
+			}
+		}
+		for _, hs := range s.Histograms {
+			for _, hb := range hs.H.Buckets {
+				add(hb.Upper, strconv.FormatFloat(hb.Upper, 'g', -1, 64), hs.T, hb.Count)
+			}
+		}
+	}
+
+	for t, byUpper := range cumulative {
+		uppers := make([]float64, 0, len(byUpper))
+		for upper := range byUpper {
+			uppers = append(uppers, upper)
+		}
+		sort.Float64s(uppers)
+		prev := 0.0
+		for _, upper := range uppers {
+			// Counter resets inside a rate window can make a bucket look
+			// smaller than the previous one; clamp to zero.
+			add(upper, leStrings[upper], t, math.Max(0, byUpper[upper]-prev))
+			prev = byUpper[upper]
+		}
+	}
+
+	uppers := make([]float64, 0, len(buckets))
+	for upper := range buckets {
+		uppers = append(uppers, upper)
+	}
+	sort.Float64s(uppers)
+
+	out := make([]Series, 0, len(uppers))
+	for _, upper := range uppers {
+		b := buckets[upper]
+		samples := make([]Sample, 0, len(b.counts))
+		for t, v := range b.counts {
+			samples = append(samples, Sample{T: t, V: v})
+		}
+		sort.Slice(samples, func(i, j int) bool { return samples[i].T < samples[j].T })
+		out = append(out, Series{Metric: map[string]string{"le": b.le}, Samples: samples})
+	}
+	return out, nil
+}
@@ -0,0 +1,126 @@
+package series
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNativeHistogram(t *testing.T) {
+	body := `{"status":"success","data":{"resultType":"matrix","result":[
+		{"metric":{},"histograms":[[1000,{"count":"6","sum":"1.2","buckets":[[0,"0.25","0.5","2"],[0,"0.5","1","4"]]}]]}
+	]}}`
+	got, err := Parse([]byte(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || len(got[0].Histograms) != 1 {
+		t.Fatalf("expected 1 series with 1 histogram, got %v", got)
+	}
+	h := got[0].Histograms[0]
+	if h.T != 1000 || h.H.Count != "6" || len(h.H.Buckets) != 2 {
+		t.Errorf("unexpected histogram %v", h)
+	}
+	if h.H.Buckets[1] != (HistogramBucket{Boundaries: 0, Lower: 0.5, Upper: 1, Count: 4}) {
+		t.Errorf("unexpected bucket %v", h.H.Buckets[1])
+	}
+}
+
+func TestBucketCountsClassic(t *testing.T) {
+	in := []Series{
+		{Metric: map[string]string{"le": "+Inf"}, Samples: []Sample{{T: 1000, V: 10}, {T: 1015, V: 12}}},
+		{Metric: map[string]string{"le": "0.1"}, Samples: []Sample{{T: 1000, V: 3}, {T: 1015, V: 5}}},
+		{Metric: map[string]string{"le": "0.5"}, Samples: []Sample{{T: 1000, V: 8}, {T: 1015, V: 4}}},
+	}
+	got, err := BucketCounts(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string][]float64{
+		"0.1":  {3, 5},
+		"0.5":  {5, 0}, // 4 < 5 is clamped to zero
+		"+Inf": {2, 8},
+	}
+	order := []string{"0.1", "0.5", "+Inf"}
+	if len(got) != len(order) {
+		t.Fatalf("expected %d buckets, got %d", len(order), len(got))
+	}
+	for i, le := range order {
+		if got[i].Metric["le"] != le {
+			t.Errorf("bucket %d: expected le %q, got %q", i, le, got[i].Metric["le"])
+			continue
+		}
+		for j, v := range expected[le] {
+			if got[i].Samples[j].V != v {
+				t.Errorf("le %q sample %d: expected %v, got %v", le, j, v, got[i].Samples[j].V)
+			}
+		}
+	}
+}
+
+func TestBucketCountsSumsSameLe(t *testing.T) {
+	in := []Series{
+		{Metric: map[string]string{"le": "1", "pod": "a"}, Samples: []Sample{{T: 1000, V: 1}}},
+		{Metric: map[string]string{"le": "1", "pod": "b"}, Samples: []Sample{{T: 1000, V: 2}}},
+		{Metric: map[string]string{"le": "+Inf", "pod": "a"}, Samples: []Sample{{T: 1000, V: 4}}},
+		{Metric: map[string]string{"le": "+Inf", "pod": "b"}, Samples: []Sample{{T: 1000, V: 4}}},
+	}
+	got, err := BucketCounts(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].Samples[0].V != 3 || got[1].Samples[0].V != 5 {
+		t.Errorf("unexpected buckets %v", got)
+	}
+	if len(got[0].Metric) != 1 {
+		t.Errorf("expected only the le label, got %v", got[0].Metric)
+	}
+}
+
+func TestBucketCountsNative(t *testing.T) {
+	in := []Series{{
+		Metric: map[string]string{},
+		Histograms: []HistogramSample{
+			{T: 1000, H: Histogram{Buckets: []HistogramBucket{{Lower: 0.5, Upper: 1, Count: 4}, {Lower: 0.25, Upper: 0.5, Count: 2}}}},
+			{T: 1015, H: Histogram{Buckets: []HistogramBucket{{Lower: 1, Upper: 2, Count: 1}}}},
+		},
+	}}
+	got, err := BucketCounts(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	les := make([]string, len(got))
+	for i, s := range got {
+		les[i] = s.Metric["le"]
+	}
+	if strings.Join(les, ",") != "0.5,1,2" {
+		t.Errorf("expected buckets 0.5,1,2, got %v", les)
+	}
+	if got[1].Samples[0] != (Sample{T: 1000, V: 4}) {
+		t.Errorf("unexpected sample %v", got[1].Samples[0])
+	}
+	if got[2].Samples[0] != (Sample{T: 1015, V: 1}) {
+		t.Errorf("unexpected sample %v", got[2].Samples[0])
+	}
+}
+
+func TestBucketCountsInvalidLe(t *testing.T) {
+	_, err := BucketCounts([]Series{{Metric: map[string]string{"le": "fast"}, Samples: []Sample{{T: 1, V: 1}}}})
+	if err == nil {
+		t.Error("expected error for invalid le label")
+	}
+}
+
+func TestMatrixResponse(t *testing.T) {
+	body, err := MatrixResponse([]Series{
+		{Metric: map[string]string{"le": "1"}, Samples: []Sample{{T: 1000, V: 2.5}}},
+		{Metric: map[string]string{"le": "2"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"data":{"result":[{"metric":{"le":"1"},"values":[[1000,"2.5"]]}],"resultType":"matrix"},"status":"success"}`
+	if string(body) != expected {
+		t.Errorf("expected %s, got %s", expected, body)
+	}
+}
@@ -0,0 +1,295 @@
+// Package series parses Prometheus-compatible query API responses into typed
+// series for the few endpoints that transform data server-side instead of
+// passing it through (see ADR-007).
+package series
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Sample is a single timestamped value.
+type Sample struct {
+	T float64
+	V float64
+}
+
+// UnmarshalJSON decodes the [<unix seconds>, "<value>"] pair used by the
+// Prometheus API.
+func (s *Sample) UnmarshalJSON(b []byte) error {
+	var pair [2]json.RawMessage
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return fmt.Errorf("decoding sample: %w", err)
+	}
+	if err := json.Unmarshal(pair[0], &s.T); err != nil {
+		return fmt.Errorf("decoding sample timestamp: %w", err)
+	}
+	var v string
+	if err := json.Unmarshal(pair[1], &v); err != nil {
+		return fmt.Errorf("decoding sample value: %w", err)
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return fmt.Errorf("parsing sample value %q: %w", v, err)
+	}
+	s.V = f
+	return nil
+}
+
+// MarshalJSON encodes the sample in the Prometheus API format.
+func (s Sample) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]any{s.T, strconv.FormatFloat(s.V, 'f', -1, 64)})
+}
+
+// HistogramBucket is one bucket of a native histogram. Boundaries follows the
+// Prometheus API: 0 = (lower, upper], 1 = [lower, upper), 2 = (lower, upper),
+// 3 = [lower, upper].
+type HistogramBucket struct {
+	Boundaries int
+	Lower      float64
+	Upper      float64
+	Count      float64
+}
+
+// UnmarshalJSON decodes the [<boundaries>, "<lower>", "<upper>", "<count>"]
+// tuple used by the Prometheus API.
+func (b *HistogramBucket) UnmarshalJSON(data []byte) error {
+	var tuple [4]json.RawMessage
+	if err := json.Unmarshal(data, &tuple); err != nil {
+		return fmt.Errorf("decoding histogram bucket: %w", err)
+	}
+	if err := json.Unmarshal(tuple[0], &b.Boundaries); err != nil {
+		return fmt.Errorf("decoding histogram bucket boundaries: %w", err)
+	}
+	for i, dst := range []*float64{&b.Lower, &b.Upper, &b.Count} {
+		var v string
+		if err := json.Unmarshal(tuple[i+1], &v); err != nil {
+			return fmt.Errorf("decoding histogram bucket: %w", err)
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("parsing histogram bucket value %q: %w", v, err)
+		}
+		*dst = f
+	}
+	return nil
+}
+
+// Histogram is a native histogram value.
+type Histogram struct {
+	Count   string            `json:"count"`
+	Sum     string            `json:"sum"`
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+// HistogramSample is a single timestamped native histogram.
+type HistogramSample struct {
+	T float64
+	H Histogram
+}
+
+// UnmarshalJSON decodes the [<unix seconds>, {histogram}] pair used by the
+// Prometheus API.
+func (s *HistogramSample) UnmarshalJSON(b []byte) error {
+	var pair [2]json.RawMessage
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return fmt.Errorf("decoding histogram sample: %w", err)
+	}
+	if err := json.Unmarshal(pair[0], &s.T); err != nil {
+		return fmt.Errorf("decoding histogram sample timestamp: %w", err)
+	}
+	if err := json.Unmarshal(pair[1], &s.H); err != nil {
+		return fmt.Errorf("decoding histogram: %w", err)
+	}
+	return nil
+}
+
+// Series is one labelled time series. Vector results are represented as a
+// series with a single sample. Native histogram samples, if the upstream
+// returned any, are kept separately in Histograms.
+type Series struct {
+	Metric     map[string]string
+	Samples    []Sample
+	Histograms []HistogramSample
+}
+
+type apiResponse struct {
+	Status    string `json:"status"`
+	Error     string `json:"error"`
+	ErrorType string `json:"errorType"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type matrixResult struct {
+	Metric     map[string]string `json:"metric"`
+	Values     []Sample          `json:"values,omitempty"`
+	Histograms []HistogramSample `json:"histograms,omitempty"`
+}
+
+type vectorResult struct {
+	Metric    map[string]string `json:"metric"`
+	Value     *Sample           `json:"value,omitempty"`
+	Histogram *HistogramSample  `json:"histogram,omitempty"`
+}
+
+// Parse decodes a query or query_range response body with a "matrix",
+// "vector" or "scalar" result.
+func Parse(body []byte) ([]Series, error) {
+	var resp apiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("query failed: %s: %s", resp.ErrorType, resp.Error)
+	}
+
+	switch resp.Data.ResultType {
+	case "matrix":
+		var results []matrixResult
+		if err := json.Unmarshal(resp.Data.Result, &results); err != nil {
+			return nil, fmt.Errorf("decoding matrix: %w", err)
+		}
+		out := make([]Series, len(results))
+		for i, r := range results {
+			out[i] = Series{Metric: r.Metric, Samples: r.Values, Histograms: r.Histograms}
+		}
+		return out, nil
+	case "vector":
+		var results []vectorResult
+		if err := json.Unmarshal(resp.Data.Result, &results); err != nil {
+			return nil, fmt.Errorf("decoding vector: %w", err)
+		}
+		out := make([]Series, len(results))
+		for i, r := range results {
+			out[i] = Series{Metric: r.Metric}
+			if r.Value != nil {
+				out[i].Samples = []Sample{*r.Value}
+			}
+			if r.Histogram != nil {
+				out[i].Histograms = []HistogramSample{*r.Histogram}
+			}
+		}
+		return out, nil
+	case "scalar":
+		var s Sample
+		if err := json.Unmarshal(resp.Data.Result, &s); err != nil {
+			return nil, fmt.Errorf("decoding scalar: %w", err)
+		}
+		return []Series{{Metric: map[string]string{}, Samples: []Sample{s}}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported result type %q", resp.Data.ResultType)
+	}
+}
+
+// Reduce collapses samples to a single value using reducer ("last", "avg",
+// "max" or "min"). NaN samples are skipped; ok is false when no usable sample
+// remains or the reducer is unknown.
+func Reduce(samples []Sample, reducer string) (value float64, ok bool) {
+	n := 0
+	for _, s := range samples {
+		if math.IsNaN(s.V) {
+			continue
+		}
+		switch {
+		case n == 0:
+			value = s.V
+		case reducer == "last":
+			value = s.V
+		case reducer == "avg":
+			value += s.V
+		case reducer == "max":
+			value = math.Max(value, s.V)
+		case reducer == "min":
+			value = math.Min(value, s.V)
+		default:
+			return 0, false
+		}
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	switch reducer {
+	case "last", "max", "min":
+		return value, true
+	case "avg":
+		return value / float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// VectorResponse builds a Prometheus-style instant query response body. Each
+// series contributes its first sample.
+func VectorResponse(series []Series) ([]byte, error) {
+	result := make([]vectorResult, 0, len(series))
+	for _, s := range series {
+		if len(s.Samples) == 0 {
+			continue
+		}
+		metric := s.Metric
+		if metric == nil {
+			metric = map[string]string{}
+		}
+		result = append(result, vectorResult{Metric: metric, Value: &s.Samples[0]})
+	}
+	return json.Marshal(map[string]any{
+		"status": "success",
+		"data": map[string]any{
+			"resultType": "vector",
+			"result":     result,
+		},
+	})
+}
+
+// MatrixResponse builds a Prometheus-style range query response body from
+// the float samples of series. Series without float samples are left out.
+func MatrixResponse(series []Series) ([]byte, error) {
+	result := make([]matrixResult, 0, len(series))
+	for _, s := range series {
+		if len(s.Samples) == 0 {
+			continue
+		}
+		metric := s.Metric
+		if metric == nil {
+			metric = map[string]string{}
+		}
+		result = append(result, matrixResult{Metric: metric, Values: s.Samples})
+	}
+	return json.Marshal(map[string]any{
+		"status": "success",
+		"data": map[string]any{
+			"resultType": "matrix",
+			"result":     result,
+		},
+	})
+}
+
+// Run is a stretch of consecutive samples, from the timestamp of its first
+// sample to that of its last.
+type Run struct {
+	Start float64
+	End   float64
+}
+
+// NonZeroRuns groups the non-zero, non-NaN samples into runs. Samples no more
+// than step seconds apart belong to the same run.
+func NonZeroRuns(samples []Sample, step float64) []Run {
+	var runs []Run
+	for _, s := range samples {
+		if s.V == 0 || math.IsNaN(s.V) {
+			continue
+		}
+		if n := len(runs); n > 0 && s.T-runs[n-1].End <= step {
+			runs[n-1].End = s.T
+			continue
+		}
+		runs = append(runs, Run{Start: s.T, End: s.T})
+	}
+	return runs
+}
@@ -0,0 +1,141 @@
+package series
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseMatrix(t *testing.T) {
+	body := `{"status":"success","data":{"resultType":"matrix","result":[
+		{"metric":{"cpu":"cpu0"},"values":[[1000,"1.5"],[1015,"2"]]},
+		{"metric":{"cpu":"cpu1"},"values":[[1000.5,"NaN"]]}
+	]}}`
+	got, err := Parse([]byte(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 series, got %d", len(got))
+	}
+	if got[0].Metric["cpu"] != "cpu0" {
+		t.Errorf("expected cpu0, got %v", got[0].Metric)
+	}
+	if len(got[0].Samples) != 2 || got[0].Samples[1] != (Sample{T: 1015, V: 2}) {
+		t.Errorf("unexpected samples %v", got[0].Samples)
+	}
+	if got[1].Samples[0].T != 1000.5 || !math.IsNaN(got[1].Samples[0].V) {
+		t.Errorf("unexpected NaN sample %v", got[1].Samples[0])
+	}
+}
+
+func TestParseVector(t *testing.T) {
+	body := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"api"},"value":[1000,"42"]}]}}`
+	got, err := Parse([]byte(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || len(got[0].Samples) != 1 || got[0].Samples[0].V != 42 {
+		t.Errorf("unexpected result %v", got)
+	}
+}
+
+func TestParseScalar(t *testing.T) {
+	got, err := Parse([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1000,"3"]}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].Samples[0].V != 3 {
+		t.Errorf("unexpected result %v", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `not json`},
+		{"error status", `{"status":"error","errorType":"bad_data","error":"parse error"}`},
+		{"unsupported type", `{"status":"success","data":{"resultType":"streams","result":[]}}`},
+		{"invalid value", `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"x"]}]}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.body)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestReduce(t *testing.T) {
+	samples := []Sample{{1, 3}, {2, 1}, {3, math.NaN()}, {4, 2}}
+	tests := []struct {
+		reducer string
+		want    float64
+	}{
+		{"last", 2},
+		{"avg", 2},
+		{"max", 3},
+		{"min", 1},
+	}
+	for _, tt := range tests {
+		got, ok := Reduce(samples, tt.reducer)
+		if !ok || got != tt.want {
+			t.Errorf("Reduce(%q) = %v, %v; want %v", tt.reducer, got, ok, tt.want)
+		}
+	}
+}
+
+func TestReduceNoValue(t *testing.T) {
+	if _, ok := Reduce(nil, "last"); ok {
+		t.Error("expected no value for empty samples")
+	}
+	if _, ok := Reduce([]Sample{{1, math.NaN()}}, "max"); ok {
+		t.Error("expected no value for NaN-only samples")
+	}
+	if _, ok := Reduce([]Sample{{1, 1}}, "median"); ok {
+		t.Error("expected no value for unknown reducer")
+	}
+}
+
+func TestVectorResponse(t *testing.T) {
+	body, err := VectorResponse([]Series{
+		{Metric: map[string]string{"cpu": "cpu0"}, Samples: []Sample{{T: 1000, V: 1.25}}},
+		{Metric: nil, Samples: []Sample{{T: 1000, V: 2}}},
+		{Metric: map[string]string{"cpu": "empty"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"data":{"result":[{"metric":{"cpu":"cpu0"},"value":[1000,"1.25"]},{"metric":{},"value":[1000,"2"]}],"resultType":"vector"},"status":"success"}`
+	if string(body) != expected {
+		t.Errorf("expected %s, got %s", expected, body)
+	}
+
+	// The output must round-trip through Parse.
+	got, err := Parse(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].Samples[0].V != 1.25 {
+		t.Errorf("unexpected round trip %v", got)
+	}
+}
+
+func TestNonZeroRuns(t *testing.T) {
+	samples := []Sample{
+		{T: 0, V: 0}, {T: 15, V: 1}, {T: 30, V: 2}, {T: 45, V: 0},
+		{T: 60, V: 1}, {T: 75, V: math.NaN()}, {T: 120, V: 1}, {T: 135, V: 1},
+	}
+	got := NonZeroRuns(samples, 15)
+	want := []Run{{Start: 15, End: 30}, {Start: 60, End: 60}, {Start: 120, End: 135}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("run %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
@@ -1,13 +1,16 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/markbates/goth/gothic"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tokuhirom/dashyard/internal/access"
 	"github.com/tokuhirom/dashyard/internal/auth"
 	"github.com/tokuhirom/dashyard/internal/config"
 	"github.com/tokuhirom/dashyard/internal/dashboard"
@@ -27,11 +30,10 @@ func New(cfg *config.Config, holder *dashboard.StoreHolder, frontendFS fs.FS, ho
 		r.Use(metrics.Middleware())
 	}
 
-	// Trusted proxies
-	if len(cfg.Server.TrustedProxies) > 0 {
-		if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
-			return nil, fmt.Errorf("setting trusted proxies: %w", err)
-		}
+	// Trusted proxies. Gin trusts every proxy unless told otherwise, which
+	// would let any client pick the IP that login throttling sees.
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("setting trusted proxies: %w", err)
 	}
 
 	// Session manager
@@ -39,10 +41,57 @@ func New(cfg *config.Config, holder *dashboard.StoreHolder, frontendFS fs.FS, ho
 
 	// Initialize OAuth providers and set gothic store
 	if len(cfg.Auth.OAuth) > 0 {
-		auth.InitGothProviders(cfg.Auth.OAuth)
+		if err := auth.InitGothProviders(cfg.Auth.OAuth); err != nil {
+			return nil, fmt.Errorf("initializing OAuth providers: %w", err)
+		}
 		gothic.Store = sm.Store()
 	}
 
+	// Per-user label policies
+	policies, err := access.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating access policies: %w", err)
+	}
+
+	// Authentication by a trusted reverse proxy
+	var proxyAuth *auth.ProxyAuth
+	if cfg.Auth.Proxy != nil {
+		proxyAuth, err = auth.NewProxyAuth(*cfg.Auth.Proxy, cfg.Server.TrustedProxies)
+		if err != nil {
+			return nil, fmt.Errorf("configuring proxy auth: %w", err)
+		}
+	}
+
+	// Local users, from the config and an htpasswd file
+	if err := auth.ValidateUsers(cfg.Users); err != nil {
+		return nil, err
+	}
+	users := auth.NewUserStore(cfg.Users)
+	if cfg.UsersFile != "" {
+		if err := users.LoadHtpasswd(cfg.UsersFile); err != nil {
+			return nil, fmt.Errorf("loading users_file: %w", err)
+		}
+	}
+
+	// LDAP password checks
+	var ldapAuth *auth.LDAPAuth
+	if cfg.Auth.LDAP != nil {
+		// Only the groups that policies and access rules refer to are kept.
+		usesGroup := func(name string) bool {
+			return policies.UsesGroup(name) || holder.Store().UsesGroup(name)
+		}
+		ldapAuth, err = auth.NewLDAPAuth(*cfg.Auth.LDAP, usesGroup)
+		if err != nil {
+			return nil, fmt.Errorf("configuring LDAP auth: %w", err)
+		}
+	}
+
+	// API tokens
+	var tokenAuth *auth.TokenAuth
+	if len(cfg.Auth.APITokens) > 0 {
+		tokenAuth = auth.NewTokenAuth(cfg.Auth.APITokens)
+	}
+
 	// Datasource registry
 	registry, err := datasource.NewRegistry(cfg.Datasources)
 	if err != nil {
@@ -50,14 +99,33 @@ func New(cfg *config.Config, holder *dashboard.StoreHolder, frontendFS fs.FS, ho
 	}
 
 	// Handlers
-	loginHandler := handler.NewLoginHandler(cfg.Users, sm)
+	loginHandler := handler.NewLoginHandler(users, sm, ldapAuth, auth.NewLoginThrottle(cfg.Auth.LoginRateLimit))
 	dashboardsHandler := handler.NewDashboardsHandler(holder, cfg.SiteTitle, cfg.HeaderColor)
 	queryHandler := handler.NewQueryHandler(registry)
+	panelQueryHandler := handler.NewPanelQueryHandler(holder, registry)
+	instantQueryHandler := handler.NewInstantQueryHandler(registry)
+	reduceHandler := handler.NewReduceHandler(registry)
+	heatmapHandler := handler.NewHeatmapHandler(registry)
+	annotationsHandler := handler.NewAnnotationsHandler(holder, registry)
 	labelValuesHandler := handler.NewLabelValuesHandler(registry)
+	variableValuesHandler := handler.NewVariableValuesHandler(registry)
+	logsHandler := handler.NewLogsHandler(registry)
 	datasourcesHandler := handler.NewDatasourcesHandler(registry)
 	readyHandler := handler.NewReadyHandler(registry)
 	staticHandler := handler.NewStaticHandler(frontendFS)
-	authInfoHandler := handler.NewAuthInfoHandler(cfg.Users, cfg.Auth.OAuth)
+	authInfoHandler := handler.NewAuthInfoHandler(cfg.Users, cfg.Auth.OAuth, cfg.UsersFile != "" || ldapAuth != nil)
+
+	// In locked-down query mode only the queries of the loaded dashboards run.
+	queryGuard := func(*gin.Context) {}
+	labelValuesGuard := func(*gin.Context) {}
+	if cfg.Server.LockedQueries {
+		queryGuard = handler.RequireDashboardQuery(holder, registry.DefaultName())
+		labelValuesGuard = handler.RequireDashboardLabelValues(holder, registry.DefaultName())
+	}
+	// API tokens with only the dashboards scope are locked down regardless.
+	queryGuard = auth.QueryScope(queryGuard, handler.RequireDashboardQuery(holder, registry.DefaultName()))
+	labelValuesGuard = auth.QueryScope(labelValuesGuard, handler.RequireDashboardLabelValues(holder, registry.DefaultName()))
+	dashboardsScope := auth.RequireScope(config.ScopeDashboards)
 
 	// Public routes
 	r.GET("/ready", readyHandler.Handle)
@@ -77,13 +145,20 @@ func New(cfg *config.Config, holder *dashboard.StoreHolder, frontendFS fs.FS, ho
 
 	// Authenticated API routes
 	api := r.Group("/api")
-	api.Use(auth.AuthMiddleware(sm))
+	api.Use(auth.AuthMiddleware(sm, proxyAuth, tokenAuth), access.Middleware(policies))
 	{
-		api.GET("/dashboards", dashboardsHandler.List)
-		api.GET("/dashboards/*path", dashboardsHandler.Get)
-		api.GET("/dashboard-source/*path", dashboardsHandler.GetSource)
-		api.GET("/query", queryHandler.Handle)
-		api.GET("/label-values", labelValuesHandler.Handle)
+		api.GET("/dashboards", dashboardsScope, dashboardsHandler.List)
+		api.GET("/dashboards/*path", dashboardsScope, dashboardsHandler.Get)
+		api.GET("/dashboard-source/*path", dashboardsScope, dashboardsHandler.GetSource)
+		api.GET("/query", queryGuard, queryHandler.Handle)
+		api.GET("/panel-query", dashboardsScope, panelQueryHandler.Handle)
+		api.GET("/instant-query", queryGuard, instantQueryHandler.Handle)
+		api.GET("/reduce", queryGuard, reduceHandler.Handle)
+		api.GET("/heatmap", queryGuard, heatmapHandler.Handle)
+		api.GET("/annotations", dashboardsScope, annotationsHandler.Handle)
+		api.GET("/label-values", labelValuesGuard, labelValuesHandler.Handle)
+		api.GET("/variable-values", queryGuard, variableValuesHandler.Handle)
+		api.GET("/logs", queryGuard, logsHandler.Handle)
 		api.GET("/datasources", datasourcesHandler.Handle)
 	}
 
@@ -92,8 +167,21 @@ func New(cfg *config.Config, holder *dashboard.StoreHolder, frontendFS fs.FS, ho
 
 	addr := fmt.Sprintf("%s:%d", host, port)
 
-	return &http.Server{
+	srv := &http.Server{
 		Addr:    addr,
 		Handler: r,
-	}, nil
+	}
+
+	// Reload the htpasswd file on changes until the server shuts down.
+	if cfg.UsersFile != "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		srv.RegisterOnShutdown(cancel)
+		go func() {
+			if err := users.Watch(ctx); err != nil {
+				slog.Error("htpasswd watcher error", "error", err)
+			}
+		}()
+	}
+
+	return srv, nil
 }
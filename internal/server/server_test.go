@@ -3,10 +3,12 @@ package server
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"testing/fstest"
 	"time"
 
+	"github.com/tokuhirom/dashyard/internal/auth"
 	"github.com/tokuhirom/dashyard/internal/config"
 	"github.com/tokuhirom/dashyard/internal/dashboard"
 )
@@ -134,6 +136,89 @@ func TestAuthenticatedRoutesRequireAuth(t *testing.T) {
 	}
 }
 
+func TestAPITokenScopes(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Auth.APITokens = []config.APIToken{
+		{Name: "wall", TokenHash: auth.HashToken("dashyard_wall"), Scopes: []string{config.ScopeDashboards}},
+		{Name: "script", TokenHash: auth.HashToken("dashyard_script"), Scopes: []string{config.ScopeQuery}},
+	}
+	srv, err := New(cfg, emptyHolder(), emptyFS(), "127.0.0.1", 0, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		token string
+		path  string
+		want  int
+	}{
+		{"dashyard_wall", "/api/dashboards", http.StatusOK},
+		{"dashyard_wall", "/api/datasources", http.StatusOK},
+		{"dashyard_wall", "/api/query?query=up&start=1&end=2&step=1s", http.StatusForbidden},
+		{"dashyard_wall", "/api/label-values?label=job", http.StatusForbidden},
+		{"dashyard_script", "/api/dashboards", http.StatusForbidden},
+		{"dashyard_script", "/api/datasources", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			resp := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(resp, req)
+
+			if resp.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, resp.Code)
+			}
+		})
+	}
+}
+
+func TestLoginThrottleClientIP(t *testing.T) {
+	login := func(srv *http.Server, forwardedFor, userID string) int {
+		req := httptest.NewRequest("POST", "/api/login", strings.NewReader(`{"user_id":"`+userID+`","password":"wrong"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.RemoteAddr = "192.0.2.1:1234"
+		resp := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		want           int
+	}{
+		// X-Forwarded-For from anyone else cannot dodge the throttling.
+		{"untrusted client", nil, http.StatusTooManyRequests},
+		{"trusted proxy", []string{"192.0.2.1"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := minimalConfig()
+			cfg.Server.TrustedProxies = tt.trustedProxies
+			cfg.Auth.LoginRateLimit = config.LoginRateLimitConfig{
+				MaxFailuresPerIP:   20,
+				MaxFailuresPerUser: 5,
+				Backoff:            time.Hour,
+				MaxBackoff:         time.Hour,
+				Lockout:            time.Hour,
+			}
+			srv, err := New(cfg, emptyHolder(), emptyFS(), "127.0.0.1", 0, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if code := login(srv, "203.0.113.1", "alice"); code != http.StatusUnauthorized {
+				t.Fatalf("expected 401, got %d", code)
+			}
+			if code := login(srv, "203.0.113.2", "bob"); code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, code)
+			}
+		})
+	}
+}
+
 func TestServerAddress(t *testing.T) {
 	cfg := minimalConfig()
 	srv, err := New(cfg, emptyHolder(), emptyFS(), "0.0.0.0", 8080, false)
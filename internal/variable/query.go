@@ -0,0 +1,80 @@
+// Package variable resolves dashboard variables: it parses the supported
+// query forms, filters and sorts the values the datasource returns, and
+// substitutes selections into queries.
+package variable
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Query kinds.
+const (
+	LabelValues = "label_values" // label_values(label) or label_values(selector, label)
+	LabelNames  = "label_names"  // label_names() or label_names(selector)
+	Metrics     = "metrics"      // metrics(regex)
+	QueryResult = "query_result" // query_result(promql)
+)
+
+// Query is a parsed variable query.
+type Query struct {
+	Kind string
+	// Match is the series selector of label_values and label_names, if any.
+	Match string
+	// Label is the label whose values label_values lists.
+	Label string
+	// Regex is the metric name pattern of metrics.
+	Regex string
+	// PromQL is the expression query_result evaluates.
+	PromQL string
+}
+
+var (
+	queryRe = regexp.MustCompile(`(?s)^(\w+)\s*\((.*)\)$`)
+	labelRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// ParseQuery parses a variable query in one of the forms label_values,
+// label_names, metrics or query_result.
+func ParseQuery(s string) (Query, error) {
+	m := queryRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Query{}, fmt.Errorf("invalid variable query %q (expected label_values, label_names, metrics or query_result)", s)
+	}
+	args := strings.TrimSpace(m[2])
+
+	switch m[1] {
+	case LabelValues:
+		q := Query{Kind: LabelValues, Label: args}
+		// The label follows the last comma; the selector may contain commas itself.
+		if i := strings.LastIndex(args, ","); i >= 0 {
+			q.Match = strings.TrimSpace(args[:i])
+			q.Label = strings.TrimSpace(args[i+1:])
+			if q.Match == "" {
+				return Query{}, fmt.Errorf("invalid variable query %q: empty selector", s)
+			}
+		}
+		if !labelRe.MatchString(q.Label) {
+			return Query{}, fmt.Errorf("invalid variable query %q: invalid label name %q", s, q.Label)
+		}
+		return q, nil
+	case LabelNames:
+		return Query{Kind: LabelNames, Match: args}, nil
+	case Metrics:
+		if args == "" {
+			return Query{}, fmt.Errorf("invalid variable query %q: metrics needs a regex", s)
+		}
+		if _, err := regexp.Compile(args); err != nil {
+			return Query{}, fmt.Errorf("invalid variable query %q: %w", s, err)
+		}
+		return Query{Kind: Metrics, Regex: args}, nil
+	case QueryResult:
+		if args == "" {
+			return Query{}, fmt.Errorf("invalid variable query %q: query_result needs a query", s)
+		}
+		return Query{Kind: QueryResult, PromQL: args}, nil
+	default:
+		return Query{}, fmt.Errorf("invalid variable query %q: unknown function %q (expected label_values, label_names, metrics or query_result)", s, m[1])
+	}
+}
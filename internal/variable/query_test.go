@@ -0,0 +1,65 @@
+package variable
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Query
+	}{
+		{"label_values(up, instance)", Query{Kind: LabelValues, Match: "up", Label: "instance"}},
+		{"label_values(  up  ,  instance  )", Query{Kind: LabelValues, Match: "up", Label: "instance"}},
+		{"label_values(job)", Query{Kind: LabelValues, Label: "job"}},
+		{
+			`label_values(kube_pod_info{namespace="default", node=~"a|b"}, pod)`,
+			Query{Kind: LabelValues, Match: `kube_pod_info{namespace="default", node=~"a|b"}`, Label: "pod"},
+		},
+		{"label_names()", Query{Kind: LabelNames}},
+		{`label_names(up{job="node"})`, Query{Kind: LabelNames, Match: `up{job="node"}`}},
+		{"metrics(node_.*)", Query{Kind: Metrics, Regex: "node_.*"}},
+		{"query_result(topk(5, sum by (job) (up)))", Query{Kind: QueryResult, PromQL: "topk(5, sum by (job) (up))"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseQuery(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr string
+	}{
+		{"", "invalid variable query"},
+		{"not a query", "invalid variable query"},
+		{"label_values()", `invalid label name ""`},
+		{"label_values(metric, )", `invalid label name ""`},
+		{"label_values(, job)", "empty selector"},
+		{"label_values(up, in-stance)", `invalid label name "in-stance"`},
+		{"metrics()", "metrics needs a regex"},
+		{"metrics(node_(.*)", "missing closing )"},
+		{"query_result()", "query_result needs a query"},
+		{"series(up)", `unknown function "series"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			_, err := ParseQuery(tt.in)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
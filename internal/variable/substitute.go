@@ -0,0 +1,208 @@
+package variable
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// Selection is what a variable stands for in a query.
+type Selection struct {
+	// Values are the selected values.
+	Values []string
+	// Regex, if set, is substituted verbatim in regex matchers instead of the
+	// escaped values, e.g. the all_value ".*" of an "All" selection.
+	Regex string
+	// Verbatim substitutes the single value unescaped in regex matchers, for
+	// variables that take a single value, which may be a regex such as
+	// "eth.*".
+	Verbatim bool
+}
+
+// A value outside a quoted string must be a single PromQL token, so that it
+// cannot change the structure of the query: an unsigned decimal number, a
+// duration or a name. Signs are left to the template, since "5m-foo" or
+// "1+secret_metric" would turn the surrounding expression into a binary
+// operation.
+const (
+	numberPattern   = `(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+)(?:[eE][+-]?[0-9]+)?`
+	durationPattern = `(?:[0-9]+(?:ms|[smhdwy]))+`
+	namePattern     = `[a-zA-Z_:][a-zA-Z0-9_:]*`
+	barePattern     = `(?:` + numberPattern + `|` + durationPattern + `|` + namePattern + `)`
+)
+
+var (
+	refRe    = regexp.MustCompile(`^\$(?:\{(\w+)\}|(\w+))`)
+	numberRe = regexp.MustCompile(`^` + numberPattern + `$`)
+	nameRe   = regexp.MustCompile(`^` + namePattern + `$`)
+)
+
+// Substitute replaces $name and ${name} in a PromQL template with the
+// selections, escaped for where they appear:
+//
+//   - in the string of a =~ or !~ matcher, the values as an alternation of
+//     regex-escaped values such as "a\\.b|c", the Regex of the selection, or
+//     the value itself if Verbatim;
+//   - in any other string, the single value, escaped for the string;
+//   - elsewhere, the single value, which must be a name, an unsigned number
+//     or a duration.
+//
+// References to names without a selection are left as they are.
+func Substitute(template string, selections map[string]Selection) (string, error) {
+	var b strings.Builder
+	for _, seg := range scan(template, func(name string) bool { _, ok := selections[name]; return ok }) {
+		if seg.name == "" {
+			b.WriteString(seg.text)
+			continue
+		}
+		text, err := format(seg.name, selections[seg.name], seg.quote, seg.regex)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(text)
+	}
+	return b.String(), nil
+}
+
+// Pattern returns a regular expression, unanchored, that matches every query
+// the template can become once the named variables are replaced by values a
+// client could select: any properly escaped text inside strings, and a name,
+// number or duration elsewhere. References to other names must appear as they
+// are. The pattern lets a query be traced back to its template without
+// knowing the selections.
+func Pattern(template string, names []string) string {
+	var b strings.Builder
+	for _, seg := range scan(template, func(name string) bool { return slices.Contains(names, name) }) {
+		switch {
+		case seg.name == "":
+			b.WriteString(regexp.QuoteMeta(seg.text))
+		case seg.quote == '`':
+			b.WriteString("[^`]*")
+		case seg.quote != 0:
+			q := regexp.QuoteMeta(string(seg.quote))
+			b.WriteString(`(?:[^` + q + `\\]|\\.)*`)
+		default:
+			b.WriteString(barePattern)
+		}
+	}
+	return b.String()
+}
+
+// segment is a piece of a template: literal text, or a reference to a
+// variable along with the string it appears in.
+type segment struct {
+	text  string // the literal text, or the reference as written
+	name  string // the referenced variable, empty for literal text
+	quote byte   // the quote of the enclosing string, 0 outside strings
+	regex bool   // whether that string is the value of a regex matcher
+}
+
+// scan splits a template into literal text and references to the variables
+// known reports, tracking the PromQL string each reference appears in.
+func scan(template string, known func(name string) bool) []segment {
+	var segs []segment
+	var lit strings.Builder
+	var quote byte // the quote of the string being scanned, 0 outside strings
+	var regex bool // whether that string is the value of a regex matcher
+	for i := 0; i < len(template); {
+		c := template[i]
+		if c == '$' {
+			if m := refRe.FindStringSubmatch(template[i:]); m != nil {
+				if name := m[1] + m[2]; known(name) {
+					if lit.Len() > 0 {
+						segs = append(segs, segment{text: lit.String()})
+						lit.Reset()
+					}
+					segs = append(segs, segment{text: m[0], name: name, quote: quote, regex: regex})
+					i += len(m[0])
+					continue
+				}
+			}
+		}
+
+		lit.WriteByte(c)
+		i++
+		switch {
+		case quote == 0 && (c == '"' || c == '\'' || c == '`'):
+			quote = c
+			regex = isRegexOperator(template[:i-1])
+		case quote != 0 && c == '\\' && quote != '`' && i < len(template):
+			lit.WriteByte(template[i])
+			i++
+		case c == quote:
+			quote = 0
+		}
+	}
+	if lit.Len() > 0 {
+		segs = append(segs, segment{text: lit.String()})
+	}
+	return segs
+}
+
+// isRegexOperator reports whether s ends with a =~ or !~ operator, ignoring
+// trailing whitespace.
+func isRegexOperator(s string) bool {
+	s = strings.TrimRight(s, " \t\n")
+	return strings.HasSuffix(s, "=~") || strings.HasSuffix(s, "!~")
+}
+
+func format(name string, sel Selection, quote byte, regex bool) (string, error) {
+	if sel.Regex == "" && len(sel.Values) == 0 {
+		return "", fmt.Errorf("variable %q has no value", name)
+	}
+	if quote != 0 && regex {
+		text := sel.Regex
+		if text == "" && sel.Verbatim && len(sel.Values) == 1 {
+			text = sel.Values[0]
+		}
+		if text == "" {
+			escaped := make([]string, len(sel.Values))
+			for i, v := range sel.Values {
+				escaped[i] = regexp.QuoteMeta(v)
+			}
+			text = strings.Join(escaped, "|")
+		}
+		return escapeString(name, text, quote)
+	}
+
+	if sel.Regex != "" || len(sel.Values) > 1 {
+		return "", fmt.Errorf("variable %q has several values and can only be used in a =~ or !~ matcher", name)
+	}
+	value := sel.Values[0]
+	if quote != 0 {
+		return escapeString(name, value, quote)
+	}
+	if !isBare(value) {
+		return "", fmt.Errorf("variable %q value %q is not a name, number or duration; quote it to use it as a string", name, value)
+	}
+	return value, nil
+}
+
+// isBare reports whether value can be substituted outside a quoted string: a
+// name, a duration, or a number without a sign.
+func isBare(value string) bool {
+	if nameRe.MatchString(value) {
+		return true
+	}
+	if _, err := model.ParseDuration(value); err == nil {
+		return true
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil && numberRe.MatchString(value)
+}
+
+// escapeString escapes s for a PromQL string delimited by quote.
+func escapeString(name, s string, quote byte) (string, error) {
+	if quote == '`' {
+		if strings.Contains(s, "`") {
+			return "", fmt.Errorf("variable %q value contains a backtick and cannot be used in a raw string", name)
+		}
+		return s, nil
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, string(quote), `\`+string(quote)), nil
+}
@@ -0,0 +1,141 @@
+package variable
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSubstitute(t *testing.T) {
+	selections := map[string]Selection{
+		"job":      {Values: []string{"api"}},
+		"host":     {Values: []string{"web-1.example.com"}},
+		"device":   {Values: []string{"eth0", "eth1"}},
+		"path":     {Values: []string{`/say "hi"`}},
+		"all":      {Values: []string{"a", "b"}, Regex: ".*"},
+		"window":   {Values: []string{"5m"}},
+		"jobs":     {Values: []string{"api"}},
+		"backtick": {Values: []string{"a.b"}},
+		"ratio":    {Values: []string{"0.95"}},
+		"tiny":     {Values: []string{"1e-05"}},
+		"span":     {Values: []string{"1h30m"}},
+		"pattern":  {Values: []string{"eth.*"}, Verbatim: true},
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"equality matcher", `up{job="$job"}`, `up{job="api"}`},
+		{"braced reference", `up{job="${job}"}`, `up{job="api"}`},
+		{"longer names are not prefixes", `up{job=~"$jobs"}`, `up{job=~"api"}`},
+		{"regex matcher escapes metacharacters", `up{instance=~"$host:.*"}`, `up{instance=~"web-1\\.example\\.com:.*"}`},
+		{"multi-value alternation", `rate(x{device=~"$device"}[5m])`, `rate(x{device=~"eth0|eth1"}[5m])`},
+		{"negative regex matcher", `x{device !~ "$device"}`, `x{device !~ "eth0|eth1"}`},
+		{"all value", `x{a=~"$all"}`, `x{a=~".*"}`},
+		{"verbatim value in a regex matcher", `x{device=~"$pattern"}`, `x{device=~"eth.*"}`},
+		{"verbatim value in other strings", `x{device="$pattern"}`, `x{device="eth.*"}`},
+		{"string escaping", `x{path="$path"}`, `x{path="/say \"hi\""}`},
+		{"single quotes", `x{path='$path'}`, `x{path='/say "hi"'}`},
+		{"raw string", "x{a=~`$backtick`}", "x{a=~`a\\.b`}"},
+		{"bare duration", `rate(x[$window])`, `rate(x[5m])`},
+		{"bare label name", `sum by ($job) (x)`, `sum by (api) (x)`},
+		{"bare number", `x > $ratio`, `x > 0.95`},
+		{"bare number with exponent", `x > $tiny`, `x > 1e-05`},
+		{"bare compound duration", `x offset $span`, `x offset 1h30m`},
+		{"unknown variables are kept", `x{a="$unknown", b="$__interval"}`, `x{a="$unknown", b="$__interval"}`},
+		{"escaped quote keeps the string open", `label_replace(x, "a", "\"$job", "b", "")`, `label_replace(x, "a", "\"api", "b", "")`},
+		{"regex context ends with the string", `x{a=~"$job", b="$job"}`, `x{a=~"api", b="api"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Substitute(tt.template, selections)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSubstituteErrors(t *testing.T) {
+	selections := map[string]Selection{
+		"device": {Values: []string{"eth0", "eth1"}},
+		"all":    {Values: []string{"a"}, Regex: ".*"},
+		"evil":   {Values: []string{`x) or vector(1`}},
+		"plus":   {Values: []string{"1+secret_metric"}},
+		"minus":  {Values: []string{"5m-foo"}},
+		"signed": {Values: []string{"-1"}},
+		"empty":  {Values: []string{""}},
+		"tick":   {Values: []string{"a`b"}},
+		"none":   {},
+	}
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{`x{device="$device"}`, `variable "device" has several values`},
+		{`x{a="$all"}`, `variable "all" has several values`},
+		{`sum by ($device) (x)`, `variable "device" has several values`},
+		{`sum(rate(x[5m])) by ($evil)`, `is not a name, number or duration`},
+		{`x > $plus`, `is not a name, number or duration`},
+		{`rate(x[$minus])`, `is not a name, number or duration`},
+		{`x $signed`, `is not a name, number or duration`},
+		{`x > $empty`, `is not a name, number or duration`},
+		{"x{a=~`$tick`}", "contains a backtick"},
+		{`x{a="$none"}`, `variable "none" has no value`},
+		{`x{a=~"$none"}`, `variable "none" has no value`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			_, err := Substitute(tt.template, selections)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPattern(t *testing.T) {
+	names := []string{"job", "window"}
+	tests := []struct {
+		name     string
+		template string
+		query    string
+		want     bool
+	}{
+		{"template itself without variables", `up`, `up`, true},
+		{"other queries", `up`, `down`, false},
+		{"string value", `up{job="$job"}`, `up{job="api"}`, true},
+		{"regex alternation", `up{job=~"$job"}`, `up{job=~"api|web\\.1"}`, true},
+		{"escaped quote in a string", `up{job="$job"}`, `up{job="a\"b"}`, true},
+		{"closing the string", `up{job="$job"}`, `up{job="api"} or vector(1) or up{job=""}`, false},
+		{"single quotes", `up{job='$job'}`, `up{job='a"b'}`, true},
+		{"closing single quotes", `up{job='$job'}`, `up{job='a' or up{job=''}`, false},
+		{"raw string", "up{job=~`$job`}", "up{job=~`a\\.b`}", true},
+		{"closing a raw string", "up{job=~`$job`}", "up{job=~`a` or x{a=``}", false},
+		{"bare duration", `rate(x[$window])`, `rate(x[5m])`, true},
+		{"bare expression", `rate(x[$window])`, `rate(x[5m] or y[5m])`, false},
+		{"bare number", `x > $window`, `x > 0.5`, true},
+		{"bare name", `sum by ($job) (x)`, `sum by (instance) (x)`, true},
+		{"plus injection", `x > $window`, `x > 1+secret_metric`, false},
+		{"minus injection", `rate(x[$window])`, `rate(x[5m-foo])`, false},
+		{"signed number", `x $window`, `x -1`, false},
+		{"unknown references are literal", `up{job="$other"}`, `up{job="$other"}`, true},
+		{"unknown references are not wildcards", `up{job="$other"}`, `up{job="api"}`, false},
+		{"literal metacharacters", `sum(rate(x[5m])) * 100`, `sum(rate(x[5m])) * 1000`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re := regexp.MustCompile(`^(?s:` + Pattern(tt.template, names) + `)$`)
+			if got := re.MatchString(tt.query); got != tt.want {
+				t.Errorf("Pattern(%q) matches %q = %v, want %v", tt.template, tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package variable
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tokuhirom/dashyard/internal/series"
+)
+
+// Sort orders.
+const (
+	SortAlpha   = "alpha"
+	SortNumeric = "numeric"
+	SortNatural = "natural"
+)
+
+// ValidSort reports whether order is a supported sort order.
+func ValidSort(order string) bool {
+	return order == SortAlpha || order == SortNumeric || order == SortNatural
+}
+
+// Filter keeps the values matching re. When re has capture groups, the text
+// of the group named "value", or else of the first group, replaces the value.
+// Duplicates are dropped, keeping the first occurrence. A nil re keeps
+// every value.
+func Filter(values []string, re *regexp.Regexp) []string {
+	group := 0
+	if re != nil && re.NumSubexp() > 0 {
+		group = 1
+		if i := re.SubexpIndex("value"); i > 0 {
+			group = i
+		}
+	}
+
+	out := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, v := range values {
+		if re != nil {
+			m := re.FindStringSubmatch(v)
+			if m == nil {
+				continue
+			}
+			if group > 0 {
+				v = m[group]
+			}
+		}
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Sort sorts values in place by order, descending if desc. An empty order
+// keeps the datasource order.
+func Sort(values []string, order string, desc bool) error {
+	var less func(a, b string) bool
+	switch order {
+	case "":
+		return nil
+	case SortAlpha:
+		less = func(a, b string) bool { return a < b }
+	case SortNumeric:
+		less = numericLess
+	case SortNatural:
+		less = naturalLess
+	default:
+		return fmt.Errorf("unknown sort order %q (expected alpha, numeric or natural)", order)
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		if desc {
+			return less(values[j], values[i])
+		}
+		return less(values[i], values[j])
+	})
+	return nil
+}
+
+// numericLess orders numbers by value, before any non-numeric values, which
+// are ordered alphabetically.
+func numericLess(a, b string) bool {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return fa < fb
+	case errA == nil:
+		return true
+	case errB == nil:
+		return false
+	default:
+		return a < b
+	}
+}
+
+// naturalLess compares runs of digits by their numeric value and everything
+// else case-insensitively, so that "pod-2" sorts before "pod-10".
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		ca, restA := nextChunk(a)
+		cb, restB := nextChunk(b)
+		if ca != cb {
+			da, db := isDigits(ca), isDigits(cb)
+			switch {
+			case da && db:
+				na, nb := strings.TrimLeft(ca, "0"), strings.TrimLeft(cb, "0")
+				if len(na) != len(nb) {
+					return len(na) < len(nb)
+				}
+				if na != nb {
+					return na < nb
+				}
+			default:
+				la, lb := strings.ToLower(ca), strings.ToLower(cb)
+				if la != lb {
+					return la < lb
+				}
+			}
+		}
+		a, b = restA, restB
+	}
+	return len(a) < len(b)
+}
+
+// nextChunk splits s after its leading run of digits or of non-digits.
+func nextChunk(s string) (chunk, rest string) {
+	digit := unicode.IsDigit(rune(s[0]))
+	i := 1
+	for i < len(s) && unicode.IsDigit(rune(s[i])) == digit {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isDigits(s string) bool {
+	return s != "" && unicode.IsDigit(rune(s[0]))
+}
+
+// ResultLines formats the series of a query_result instant query as one line
+// each, `name{label="value", ...} value timestamp` with the timestamp in
+// milliseconds, for a regex to pick values from.
+func ResultLines(in []series.Series) []string {
+	lines := make([]string, 0, len(in))
+	for _, s := range in {
+		names := make([]string, 0, len(s.Metric))
+		for name := range s.Metric {
+			if name != "__name__" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		var b strings.Builder
+		b.WriteString(s.Metric["__name__"])
+		b.WriteByte('{')
+		for i, name := range names {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%s=%q", name, s.Metric[name])
+		}
+		b.WriteByte('}')
+		if len(s.Samples) > 0 {
+			sample := s.Samples[len(s.Samples)-1]
+			fmt.Fprintf(&b, " %s %d", strconv.FormatFloat(sample.V, 'f', -1, 64), int64(sample.T*1000))
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
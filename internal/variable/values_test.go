@@ -0,0 +1,82 @@
+package variable
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/tokuhirom/dashyard/internal/series"
+)
+
+func TestFilter(t *testing.T) {
+	values := []string{"web-1:8080", "web-2:8080", "db-1:5432", "web-1:9090"}
+	tests := []struct {
+		name  string
+		regex string
+		want  []string
+	}{
+		{"no regex", "", values},
+		{"match", "^web", []string{"web-1:8080", "web-2:8080", "web-1:9090"}},
+		{"capture group", `^(web-\d+):`, []string{"web-1", "web-2"}},
+		{"named value group", `^(?P<host>\w+)-(?P<value>\d+)`, []string{"1", "2"}},
+		{"no match", "^cache", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var re *regexp.Regexp
+			if tt.regex != "" {
+				re = regexp.MustCompile(tt.regex)
+			}
+			got := Filter(values, re)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	values := []string{"pod-10", "Pod-9", "pod-2", "10", "9", "1.5"}
+	tests := []struct {
+		order string
+		desc  bool
+		want  []string
+	}{
+		{"", false, values},
+		{SortAlpha, false, []string{"1.5", "10", "9", "Pod-9", "pod-10", "pod-2"}},
+		{SortAlpha, true, []string{"pod-2", "pod-10", "Pod-9", "9", "10", "1.5"}},
+		{SortNumeric, false, []string{"1.5", "9", "10", "Pod-9", "pod-10", "pod-2"}},
+		{SortNatural, false, []string{"1.5", "9", "10", "pod-2", "Pod-9", "pod-10"}},
+		{SortNatural, true, []string{"pod-10", "Pod-9", "pod-2", "10", "9", "1.5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			got := slices.Clone(values)
+			if err := Sort(got, tt.order, tt.desc); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if err := Sort(values, "random", false); err == nil {
+		t.Error("expected error for unknown sort order")
+	}
+}
+
+func TestResultLines(t *testing.T) {
+	in := []series.Series{
+		{Metric: map[string]string{"__name__": "up", "job": "node", "instance": "a:9100"}, Samples: []series.Sample{{T: 1700000000, V: 1}}},
+		{Metric: map[string]string{"job": "api"}, Samples: []series.Sample{{T: 1700000000.5, V: 0.25}}},
+	}
+	got := ResultLines(in)
+	want := []string{
+		`up{instance="a:9100", job="node"} 1 1700000000000`,
+		`{job="api"} 0.25 1700000000500`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -1,24 +1,28 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"embed"
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
-	"github.com/GehirnInc/crypt"
-	_ "github.com/GehirnInc/crypt/sha512_crypt"
 	"github.com/alecthomas/kong"
+	"github.com/tokuhirom/dashyard/internal/auth"
 	"github.com/tokuhirom/dashyard/internal/config"
 	"github.com/tokuhirom/dashyard/internal/dashboard"
 	"github.com/tokuhirom/dashyard/internal/metrics"
 	"github.com/tokuhirom/dashyard/internal/server"
+	"gopkg.in/yaml.v3"
 )
 
 //go:embed frontend/dist/*
@@ -32,7 +36,8 @@ var cli struct {
 
 	Serve      ServeCmd      `cmd:"" help:"Start the dashboard server."`
 	Validate   ValidateCmd   `cmd:"" help:"Validate config or dashboard files."`
-	Mkpasswd   MkpasswdCmd   `cmd:"" help:"Generate a SHA-512 crypt password hash."`
+	Mkpasswd   MkpasswdCmd   `cmd:"" help:"Generate a password hash."`
+	Mktoken    MktokenCmd    `cmd:"" help:"Generate an API token and its config entry."`
 	GenPrompt GenPromptCmd `cmd:"gen-prompt" help:"Generate an LLM prompt for dashboard YAML generation from Prometheus metrics."`
 }
 
@@ -125,10 +130,13 @@ type ValidateConfigCmd struct {
 }
 
 func (cmd *ValidateConfigCmd) Run() error {
-	_, err := config.Load(cmd.Path)
+	cfg, err := config.Load(cmd.Path)
 	if err != nil {
 		return fmt.Errorf("config %s: %w", cmd.Path, err)
 	}
+	if err := auth.ValidateUsers(cfg.Users); err != nil {
+		return fmt.Errorf("config %s: %w", cmd.Path, err)
+	}
 	fmt.Printf("Config OK: %s\n", cmd.Path)
 	return nil
 }
@@ -147,12 +155,23 @@ func (cmd *ValidateDashboardsCmd) Run() error {
 }
 
 type MkpasswdCmd struct {
-	Password string `arg:"" help:"Password to hash."`
+	Password string `arg:"" optional:"" help:"Password to hash. Read from the first line of stdin if omitted."`
+	Algo     string `help:"Hash algorithm: sha512 (SHA-512 crypt), bcrypt or argon2id." enum:"sha512,bcrypt,argon2id" default:"sha512"`
 }
 
 func (cmd *MkpasswdCmd) Run() error {
-	c := crypt.SHA512.New()
-	hash, err := c.Generate([]byte(cmd.Password), nil)
+	password := cmd.Password
+	if password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+		if password == "" {
+			return fmt.Errorf("no password given")
+		}
+	}
+	hash, err := auth.HashPassword(password, cmd.Algo)
 	if err != nil {
 		return fmt.Errorf("failed to generate hash: %w", err)
 	}
@@ -160,6 +179,36 @@ func (cmd *MkpasswdCmd) Run() error {
 	return nil
 }
 
+type MktokenCmd struct {
+	Name    string        `arg:"" help:"Name of the token, e.g. the script or display using it."`
+	Scope   []string      `help:"Scope of the token: dashboards (view dashboards) or query (run any query). Can be specified multiple times." enum:"dashboards,query" default:"dashboards"`
+	Expires time.Duration `help:"Lifetime of the token, e.g. 720h (default: no expiry)."`
+}
+
+func (cmd *MktokenCmd) Run() error {
+	token, err := auth.GenerateToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	entry := config.APIToken{
+		Name:      cmd.Name,
+		TokenHash: auth.HashToken(token),
+		Scopes:    cmd.Scope,
+	}
+	if cmd.Expires > 0 {
+		expiresAt := time.Now().Add(cmd.Expires).UTC().Truncate(time.Second)
+		entry.ExpiresAt = &expiresAt
+	}
+	snippet, err := yaml.Marshal([]config.APIToken{entry})
+	if err != nil {
+		return fmt.Errorf("failed to encode config entry: %w", err)
+	}
+
+	fmt.Printf("Token (shown only once, send it as \"Authorization: Bearer <token>\"):\n\n  %s\n\n", token)
+	fmt.Printf("Add this to auth.api_tokens in the config:\n\n%s", snippet)
+	return nil
+}
+
 func main() {
 	kctx := kong.Parse(&cli,
 		kong.Name("dashyard"),